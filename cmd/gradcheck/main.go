@@ -0,0 +1,253 @@
+// Command gradcheck compares the analytic gradients of GoTen's ops and layers
+// against central differences and reports the largest error of every input.
+//
+// Usage:
+//
+//	gradcheck [-run regexp] [-eps 1e-6] [-atol 1e-5] [-rtol 1e-3] [-v]
+//
+// It exits with status 1 if any check fails.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+)
+
+// check computes the gradient check results of one op, named after its inputs.
+type check struct {
+	name   string
+	inputs []string
+	run    func(opts engine.GradCheckOptions) ([]engine.GradCheckResult, error)
+}
+
+var gen = engine.NewGenerator(1)
+
+func randn(shape ...int) *engine.Tensor {
+	t, err := engine.Normal(gen, shape, 0, 1)
+	if err != nil {
+		log.Fatalf("Failed to create input: %v", err)
+	}
+	return t
+}
+
+func positive(shape ...int) *engine.Tensor {
+	t, err := engine.Uniform(gen, shape, 0.5, 2)
+	if err != nil {
+		log.Fatalf("Failed to create input: %v", err)
+	}
+	return t
+}
+
+func positiveProbs(shape ...int) *engine.Tensor {
+	t, err := engine.Uniform(gen, shape, 0.1, 0.9)
+	if err != nil {
+		log.Fatalf("Failed to create input: %v", err)
+	}
+	return t
+}
+
+// autograd returns a check of the autograd gradients of f.
+func autograd(name string, inputs []string, f func(in ...*engine.Tensor) (*engine.Tensor, error), values ...*engine.Tensor) check {
+	return check{name, inputs, func(opts engine.GradCheckOptions) ([]engine.GradCheckResult, error) {
+		return engine.GradCheck(f, values, opts)
+	}}
+}
+
+func unary(f func(*engine.Tensor) (*engine.Tensor, error)) func(in ...*engine.Tensor) (*engine.Tensor, error) {
+	return func(in ...*engine.Tensor) (*engine.Tensor, error) { return f(in[0]) }
+}
+
+func binary(f func(a, b *engine.Tensor) (*engine.Tensor, error)) func(in ...*engine.Tensor) (*engine.Tensor, error) {
+	return func(in ...*engine.Tensor) (*engine.Tensor, error) { return f(in[0], in[1]) }
+}
+
+func checks() []check {
+	x, y := []string{"x"}, []string{"x", "y"}
+	return []check{
+		autograd("Add", y, binary(engine.Add), randn(2, 3), randn(3)),
+		autograd("Sub", y, binary(engine.Sub), randn(2, 3), randn(2, 1)),
+		autograd("Mul", y, binary(engine.Mul), randn(2, 3), randn(1, 3)),
+		autograd("Div", y, binary(engine.Div), randn(2, 3), positive(2, 3)),
+		autograd("Pow", y, binary(engine.Pow), positive(2, 3), randn(2, 3)),
+		autograd("Maximum", y, binary(engine.Maximum), randn(2, 3), randn(2, 3)),
+		autograd("Dot", y, binary(engine.Dot), randn(3, 4), randn(4, 2)),
+		autograd("BatchMatMul", y, binary(engine.BatchMatMul), randn(2, 3, 4), randn(4, 2)),
+		autograd("Einsum", y, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Einsum("ij,jk->ik", in...)
+		}, randn(3, 4), randn(4, 2)),
+		autograd("Sigmoid", x, unary(engine.Sigmoid), randn(2, 3)),
+		autograd("Tanh", x, unary(engine.Tanh), randn(2, 3)),
+		autograd("Exp", x, unary(engine.Exp), randn(2, 3)),
+		autograd("Log", x, unary(engine.Log), positive(2, 3)),
+		autograd("Sqrt", x, unary(engine.Sqrt), positive(2, 3)),
+		autograd("Softplus", x, unary(engine.Softplus), randn(2, 3)),
+		autograd("LeakyRelu", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.LeakyRelu(in[0], 0.1)
+		}, randn(2, 3)),
+		autograd("Elu", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Elu(in[0], 1)
+		}, randn(2, 3)),
+		autograd("Gelu", x, unary(engine.Gelu), randn(2, 3)),
+		autograd("Silu", x, unary(engine.Silu), randn(2, 3)),
+		autograd("Square", x, unary(engine.Square), randn(2, 3)),
+		autograd("Sum", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Sum(in[0], []int{1}, true)
+		}, randn(2, 3)),
+		autograd("Mean", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Mean(in[0], []int{0}, false)
+		}, randn(2, 3)),
+		autograd("Var", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Var(in[0], []int{1}, 1, false)
+		}, randn(2, 3)),
+		autograd("Softmax", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Softmax(in[0], -1)
+		}, randn(2, 3)),
+		autograd("LogSoftmax", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.LogSoftmax(in[0], -1)
+		}, randn(2, 3)),
+		autograd("LogSumExp", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.LogSumExp(in[0], []int{1}, false)
+		}, randn(2, 3)),
+		autograd("Transpose", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			t, err := engine.Transpose(in[0])
+			if err != nil {
+				return nil, err
+			}
+			return engine.Mul(t, t)
+		}, randn(2, 3)),
+		autograd("Concat", y, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Concat(in, 1)
+		}, randn(2, 3), randn(2, 1)),
+		autograd("Pad", x, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			return engine.Pad(in[0], [][2]int{{1, 1}, {2, 0}}, engine.PadReflect, 0)
+		}, randn(3, 3)),
+		autograd("MSE", []string{"pred", "y"}, binary(nn.MSE), randn(4, 1), randn(4, 1)),
+		autograd("LogLoss", []string{"pred"}, func(in ...*engine.Tensor) (*engine.Tensor, error) {
+			y, _ := engine.NewTensor([]float64{0, 1, 1, 0}, []int{4, 1})
+			return nn.LogLoss(in[0], y)
+		}, positiveProbs(4, 1)),
+		linearLayerCheck(),
+		mseBackwardCheck(),
+	}
+}
+
+// linearLayerCheck checks the hand-written LinearLayer.Backward.
+func linearLayerCheck() check {
+	return check{"LinearLayer.Backward", []string{"x", "w", "b"}, func(opts engine.GradCheckOptions) ([]engine.GradCheckResult, error) {
+		layer, err := nn.NewLinearLayer(4, 3)
+		if err != nil {
+			return nil, err
+		}
+		x := randn(2, 4)
+		dout := randn(2, 3)
+		forward := func() (*engine.Tensor, error) { return layer.Forward(x) }
+		if _, err := forward(); err != nil {
+			return nil, err
+		}
+		dx, err := layer.Backward(dout)
+		if err != nil {
+			return nil, err
+		}
+		analytic := [][]float64{dx.GetData(), layer.GetWeights().GetWeightGrads().GetData(), layer.GetBiases().GetBiasesGrads().GetData()}
+		var results []engine.GradCheckResult
+		for i, p := range []*engine.Tensor{x, layer.GetWeights(), layer.GetBiases()} {
+			numerical, err := engine.NumericGrad(forward, p, dout, opts.Eps)
+			if err != nil {
+				return nil, err
+			}
+			r, err := engine.CompareGrads(analytic[i], numerical, opts)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, r)
+		}
+		return results, nil
+	}}
+}
+
+// mseBackwardCheck checks the hand-written nn.Backward against nn.MSE.
+func mseBackwardCheck() check {
+	return check{"nn.Backward", []string{"pred"}, func(opts engine.GradCheckOptions) ([]engine.GradCheckResult, error) {
+		pred, y := randn(4, 1), randn(4, 1)
+		analytic, err := nn.Backward(pred, y)
+		if err != nil {
+			return nil, err
+		}
+		// nn.Backward differentiates the mean over the rows of nn.MSE
+		loss := func() (*engine.Tensor, error) {
+			rows, err := nn.MSE(pred, y)
+			if err != nil {
+				return nil, err
+			}
+			return engine.Mean(rows, nil, false)
+		}
+		numerical, err := engine.NumericGrad(loss, pred, nil, opts.Eps)
+		if err != nil {
+			return nil, err
+		}
+		r, err := engine.CompareGrads(analytic.GetData(), numerical, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []engine.GradCheckResult{r}, nil
+	}}
+}
+
+func main() {
+	defaults := engine.DefaultGradCheckOptions()
+	run := flag.String("run", "", "only check ops whose name matches this regular expression")
+	eps := flag.Float64("eps", defaults.Eps, "step of the central differences")
+	atol := flag.Float64("atol", defaults.Atol, "absolute tolerance")
+	rtol := flag.Float64("rtol", defaults.Rtol, "relative tolerance")
+	verbose := flag.Bool("v", false, "report every input, not only the failing ones")
+	flag.Parse()
+
+	filter, err := regexp.Compile(*run)
+	if err != nil {
+		log.Fatalf("Invalid -run pattern: %v", err)
+	}
+	opts := engine.GradCheckOptions{Eps: *eps, Atol: *atol, Rtol: *rtol}
+
+	failed, total := 0, 0
+	for _, c := range checks() {
+		if !filter.MatchString(c.name) {
+			continue
+		}
+		total++
+		results, err := c.run(opts)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", c.name, err)
+			failed++
+			continue
+		}
+		passed := true
+		for _, r := range results {
+			passed = passed && r.Passed
+		}
+		if !passed {
+			failed++
+			fmt.Printf("FAIL %s\n", c.name)
+		} else {
+			fmt.Printf("ok   %s\n", c.name)
+		}
+		if !passed || *verbose {
+			fmt.Print(indent(engine.GradCheckReport(results, c.inputs...)))
+		}
+	}
+	fmt.Printf("%d of %d checks passed\n", total-failed, total)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// indent indents every line of s by four spaces.
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(strings.TrimSuffix(s, "\n"), "\n", "\n    ") + "\n"
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+)
+
+// anomalyEnabled controls whether ops check their outputs, and Backward the
+// gradients, for NaN and Inf.
+var anomalyEnabled = false
+
+// SetDetectAnomaly turns anomaly detection on or off and returns the previous
+// setting. While it is on, every op returns an error instead of a result that
+// holds NaN or Inf, and Backward stops at the first op whose backward pass
+// produces a non-finite gradient. The checks make every op slower.
+func SetDetectAnomaly(enabled bool) bool {
+	prev := anomalyEnabled
+	anomalyEnabled = enabled
+	return prev
+}
+
+// IsAnomalyEnabled reports whether anomaly detection is on.
+func IsAnomalyEnabled() bool {
+	return anomalyEnabled
+}
+
+// DetectAnomaly runs f with anomaly detection turned on.
+func DetectAnomaly(f func()) {
+	prev := SetDetectAnomaly(true)
+	defer SetDetectAnomaly(prev)
+	f()
+}
+
+// recordOp records out like record and, in anomaly mode, checks it for NaN and
+// Inf. Ops returning (*Tensor, error) end with it.
+func recordOp(out *Tensor, op string, backward gradFn, inputs ...*Tensor) (*Tensor, error) {
+	out = record(out, op, backward, inputs...)
+	if !anomalyEnabled || out == nil {
+		return out, nil
+	}
+	site := callSite()
+	if out.backward != nil {
+		out.site = site
+	}
+	if i, v, ok := firstNonFinite(out); ok {
+		return nil, fmt.Errorf("anomaly detected: %s produced %v at element %d of its output from inputs of shape %s, called from %s", op, v, i, inputShapes(inputs), site)
+	}
+	return out, nil
+}
+
+// checkGradAnomaly returns an error if the backward pass of node left a
+// non-finite gradient on one of its parents.
+func checkGradAnomaly(node *Tensor) error {
+	for p, parent := range node.parents {
+		if parent == nil || !parent.dtype.IsFloat() {
+			continue
+		}
+		grad, ok := pending[parent]
+		if !ok && parent.grad != nil {
+			grad = parent.grad.GetData()
+		}
+		if i, v, ok := firstNonFiniteData(grad); ok {
+			site := node.site
+			if site == "" {
+				site = "unknown call site, the op ran with anomaly detection off"
+			}
+			return fmt.Errorf("anomaly detected: backward of %s produced gradient %v at element %d of input %d of shape %v, op created at %s", node.op, v, i, p, parent.GetShape(), site)
+		}
+	}
+	return nil
+}
+
+// firstNonFinite returns the row-major index and value of the first NaN or Inf
+// element of a float tensor.
+func firstNonFinite(t *Tensor) (int, float64, bool) {
+	if !t.dtype.IsFloat() {
+		return 0, 0, false
+	}
+	return firstNonFiniteData(t.GetData())
+}
+
+// firstNonFiniteData returns the index and value of the first NaN or Inf in data.
+func firstNonFiniteData(data []float64) (int, float64, bool) {
+	for i, v := range data {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return i, v, true
+		}
+	}
+	return 0, 0, false
+}
+
+// inputShapes formats the shapes of the inputs of an op.
+func inputShapes(inputs []*Tensor) string {
+	shapes := make([]string, len(inputs))
+	for i, in := range inputs {
+		if in == nil {
+			shapes[i] = "nil"
+		} else {
+			shapes[i] = fmt.Sprint(in.GetShape())
+		}
+	}
+	return strings.Join(shapes, ", ")
+}
+
+// enginePath is the import path of the engine package and its subpackages.
+const enginePath = "github.com/conacts/goten/engine"
+
+// inEngine reports whether the function named fn belongs to the engine package
+// or one of its subpackages such as engine/linalg.
+func inEngine(fn string) bool {
+	rest := strings.TrimPrefix(fn, enginePath)
+	return len(rest) < len(fn) && (strings.HasPrefix(rest, ".") || strings.HasPrefix(rest, "/"))
+}
+
+// callSite returns the file, line and function of the first caller outside the
+// engine package and its subpackages.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !inEngine(frame.Function) {
+			return fmt.Sprintf("%s:%d (%s)", frame.File, frame.Line, frame.Function)
+		}
+		if !more {
+			return "unknown call site"
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"fmt"
+)
+
+// gradFn propagates the gradient of an op's output back to its inputs.
+// grad holds the gradient of the output laid out in row-major order.
+type gradFn func(grad []float64)
+
+// gradEnabled controls whether ops record themselves on the computation graph.
+var gradEnabled = true
+
+// pending holds the gradients of non-leaf tensors received during the running
+// Backward pass, so that each pass propagates only its own gradients.
+var pending map[*Tensor][]float64
+
+// SetGradEnabled turns graph recording on or off and returns the previous setting.
+func SetGradEnabled(enabled bool) bool {
+	prev := gradEnabled
+	gradEnabled = enabled
+	return prev
+}
+
+// IsGradEnabled reports whether ops are currently recorded on the computation graph.
+func IsGradEnabled() bool {
+	return gradEnabled
+}
+
+// NoGrad runs f with graph recording disabled, e.g. for parameter updates.
+func NoGrad(f func()) {
+	prev := SetGradEnabled(false)
+	defer SetGradEnabled(prev)
+	f()
+}
+
+// RequiresGrad reports whether gradients are accumulated for the tensor.
+func (t *Tensor) RequiresGrad() bool {
+	return t.requiresGrad
+}
+
+// SetRequiresGrad marks the tensor as a leaf whose gradient should be tracked.
+// Only floating point tensors take part in autograd.
+func (t *Tensor) SetRequiresGrad(requiresGrad bool) {
+	t.requiresGrad = requiresGrad
+}
+
+// IsLeaf returns true if the tensor was not produced by a recorded op.
+func (t *Tensor) IsLeaf() bool {
+	return t.backward == nil
+}
+
+// GetOp returns the name of the op that produced the tensor, or "" for leaves.
+func (t *Tensor) GetOp() string {
+	return t.op
+}
+
+// RetainGrad makes Backward accumulate the gradient of a non-leaf tensor into
+// its grad, which it otherwise only does for leaves.
+func (t *Tensor) RetainGrad() {
+	t.retainGrad = true
+}
+
+// GetGrad returns the gradient accumulated by Backward, or nil if there is none.
+func (t *Tensor) GetGrad() *Tensor {
+	return t.grad
+}
+
+func (t *Tensor) SetGrad(grad *Tensor) {
+	t.grad = grad
+}
+
+// ZeroGrad resets the accumulated gradient of the tensor to zeros.
+func (t *Tensor) ZeroGrad() {
+	if t.grad == nil {
+		return
+	}
+	t.grad, _ = NewTensorOf(t.dtype, make([]float64, t.GetSize()), copyShape(t.shape))
+}
+
+// Detach returns a tensor sharing the same data that is cut off from the graph.
+func (t *Tensor) Detach() *Tensor {
+	out := &Tensor{
+		shape:   copyShape(t.shape),
+		strides: copyShape(t.strides),
+		offset:  t.offset,
+		base:    t.base,
+	}
+	out.shareBuffer(t)
+	return out
+}
+
+// Backward computes the gradient of t with respect to every tensor in its graph
+// that requires gradients. t must hold a single element. Gradients accumulate in
+// the leaves and in tensors marked with RetainGrad, so call ZeroGrad on them
+// between iterations. Each call propagates only the gradients of its own pass.
+func (t *Tensor) Backward() error {
+	if t == nil {
+		return fmt.Errorf("cannot run backward on nil tensor")
+	}
+	if t.GetSize() != 1 {
+		return fmt.Errorf("backward can only be called on a tensor with a single element, got shape %v", t.GetShape())
+	}
+	if !t.requiresGrad {
+		return fmt.Errorf("tensor does not require grad and has no recorded graph")
+	}
+
+	prev := pending
+	pending = make(map[*Tensor][]float64)
+	defer func() { pending = prev }()
+
+	order := topoSort(t)
+	accumulateGrad(t, []float64{1})
+	for i := len(order) - 1; i >= 0; i-- {
+		node := order[i]
+		grad, ok := pending[node]
+		if node.backward == nil || !ok {
+			continue
+		}
+		node.backward(grad)
+		if anomalyEnabled {
+			if err := checkGradAnomaly(node); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// topoSort returns the nodes of the graph ending in t so that every node
+// comes after all of its parents.
+func topoSort(t *Tensor) []*Tensor {
+	order := make([]*Tensor, 0)
+	visited := make(map[*Tensor]bool)
+	var visit func(n *Tensor)
+	visit = func(n *Tensor) {
+		if visited[n] {
+			return
+		}
+		visited[n] = true
+		for _, p := range n.parents {
+			visit(p)
+		}
+		order = append(order, n)
+	}
+	visit(t)
+	return order
+}
+
+// record attaches out to the graph if grad mode is on and any input requires grad.
+func record(out *Tensor, op string, backward gradFn, inputs ...*Tensor) *Tensor {
+	if out == nil || !gradEnabled || !out.dtype.IsFloat() {
+		return out
+	}
+	needsGrad := false
+	for _, in := range inputs {
+		if in != nil && in.requiresGrad && in.dtype.IsFloat() {
+			needsGrad = true
+			break
+		}
+	}
+	if !needsGrad {
+		return out
+	}
+	out.requiresGrad = true
+	out.parents = inputs
+	out.op = op
+	out.backward = backward
+	return out
+}
+
+// accumulateGrad adds grad into the gradient of t if t requires gradients.
+// During Backward, the gradients of non-leaf tensors are collected for the
+// running pass and only stored in their grad if they are retained.
+func accumulateGrad(t *Tensor, grad []float64) {
+	if t == nil || !t.requiresGrad || !t.dtype.IsFloat() {
+		return
+	}
+	if pending != nil && t.backward != nil {
+		if sum, ok := pending[t]; ok {
+			for i, g := range grad {
+				sum[i] += g
+			}
+		} else {
+			pending[t] = append([]float64(nil), grad...)
+		}
+		if !t.retainGrad {
+			return
+		}
+	}
+	if t.grad == nil || t.grad.GetSize() != len(grad) {
+		data := make([]float64, len(grad))
+		copy(data, grad)
+		t.grad, _ = NewTensorOf(t.dtype, data, copyShape(t.shape))
+		return
+	}
+	// Gradients have the dtype of their tensor, so only float64 data can be updated in place
+	data := t.grad.GetData()
+	for i, g := range grad {
+		data[i] += g
+	}
+	if t.grad.dtype != Float64 {
+		t.grad.SetData(data)
+	}
+}
+
+func copyShape(shape []int) []int {
+	out := make([]int, len(shape))
+	copy(out, shape)
+	return out
+}
@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Backend computes the kernels behind the engine ops. Kernels work on float64
+// buffers laid out in row-major order; ops take care of shapes, dtypes and
+// autograd. Tensors remember the backend that owns them and ops run on the
+// backend of their first operand.
+//
+// The math ops, the reductions Sum, Mean, Var and Std, the softmax family and
+// matrix products run on the backend, forward and backward. The other ops run
+// in Go without it: views and shape ops (Concat, Pad, Tile, Repeat), indexing
+// (Gather, ScatterAdd, Where and the masked ops), comparisons and logical ops,
+// Max, Min, ArgMax and ArgMin, Einsum, random sampling and dtype conversions.
+type Backend interface {
+	// Name returns the name the backend is registered under.
+	Name() string
+
+	// Add and Mul write a[ia[i]] op b[ib[i]] to dst[i]. A nil index reads the
+	// operand in order, other indices come from broadcasting.
+	Add(dst, a, b []float64, ia, ib []int)
+	Mul(dst, a, b []float64, ia, ib []int)
+
+	// Unary kernels write f(a[i]) to dst[i].
+	Scale(dst, a []float64, v float64)
+	Neg(dst, a []float64)
+	Exp(dst, a []float64)
+	Sigmoid(dst, a []float64)
+	Relu(dst, a []float64)
+	Square(dst, a []float64)
+
+	// Map and Map2 are the generic element-wise kernels behind the rest of the
+	// math ops. They write f(a[i]) and f(a[ia[i]], b[ib[i]]) to dst[i]; op names
+	// the engine op so that backends can substitute a faster kernel for it.
+	Map(op string, dst, a []float64, f func(x float64) float64)
+	Map2(op string, dst, a, b []float64, ia, ib []int, f func(x, y float64) float64)
+
+	// Sum adds src[i] into dst[index[i]]. A nil index adds src to dst element-wise.
+	Sum(dst, src []float64, index []int)
+
+	// MatMul accumulates the m x n matrix product of the m x k matrix a and the
+	// k x n matrix b into the packed row-major buffer c.
+	MatMul(c []float64, a, b Matrix, m, k, n int)
+}
+
+// CPU is the name of the default pure Go backend.
+const CPU = "cpu"
+
+var (
+	backends       = map[string]Backend{CPU: cpuBackend{}}
+	defaultBackend = backends[CPU]
+)
+
+// RegisterBackend makes b available under its name. It returns an error if a
+// backend with the same name is already registered.
+func RegisterBackend(b Backend) error {
+	if b == nil {
+		return errors.New("cannot register nil backend")
+	}
+	if _, ok := backends[b.Name()]; ok {
+		return fmt.Errorf("backend %q is already registered", b.Name())
+	}
+	backends[b.Name()] = b
+	return nil
+}
+
+// UnregisterBackend removes the backend registered under name. The default
+// backend cannot be removed. Tensors already on the backend keep using it.
+func UnregisterBackend(name string) error {
+	if _, ok := backends[name]; !ok {
+		return fmt.Errorf("unknown backend %q", name)
+	}
+	if name == defaultBackend.Name() {
+		return fmt.Errorf("cannot unregister default backend %q", name)
+	}
+	delete(backends, name)
+	return nil
+}
+
+// GetBackend returns the backend registered under name.
+func GetBackend(name string) (Backend, error) {
+	b, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q", name)
+	}
+	return b, nil
+}
+
+// Backends returns the names of the registered backends in alphabetical order.
+func Backends() []string {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDefaultBackend sets the backend of newly created tensors and returns the
+// name of the previous default.
+func SetDefaultBackend(name string) (string, error) {
+	b, err := GetBackend(name)
+	if err != nil {
+		return "", err
+	}
+	prev := defaultBackend.Name()
+	defaultBackend = b
+	return prev, nil
+}
+
+// DefaultBackend returns the backend of newly created tensors.
+func DefaultBackend() Backend {
+	return defaultBackend
+}
+
+// GetBackend returns the backend that owns the tensor.
+func (t *Tensor) GetBackend() Backend {
+	if t.backend == nil {
+		return defaultBackend
+	}
+	return t.backend
+}
+
+// SetBackend moves the tensor to b. Data is always kept in Go memory, so no
+// copy is needed. A nil backend selects the default one.
+func (t *Tensor) SetBackend(b Backend) {
+	t.backend = b
+}
+
+// cpuBackend implements the kernels with plain Go loops.
+type cpuBackend struct{}
+
+func (cpuBackend) Name() string {
+	return CPU
+}
+
+func (cpuBackend) Add(dst, a, b []float64, ia, ib []int) {
+	for i := range dst {
+		dst[i] = a[at(ia, i)] + b[at(ib, i)]
+	}
+}
+
+func (cpuBackend) Mul(dst, a, b []float64, ia, ib []int) {
+	for i := range dst {
+		dst[i] = a[at(ia, i)] * b[at(ib, i)]
+	}
+}
+
+func (cpuBackend) Scale(dst, a []float64, v float64) {
+	for i, x := range a {
+		dst[i] = x * v
+	}
+}
+
+func (cpuBackend) Neg(dst, a []float64) {
+	for i, x := range a {
+		dst[i] = -x
+	}
+}
+
+func (cpuBackend) Exp(dst, a []float64) {
+	for i, x := range a {
+		dst[i] = math.Exp(x)
+	}
+}
+
+func (cpuBackend) Sigmoid(dst, a []float64) {
+	for i, x := range a {
+		dst[i] = 1 / (1 + math.Exp(-x))
+	}
+}
+
+func (cpuBackend) Relu(dst, a []float64) {
+	for i, x := range a {
+		if x > 0 {
+			dst[i] = x
+		} else {
+			dst[i] = 0
+		}
+	}
+}
+
+func (cpuBackend) Square(dst, a []float64) {
+	for i, x := range a {
+		dst[i] = x * x
+	}
+}
+
+func (cpuBackend) Map(op string, dst, a []float64, f func(x float64) float64) {
+	for i, x := range a {
+		dst[i] = f(x)
+	}
+}
+
+func (cpuBackend) Map2(op string, dst, a, b []float64, ia, ib []int, f func(x, y float64) float64) {
+	for i := range dst {
+		dst[i] = f(a[at(ia, i)], b[at(ib, i)])
+	}
+}
+
+func (cpuBackend) Sum(dst, src []float64, index []int) {
+	for i, v := range src {
+		dst[at(index, i)] += v
+	}
+}
+
+func (cpuBackend) MatMul(c []float64, a, b Matrix, m, k, n int) {
+	matmul(c, a, b, m, k, n)
+}
@@ -0,0 +1,88 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+)
+
+// loggingBackend wraps another backend and writes a line for every kernel it runs.
+type loggingBackend struct {
+	inner Backend
+	w     io.Writer
+}
+
+// NewLoggingBackend returns a backend that runs the kernels of inner and writes
+// the name and size of every kernel call to w. Ops that do not use the backend,
+// listed with Backend, are not logged. It is registered separately,
+// under "log/" followed by the name of inner, e.g. "log/cpu".
+func NewLoggingBackend(inner Backend, w io.Writer) Backend {
+	return &loggingBackend{inner: inner, w: w}
+}
+
+func (b *loggingBackend) log(op string, size ...int) {
+	fmt.Fprintf(b.w, "%s %s %v\n", b.inner.Name(), op, size)
+}
+
+func (b *loggingBackend) Name() string {
+	return "log/" + b.inner.Name()
+}
+
+func (b *loggingBackend) Add(dst, a, c []float64, ia, ic []int) {
+	b.log("Add", len(dst))
+	b.inner.Add(dst, a, c, ia, ic)
+}
+
+func (b *loggingBackend) Mul(dst, a, c []float64, ia, ic []int) {
+	b.log("Mul", len(dst))
+	b.inner.Mul(dst, a, c, ia, ic)
+}
+
+func (b *loggingBackend) Scale(dst, a []float64, v float64) {
+	b.log("Scale", len(dst))
+	b.inner.Scale(dst, a, v)
+}
+
+func (b *loggingBackend) Neg(dst, a []float64) {
+	b.log("Neg", len(dst))
+	b.inner.Neg(dst, a)
+}
+
+func (b *loggingBackend) Exp(dst, a []float64) {
+	b.log("Exp", len(dst))
+	b.inner.Exp(dst, a)
+}
+
+func (b *loggingBackend) Sigmoid(dst, a []float64) {
+	b.log("Sigmoid", len(dst))
+	b.inner.Sigmoid(dst, a)
+}
+
+func (b *loggingBackend) Relu(dst, a []float64) {
+	b.log("Relu", len(dst))
+	b.inner.Relu(dst, a)
+}
+
+func (b *loggingBackend) Square(dst, a []float64) {
+	b.log("Square", len(dst))
+	b.inner.Square(dst, a)
+}
+
+func (b *loggingBackend) Map(op string, dst, a []float64, f func(x float64) float64) {
+	b.log(op, len(dst))
+	b.inner.Map(op, dst, a, f)
+}
+
+func (b *loggingBackend) Map2(op string, dst, a, c []float64, ia, ic []int, f func(x, y float64) float64) {
+	b.log(op, len(dst))
+	b.inner.Map2(op, dst, a, c, ia, ic, f)
+}
+
+func (b *loggingBackend) Sum(dst, src []float64, index []int) {
+	b.log("Sum", len(src), len(dst))
+	b.inner.Sum(dst, src, index)
+}
+
+func (b *loggingBackend) MatMul(c []float64, x, y Matrix, m, k, n int) {
+	b.log("MatMul", m, k, n)
+	b.inner.MatMul(c, x, y, m, k, n)
+}
@@ -0,0 +1,105 @@
+package engine
+
+import "fmt"
+
+// BroadcastShapes returns the shape that s1 and s2 broadcast to following NumPy rules:
+// shapes are aligned on their trailing dimensions and each pair of dimensions must
+// either match or contain a 1.
+func BroadcastShapes(s1, s2 []int) ([]int, error) {
+	n := len(s1)
+	if len(s2) > n {
+		n = len(s2)
+	}
+	out := make([]int, n)
+	for i := 1; i <= n; i++ {
+		d1, d2 := 1, 1
+		if i <= len(s1) {
+			d1 = s1[len(s1)-i]
+		}
+		if i <= len(s2) {
+			d2 = s2[len(s2)-i]
+		}
+		switch {
+		case d1 == d2:
+			out[n-i] = d1
+		case d1 == 1:
+			out[n-i] = d2
+		case d2 == 1:
+			out[n-i] = d1
+		default:
+			return nil, fmt.Errorf("shapes %v and %v cannot be broadcast together", s1, s2)
+		}
+	}
+	return out, nil
+}
+
+// broadcast returns the common shape of t1 and t2 along with, for every element
+// of that shape, the flat index read from each input. A nil index slice means
+// the input already has the broadcast shape.
+func broadcast(t1, t2 *Tensor) ([]int, []int, []int, error) {
+	shape, err := BroadcastShapes(t1.GetShape(), t2.GetShape())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return shape, broadcastIndex(t1.GetShape(), shape), broadcastIndex(t2.GetShape(), shape), nil
+}
+
+// broadcastIndex maps every element of outShape to the flat index of the element
+// of shape it is read from.
+func broadcastIndex(shape, outShape []int) []int {
+	if len(shape) == len(outShape) {
+		same := true
+		for i := range shape {
+			if shape[i] != outShape[i] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return nil
+		}
+	}
+
+	// Strides of the input aligned to outShape, with 0 for broadcast dimensions
+	strides := make([]int, len(outShape))
+	stride := 1
+	for i := 1; i <= len(shape); i++ {
+		dim := shape[len(shape)-i]
+		if dim != 1 {
+			strides[len(outShape)-i] = stride
+		}
+		stride *= dim
+	}
+
+	return stridedIndex(outShape, strides, 0)
+}
+
+// at returns the input position of output element i for a broadcast index.
+func at(index []int, i int) int {
+	if index == nil {
+		return i
+	}
+	return index[i]
+}
+
+// reduceBroadcast sums a gradient of the broadcast shape back onto an input of
+// the given size using the index produced by broadcastIndex.
+func reduceBroadcast(grad []float64, index []int, size int) []float64 {
+	if index == nil {
+		return grad
+	}
+	out := make([]float64, size)
+	for i, g := range grad {
+		out[index[i]] += g
+	}
+	return out
+}
+
+// shapeSize returns the number of elements described by shape.
+func shapeSize(shape []int) int {
+	size := 1
+	for _, dim := range shape {
+		size *= dim
+	}
+	return size
+}
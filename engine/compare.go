@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"math"
+)
+
+// boolType is the dtype of comparison and logical ops.
+func boolType(...DType) DType {
+	return Bool
+}
+
+// compareOp applies the predicate f element-wise to t1 and t2 broadcast to a
+// common shape and returns the resulting bool mask.
+func compareOp(t1, t2 *Tensor, op string, f func(x, y float64) bool) (*Tensor, error) {
+	return binaryOp(t1, t2, op, boolType, func(x, y float64) float64 {
+		if f(x, y) {
+			return 1
+		}
+		return 0
+	}, nil)
+}
+
+// Gt returns the bool mask t1 > t2, broadcasting the two if needed.
+func Gt(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "Gt", func(x, y float64) bool { return x > y })
+}
+
+// Ge returns the bool mask t1 >= t2, broadcasting the two if needed.
+func Ge(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "Ge", func(x, y float64) bool { return x >= y })
+}
+
+// Lt returns the bool mask t1 < t2, broadcasting the two if needed.
+func Lt(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "Lt", func(x, y float64) bool { return x < y })
+}
+
+// Le returns the bool mask t1 <= t2, broadcasting the two if needed.
+func Le(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "Le", func(x, y float64) bool { return x <= y })
+}
+
+// Eq returns the bool mask t1 == t2, broadcasting the two if needed. NaN is
+// not equal to anything, including itself.
+func Eq(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "Eq", func(x, y float64) bool { return x == y })
+}
+
+// Ne returns the bool mask t1 != t2, broadcasting the two if needed.
+func Ne(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "Ne", func(x, y float64) bool { return x != y })
+}
+
+// And returns the element-wise logical and of t1 and t2, where non-zero
+// elements count as true.
+func And(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "And", func(x, y float64) bool { return x != 0 && y != 0 })
+}
+
+// Or returns the element-wise logical or of t1 and t2, where non-zero elements
+// count as true.
+func Or(t1, t2 *Tensor) (*Tensor, error) {
+	return compareOp(t1, t2, "Or", func(x, y float64) bool { return x != 0 || y != 0 })
+}
+
+// predicateOp applies the predicate f element-wise to t and returns the
+// resulting bool mask.
+func predicateOp(t *Tensor, op string, f func(x float64) bool) (*Tensor, error) {
+	return unaryOp(t, op, boolType, func(x float64) float64 {
+		if f(x) {
+			return 1
+		}
+		return 0
+	}, nil)
+}
+
+// Not returns the element-wise logical negation of t, where non-zero elements
+// count as true.
+func Not(t *Tensor) (*Tensor, error) {
+	return predicateOp(t, "Not", func(x float64) bool { return x == 0 })
+}
+
+// IsNaN returns the bool mask of the NaN elements of t.
+func IsNaN(t *Tensor) (*Tensor, error) {
+	return predicateOp(t, "IsNaN", math.IsNaN)
+}
+
+// IsInf returns the bool mask of the infinite elements of t, of either sign.
+func IsInf(t *Tensor) (*Tensor, error) {
+	return predicateOp(t, "IsInf", func(x float64) bool { return math.IsInf(x, 0) })
+}
+
+// IsClose returns the bool mask of the elements where |t1 - t2| <= atol + rtol * |t2|,
+// broadcasting the two if needed. Infinities are close only to themselves and
+// NaN is never close to anything.
+func IsClose(t1, t2 *Tensor, rtol, atol float64) (*Tensor, error) {
+	return compareOp(t1, t2, "IsClose", func(x, y float64) bool {
+		if x == y {
+			return true
+		}
+		if math.IsInf(x, 0) || math.IsInf(y, 0) {
+			return false
+		}
+		return math.Abs(x-y) <= atol+rtol*math.Abs(y)
+	})
+}
+
+// AllClose reports whether every element of t1 is close to the matching element
+// of t2 in the sense of IsClose. Use it instead of Equals to compare floating
+// point results. Tensors that cannot be broadcast together are never close.
+func AllClose(t1, t2 *Tensor, rtol, atol float64) bool {
+	mask, err := IsClose(t1, t2, rtol, atol)
+	if err != nil {
+		return false
+	}
+	for _, ok := range mask.GetBoolData() {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,300 @@
+package engine
+
+import (
+	"fmt"
+)
+
+// DType is the element type of a tensor.
+type DType int
+
+const (
+	Float64 DType = iota
+	Float32
+	Int64
+	Bool
+)
+
+func (d DType) String() string {
+	switch d {
+	case Float64:
+		return "float64"
+	case Float32:
+		return "float32"
+	case Int64:
+		return "int64"
+	case Bool:
+		return "bool"
+	}
+	return fmt.Sprintf("DType(%d)", int(d))
+}
+
+// IsFloat returns true for floating point dtypes, the only ones that take part in autograd.
+func (d DType) IsFloat() bool {
+	return d == Float64 || d == Float32
+}
+
+// ItemSize returns the number of bytes used to store one element.
+func (d DType) ItemSize() int {
+	switch d {
+	case Float32:
+		return 4
+	case Bool:
+		return 1
+	}
+	return 8
+}
+
+// rank orders dtypes from narrowest to widest for promotion.
+func (d DType) rank() int {
+	switch d {
+	case Bool:
+		return 0
+	case Int64:
+		return 1
+	case Float32:
+		return 2
+	}
+	return 3
+}
+
+// PromoteTypes returns the narrowest dtype both a and b can be converted to,
+// following bool < int64 < float32 < float64.
+func PromoteTypes(a, b DType) DType {
+	if a.rank() >= b.rank() {
+		return a
+	}
+	return b
+}
+
+// commonType returns the dtype all of dtypes can be converted to.
+func commonType(dtypes ...DType) DType {
+	out := Bool
+	for _, d := range dtypes {
+		out = PromoteTypes(out, d)
+	}
+	return out
+}
+
+// arithmeticType returns the dtype of an arithmetic op on the given inputs.
+// Bools are counted as int64 so that e.g. sums count true values.
+func arithmeticType(dtypes ...DType) DType {
+	out := commonType(dtypes...)
+	if out == Bool {
+		return Int64
+	}
+	return out
+}
+
+// floatType returns the dtype of an op with non-integer results such as Exp or
+// Mean. Integer and bool inputs produce float64.
+func floatType(dtypes ...DType) DType {
+	out := arithmeticType(dtypes...)
+	if !out.IsFloat() {
+		return Float64
+	}
+	return out
+}
+
+// NewTensorOf creates a tensor of the given dtype from float64 values. Values are
+// truncated for int64 and compared to zero for bool. Float64 tensors use data
+// directly, other dtypes get their own buffer.
+func NewTensorOf(dtype DType, data []float64, shape []int) (*Tensor, error) {
+	if dtype == Float64 {
+		return NewTensor(data, shape)
+	}
+	if err := checkShape(len(data), shape); err != nil {
+		return nil, err
+	}
+	t := newTyped(dtype, shape)
+	t.allocate(len(data))
+	for i, v := range data {
+		t.store(i, v)
+	}
+	return t, nil
+}
+
+// NewFloat32Tensor creates a float32 tensor backed by data.
+func NewFloat32Tensor(data []float32, shape []int) (*Tensor, error) {
+	if err := checkShape(len(data), shape); err != nil {
+		return nil, err
+	}
+	t := newTyped(Float32, shape)
+	t.f32 = data
+	return t, nil
+}
+
+// NewInt64Tensor creates an int64 tensor backed by data.
+func NewInt64Tensor(data []int64, shape []int) (*Tensor, error) {
+	if err := checkShape(len(data), shape); err != nil {
+		return nil, err
+	}
+	t := newTyped(Int64, shape)
+	t.i64 = data
+	return t, nil
+}
+
+// NewBoolTensor creates a bool tensor backed by data.
+func NewBoolTensor(data []bool, shape []int) (*Tensor, error) {
+	if err := checkShape(len(data), shape); err != nil {
+		return nil, err
+	}
+	t := newTyped(Bool, shape)
+	t.bools = data
+	return t, nil
+}
+
+// newTyped returns a contiguous tensor of the given dtype without a buffer.
+func newTyped(dtype DType, shape []int) *Tensor {
+	return &Tensor{
+		shape:   shape,
+		strides: contiguousStrides(shape),
+		dtype:   dtype,
+		backend: defaultBackend,
+	}
+}
+
+// GetDType returns the element type of the tensor.
+func (t *Tensor) GetDType() DType {
+	return t.dtype
+}
+
+// AsType returns the tensor converted to dtype. If t already has that dtype it
+// is returned as is. Gradients flow through conversions between float dtypes.
+func (t *Tensor) AsType(dtype DType) *Tensor {
+	if t.dtype == dtype {
+		return t
+	}
+	out := newTyped(dtype, copyShape(t.shape))
+	out.backend = t.backend
+	out.allocate(t.GetSize())
+	index := stridedIndex(t.shape, t.strides, t.offset)
+	switch {
+	case dtype == Int64 && t.dtype == Bool:
+		for i, pos := range index {
+			if t.bools[pos] {
+				out.i64[i] = 1
+			}
+		}
+	case dtype == Bool && t.dtype == Int64:
+		for i, pos := range index {
+			out.bools[i] = t.i64[pos] != 0
+		}
+	default:
+		for i, pos := range index {
+			out.store(i, t.load(pos))
+		}
+	}
+	if !dtype.IsFloat() {
+		return out
+	}
+	return record(out, "AsType", func(grad []float64) {
+		accumulateGrad(t, grad)
+	}, t)
+}
+
+// GetFloat32Data returns the elements of the tensor as float32 in row-major
+// order. Contiguous float32 tensors return their underlying buffer.
+func (t *Tensor) GetFloat32Data() []float32 {
+	if t.dtype == Float32 && t.IsContiguous() {
+		return t.f32[t.offset : t.offset+t.GetSize()]
+	}
+	return t.AsType(Float32).Contiguous().f32
+}
+
+// GetInt64Data returns the elements of the tensor as int64 in row-major order.
+// Contiguous int64 tensors return their underlying buffer.
+func (t *Tensor) GetInt64Data() []int64 {
+	if t.dtype == Int64 && t.IsContiguous() {
+		return t.i64[t.offset : t.offset+t.GetSize()]
+	}
+	return t.AsType(Int64).Contiguous().i64
+}
+
+// GetBoolData returns the elements of the tensor as bool in row-major order.
+// Contiguous bool tensors return their underlying buffer.
+func (t *Tensor) GetBoolData() []bool {
+	if t.dtype == Bool && t.IsContiguous() {
+		return t.bools[t.offset : t.offset+t.GetSize()]
+	}
+	return t.AsType(Bool).Contiguous().bools
+}
+
+// allocate gives t a zeroed buffer of n elements of its dtype.
+func (t *Tensor) allocate(n int) {
+	switch t.dtype {
+	case Float32:
+		t.f32 = make([]float32, n)
+	case Int64:
+		t.i64 = make([]int64, n)
+	case Bool:
+		t.bools = make([]bool, n)
+	default:
+		t.data = make([]float64, n)
+	}
+}
+
+// hasBuffer reports whether the tensor has storage for its dtype.
+func (t *Tensor) hasBuffer() bool {
+	switch t.dtype {
+	case Float32:
+		return t.f32 != nil
+	case Int64:
+		return t.i64 != nil
+	case Bool:
+		return t.bools != nil
+	}
+	return t.data != nil
+}
+
+// bufferLen returns the number of elements of the buffer of the tensor.
+func (t *Tensor) bufferLen() int {
+	switch t.dtype {
+	case Float32:
+		return len(t.f32)
+	case Int64:
+		return len(t.i64)
+	case Bool:
+		return len(t.bools)
+	}
+	return len(t.data)
+}
+
+// load returns the element at buffer position pos as a float64.
+func (t *Tensor) load(pos int) float64 {
+	switch t.dtype {
+	case Float32:
+		return float64(t.f32[pos])
+	case Int64:
+		return float64(t.i64[pos])
+	case Bool:
+		if t.bools[pos] {
+			return 1
+		}
+		return 0
+	}
+	return t.data[pos]
+}
+
+// store writes v at buffer position pos, converting it to the tensor's dtype.
+func (t *Tensor) store(pos int, v float64) {
+	switch t.dtype {
+	case Float32:
+		t.f32[pos] = float32(v)
+	case Int64:
+		t.i64[pos] = int64(v)
+	case Bool:
+		t.bools[pos] = v != 0
+	default:
+		t.data[pos] = v
+	}
+}
+
+// shareBuffer makes out use the same storage, dtype and backend as t.
+func (out *Tensor) shareBuffer(t *Tensor) {
+	out.dtype = t.dtype
+	out.backend = t.backend
+	out.data = t.data
+	out.f32 = t.f32
+	out.i64 = t.i64
+	out.bools = t.bools
+}
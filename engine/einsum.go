@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// einsumSpec is a parsed Einsum subscript string: one label per dimension of
+// every input and of the output.
+type einsumSpec struct {
+	inputs []string
+	output string
+}
+
+// parseEinsum parses subscripts such as "bij,bjk->bik" for n operands. Without
+// "->" the output holds, in alphabetical order, the labels that appear once.
+func parseEinsum(spec string, n int) (*einsumSpec, error) {
+	spec = strings.ReplaceAll(spec, " ", "")
+	lhs, output, explicit := strings.Cut(spec, "->")
+	inputs := strings.Split(lhs, ",")
+	if len(inputs) != n {
+		return nil, fmt.Errorf("subscripts %q name %d operands but %d were given", spec, len(inputs), n)
+	}
+
+	counts := make(map[rune]int)
+	for _, in := range inputs {
+		for _, c := range in {
+			if !isLabel(c) {
+				return nil, fmt.Errorf("invalid label %q in subscripts %q", c, spec)
+			}
+			counts[c]++
+		}
+	}
+	if !explicit {
+		labels := make([]string, 0)
+		for c, count := range counts {
+			if count == 1 {
+				labels = append(labels, string(c))
+			}
+		}
+		sort.Strings(labels)
+		output = strings.Join(labels, "")
+	}
+	for i, c := range output {
+		if !isLabel(c) {
+			return nil, fmt.Errorf("invalid label %q in subscripts %q", c, spec)
+		}
+		if counts[c] == 0 {
+			return nil, fmt.Errorf("output label %q does not appear in the inputs of %q", c, spec)
+		}
+		if strings.ContainsRune(output[:i], c) {
+			return nil, fmt.Errorf("output label %q is repeated in %q", c, spec)
+		}
+	}
+	return &einsumSpec{inputs: inputs, output: output}, nil
+}
+
+func isLabel(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// Einsum evaluates the Einstein summation described by spec over the operands.
+// Each operand gets one label per dimension, e.g. "bij,bjk->bik" is a batched
+// matrix product, "ij->ji" a transpose, "ii->" a trace and "i,j->ij" an outer
+// product. Labels missing from the output are summed over. Without "->" the
+// output is made of the labels that appear only once, in alphabetical order.
+func Einsum(spec string, operands ...*Tensor) (*Tensor, error) {
+	for _, t := range operands {
+		if t == nil {
+			return nil, errors.New("cannot compute einsum with nil tensor")
+		}
+	}
+	if len(operands) == 0 {
+		return nil, errors.New("einsum needs at least one operand")
+	}
+	s, err := parseEinsum(spec, len(operands))
+	if err != nil {
+		return nil, err
+	}
+
+	// Output labels come first so that the output is visited in row-major order
+	labels := []rune(s.output)
+	sizes := make(map[rune]int)
+	dtypes := make([]DType, len(operands))
+	for i, in := range s.inputs {
+		shape := operands[i].GetShape()
+		if len([]rune(in)) != len(shape) {
+			return nil, fmt.Errorf("subscripts %q do not match operand %d of shape %v", in, i, shape)
+		}
+		for axis, c := range []rune(in) {
+			size, ok := sizes[c]
+			if !ok {
+				sizes[c] = shape[axis]
+				if !strings.ContainsRune(s.output, c) {
+					labels = append(labels, c)
+				}
+				continue
+			}
+			if size != shape[axis] {
+				return nil, fmt.Errorf("label %q has size %d in operand %d but %d elsewhere", c, shape[axis], i, size)
+			}
+		}
+		dtypes[i] = operands[i].dtype
+	}
+	dims := make([]int, len(labels))
+	for l, c := range labels {
+		dims[l] = sizes[c]
+	}
+	outShape := make([]int, len(s.output))
+	copy(outShape, dims)
+
+	// strides[i][l] is the step through operand i, or the output for the last
+	// entry, when label l is incremented. Repeated labels add up their strides,
+	// which walks the diagonal.
+	data := make([][]float64, len(operands))
+	strides := make([][]int, len(operands)+1)
+	for i, in := range s.inputs {
+		data[i] = operands[i].GetData()
+		strides[i] = labelStrides(in, labels, operands[i].GetShape())
+	}
+	strides[len(operands)] = labelStrides(s.output, labels, outShape)
+
+	result := make([]float64, shapeSize(outShape))
+	out := len(operands)
+	einsumLoop(dims, strides, func(pos []int) {
+		v := 1.0
+		for i, d := range data {
+			v *= d[pos[i]]
+		}
+		result[pos[out]] += v
+	})
+
+	t, err := NewTensorOf(arithmeticType(dtypes...), result, outShape)
+	if err != nil {
+		return nil, err
+	}
+	t.backend = operands[0].backend
+	return recordOp(t, "Einsum", func(grad []float64) {
+		// The gradient of operand i is the product of the other operands
+		// summed the same way, scattered onto the elements of operand i.
+		for i, op := range operands {
+			if !op.requiresGrad {
+				continue
+			}
+			dt := make([]float64, len(data[i]))
+			einsumLoop(dims, strides, func(pos []int) {
+				v := grad[pos[out]]
+				for j, d := range data {
+					if j != i {
+						v *= d[pos[j]]
+					}
+				}
+				dt[pos[i]] += v
+			})
+			accumulateGrad(op, dt)
+		}
+	}, operands...)
+}
+
+// labelStrides returns the contiguous stride of a tensor with the given subscripts
+// and shape for each of labels, 0 for labels it does not have.
+func labelStrides(subscripts string, labels []rune, shape []int) []int {
+	contiguous := contiguousStrides(shape)
+	strides := make([]int, len(labels))
+	for axis, c := range []rune(subscripts) {
+		for l, label := range labels {
+			if label == c {
+				strides[l] += contiguous[axis]
+			}
+		}
+	}
+	return strides
+}
+
+// einsumLoop calls f for every combination of label values, passing the
+// position in each buffer described by strides.
+func einsumLoop(dims []int, strides [][]int, f func(pos []int)) {
+	coords := make([]int, len(dims))
+	pos := make([]int, len(strides))
+	for n := shapeSize(dims); n > 0; n-- {
+		f(pos)
+		for l := len(dims) - 1; l >= 0; l-- {
+			coords[l]++
+			for i := range pos {
+				pos[i] += strides[i][l]
+			}
+			if coords[l] < dims[l] {
+				break
+			}
+			for i := range pos {
+				pos[i] -= coords[l] * strides[i][l]
+			}
+			coords[l] = 0
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// GradCheckOptions controls the step of the finite differences and the
+// tolerance of the comparison.
+type GradCheckOptions struct {
+	Eps  float64 // Step of the central differences
+	Atol float64 // Absolute tolerance
+	Rtol float64 // Tolerance relative to the numerical gradient
+}
+
+// DefaultGradCheckOptions returns options suited to float64 inputs.
+func DefaultGradCheckOptions() GradCheckOptions {
+	return GradCheckOptions{Eps: 1e-6, Atol: 1e-5, Rtol: 1e-3}
+}
+
+// GradCheckResult compares the analytic and numerical gradients of one input.
+type GradCheckResult struct {
+	MaxAbsError float64 // Largest |analytic - numerical| over the elements
+	MaxRelError float64 // Largest error relative to the larger of the two gradients
+	Worst       int     // Row-major index of the element with the largest error
+	Passed      bool    // Whether every element is within Atol + Rtol * |numerical|
+}
+
+func (r GradCheckResult) String() string {
+	status := "ok"
+	if !r.Passed {
+		status = "FAILED"
+	}
+	return fmt.Sprintf("max abs error %.3e, max rel error %.3e at element %d: %s", r.MaxAbsError, r.MaxRelError, r.Worst, status)
+}
+
+// CompareGrads compares an analytic gradient against a numerical one element by
+// element.
+func CompareGrads(analytic, numerical []float64, opts GradCheckOptions) (GradCheckResult, error) {
+	if len(analytic) != len(numerical) {
+		return GradCheckResult{}, fmt.Errorf("analytic gradient has %d elements, numerical gradient has %d", len(analytic), len(numerical))
+	}
+	r := GradCheckResult{Passed: true}
+	for i, a := range analytic {
+		n := numerical[i]
+		diff := math.Abs(a - n)
+		if math.IsNaN(diff) {
+			diff = math.Inf(1)
+		}
+		rel := 0.0
+		switch {
+		case math.IsInf(diff, 1):
+			rel = diff
+		case diff > 0:
+			rel = diff / math.Max(math.Abs(a), math.Abs(n))
+		}
+		if diff > r.MaxAbsError || (diff == r.MaxAbsError && rel > r.MaxRelError) {
+			r.Worst = i
+		}
+		r.MaxAbsError = math.Max(r.MaxAbsError, diff)
+		r.MaxRelError = math.Max(r.MaxRelError, rel)
+		if !(diff <= opts.Atol+opts.Rtol*math.Abs(n)) {
+			r.Passed = false
+		}
+	}
+	return r, nil
+}
+
+// NumericGrad returns the central-difference gradient with respect to x of the
+// sum of f() weighted by dout, that is the product of dout with the Jacobian of
+// f. dout must have the shape of the output of f, or be nil for all ones. The
+// elements of x are perturbed in place and restored before returning; f is run
+// with graph recording disabled.
+func NumericGrad(f func() (*Tensor, error), x, dout *Tensor, eps float64) ([]float64, error) {
+	if x == nil {
+		return nil, errors.New("cannot differentiate with respect to nil tensor")
+	}
+	if !x.dtype.IsFloat() {
+		return nil, fmt.Errorf("cannot differentiate with respect to %v tensor", x.dtype)
+	}
+	eval := func() (float64, error) {
+		var out *Tensor
+		var err error
+		NoGrad(func() { out, err = f() })
+		if err != nil {
+			return 0, err
+		}
+		if out == nil {
+			return 0, errors.New("function returned nil tensor")
+		}
+		data := out.GetData()
+		var weights []float64
+		if dout != nil {
+			if !SameShape(out, dout) {
+				return 0, fmt.Errorf("output gradient of shape %v does not match output of shape %v", dout.GetShape(), out.GetShape())
+			}
+			weights = dout.GetData()
+		}
+		sum := 0.0
+		for i, v := range data {
+			if weights != nil {
+				v *= weights[i]
+			}
+			sum += v
+		}
+		return sum, nil
+	}
+
+	positions := stridedIndex(x.shape, x.strides, x.offset)
+	grad := make([]float64, len(positions))
+	for i, pos := range positions {
+		orig := x.load(pos)
+		x.store(pos, orig+eps)
+		plus, err := eval()
+		if err == nil {
+			x.store(pos, orig-eps)
+			var minus float64
+			minus, err = eval()
+			grad[i] = (plus - minus) / (2 * eps)
+		}
+		x.store(pos, orig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return grad, nil
+}
+
+// GradCheck compares the gradients autograd computes for f with respect to each
+// of its inputs against central differences. The output of f is reduced with
+// fixed pseudo-random weights, so that mistakes that a plain sum would hide,
+// such as a transposed gradient, are caught. The result of non-float inputs is
+// always a pass. The inputs are left as they were, including their gradients.
+func GradCheck(f func(inputs ...*Tensor) (*Tensor, error), inputs []*Tensor, opts GradCheckOptions) ([]GradCheckResult, error) {
+	for _, in := range inputs {
+		if in == nil {
+			return nil, errors.New("cannot check gradients with nil input")
+		}
+	}
+
+	// Compute the analytic gradients with fresh gradient state on the inputs
+	type state struct {
+		requiresGrad bool
+		grad         *Tensor
+	}
+	saved := make([]state, len(inputs))
+	for i, in := range inputs {
+		saved[i] = state{in.requiresGrad, in.grad}
+		in.grad = nil
+		in.requiresGrad = in.dtype.IsFloat()
+	}
+	defer func() {
+		for i, in := range inputs {
+			in.requiresGrad, in.grad = saved[i].requiresGrad, saved[i].grad
+		}
+	}()
+	prev := SetGradEnabled(true)
+	out, err := f(inputs...)
+	SetGradEnabled(prev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate function: %v", err)
+	}
+	if out == nil || !out.dtype.IsFloat() {
+		return nil, errors.New("function must return a float tensor")
+	}
+	dout, err := Uniform(NewGenerator(0), out.GetShape(), 0.5, 1.5)
+	if err != nil {
+		return nil, err
+	}
+	analytic := make([][]float64, len(inputs))
+	if out.requiresGrad {
+		prev := SetGradEnabled(true)
+		loss, err := Mul(out, dout)
+		if err == nil {
+			loss, err = Sum(loss, nil, false)
+		}
+		if err == nil {
+			err = loss.Backward()
+		}
+		SetGradEnabled(prev)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute analytic gradients: %v", err)
+		}
+	}
+	for i, in := range inputs {
+		if in.grad != nil {
+			analytic[i] = in.grad.GetData()
+		} else {
+			// No gradient reached the input, so it is analytically zero
+			analytic[i] = make([]float64, in.GetSize())
+		}
+	}
+
+	results := make([]GradCheckResult, len(inputs))
+	for i, in := range inputs {
+		if !in.dtype.IsFloat() {
+			results[i] = GradCheckResult{Passed: true}
+			continue
+		}
+		numerical, err := NumericGrad(func() (*Tensor, error) { return f(inputs...) }, in, dout, opts.Eps)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute numerical gradient of input %d: %v", i, err)
+		}
+		if results[i], err = CompareGrads(analytic[i], numerical, opts); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// GradCheckReport formats results as one line per input, naming input i
+// names[i] if given.
+func GradCheckReport(results []GradCheckResult, names ...string) string {
+	var sb strings.Builder
+	for i, r := range results {
+		name := fmt.Sprintf("input %d", i)
+		if i < len(names) {
+			name = names[i]
+		}
+		sb.WriteString(fmt.Sprintf("%s: %v\n", name, r))
+	}
+	return sb.String()
+}
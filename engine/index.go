@@ -0,0 +1,291 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// indexValues returns the elements of an int64 index tensor, checking that they
+// are valid indices into a dimension of size dim.
+func indexValues(index *Tensor, dim int) ([]int, error) {
+	if index == nil {
+		return nil, errors.New("index tensor is nil")
+	}
+	if index.dtype != Int64 {
+		return nil, fmt.Errorf("index tensor must have dtype int64, got %v", index.dtype)
+	}
+	values := make([]int, index.GetSize())
+	for i, v := range index.GetInt64Data() {
+		if v < 0 || v >= int64(dim) {
+			return nil, fmt.Errorf("index %d is out of range for dimension of size %d", v, dim)
+		}
+		values[i] = int(v)
+	}
+	return values, nil
+}
+
+// maskValues returns the elements of a bool mask tensor.
+func maskValues(mask *Tensor) ([]bool, error) {
+	if mask == nil {
+		return nil, errors.New("mask tensor is nil")
+	}
+	if mask.dtype != Bool {
+		return nil, fmt.Errorf("mask tensor must have dtype bool, got %v", mask.dtype)
+	}
+	return mask.GetBoolData(), nil
+}
+
+// gatherPositions returns, for every element of index, the flat position in a
+// packed tensor of the given shape of the element it selects along axis.
+func gatherPositions(shape []int, axis int, index *Tensor) ([]int, error) {
+	if index == nil {
+		return nil, errors.New("index tensor is nil")
+	}
+	ishape := index.GetShape()
+	if len(ishape) != len(shape) {
+		return nil, fmt.Errorf("index of shape %v does not match tensor of shape %v", ishape, shape)
+	}
+	for d := range shape {
+		if d != axis && ishape[d] > shape[d] {
+			return nil, fmt.Errorf("index of shape %v is larger than tensor of shape %v outside axis %d", ishape, shape, axis)
+		}
+	}
+	values, err := indexValues(index, shape[axis])
+	if err != nil {
+		return nil, err
+	}
+	strides := contiguousStrides(shape)
+	stride := strides[axis]
+	strides[axis] = 0
+	positions := stridedIndex(ishape, strides, 0)
+	for i, v := range values {
+		positions[i] += v * stride
+	}
+	return positions, nil
+}
+
+// Gather picks, for every element of the int64 index tensor, the element of t
+// at the same coordinates except along axis, where the index value is used:
+// for axis 1, out[i][j] = t[i][index[i][j]]. index must have the rank of t and
+// the result has the shape of index.
+func Gather(t *Tensor, axis int, index *Tensor) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot gather from nil tensor")
+	}
+	a, err := normalizeAxis(axis, len(t.GetShape()))
+	if err != nil {
+		return nil, err
+	}
+	positions, err := gatherPositions(t.GetShape(), a, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather: %v", err)
+	}
+	in := t.GetData()
+	data := make([]float64, len(positions))
+	for i, pos := range positions {
+		data[i] = in[pos]
+	}
+	out, err := NewTensorOf(t.dtype, data, index.GetShape())
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "Gather", func(grad []float64) {
+		dt := make([]float64, len(in))
+		for i, pos := range positions {
+			dt[pos] += grad[i]
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// ScatterAdd returns a copy of t with every element of src added at the
+// position Gather would read it from: for axis 1, out[i][index[i][j]] += src[i][j].
+// src must have the shape of index.
+func ScatterAdd(t *Tensor, axis int, index, src *Tensor) (*Tensor, error) {
+	if t == nil || src == nil {
+		return nil, errors.New("cannot scatter with nil tensor")
+	}
+	a, err := normalizeAxis(axis, len(t.GetShape()))
+	if err != nil {
+		return nil, err
+	}
+	positions, err := gatherPositions(t.GetShape(), a, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scatter: %v", err)
+	}
+	if !SameShape(src, index) {
+		return nil, fmt.Errorf("source of shape %v does not match index of shape %v", src.GetShape(), index.GetShape())
+	}
+	data := make([]float64, t.GetSize())
+	copy(data, t.GetData())
+	for i, v := range src.GetData() {
+		data[positions[i]] += v
+	}
+	out, err := NewTensorOf(commonType(t.dtype, src.dtype), data, copyShape(t.shape))
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "ScatterAdd", func(grad []float64) {
+		accumulateGrad(t, grad)
+		dsrc := make([]float64, len(positions))
+		for i, pos := range positions {
+			dsrc[i] = grad[pos]
+		}
+		accumulateGrad(src, dsrc)
+	}, t, src)
+}
+
+// IndexSelect returns the slices of t along axis listed in the 1-D int64 index
+// tensor, in order and possibly repeated. This is an embedding lookup for axis 0.
+func IndexSelect(t *Tensor, axis int, index *Tensor) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot index nil tensor")
+	}
+	shape := t.GetShape()
+	a, err := normalizeAxis(axis, len(shape))
+	if err != nil {
+		return nil, err
+	}
+	if index == nil || len(index.GetShape()) != 1 {
+		return nil, errors.New("index tensor must be 1-D")
+	}
+	values, err := indexValues(index, shape[a])
+	if err != nil {
+		return nil, fmt.Errorf("failed to select indices: %v", err)
+	}
+	tables := identityTables(shape)
+	tables[a] = values
+	return gatherAxes(t, "IndexSelect", tables, 0)
+}
+
+// MaskedSelect returns a 1-D tensor of the elements of t where the bool mask is
+// true. The mask and t are broadcast to a common shape first.
+func MaskedSelect(t, mask *Tensor) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot select from nil tensor")
+	}
+	keep, err := maskValues(mask)
+	if err != nil {
+		return nil, err
+	}
+	shape, idx, midx, err := broadcast(t, mask)
+	if err != nil {
+		return nil, fmt.Errorf("mask of shape %v cannot be broadcast with tensor of shape %v", mask.GetShape(), t.GetShape())
+	}
+	positions := make([]int, 0)
+	for i := 0; i < shapeSize(shape); i++ {
+		if keep[at(midx, i)] {
+			positions = append(positions, at(idx, i))
+		}
+	}
+	if len(positions) == 0 {
+		return nil, errors.New("mask selects no elements")
+	}
+	in := t.GetData()
+	data := make([]float64, len(positions))
+	for i, pos := range positions {
+		data[i] = in[pos]
+	}
+	out, err := NewTensorOf(t.dtype, data, []int{len(data)})
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "MaskedSelect", func(grad []float64) {
+		dt := make([]float64, len(in))
+		for i, pos := range positions {
+			dt[pos] += grad[i]
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// MaskedFill returns a copy of t with value wherever the bool mask, broadcast to
+// the shape of t, is true. No gradient flows to the filled elements.
+func MaskedFill(t, mask *Tensor, value float64) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot fill nil tensor")
+	}
+	fill, err := maskValues(mask)
+	if err != nil {
+		return nil, err
+	}
+	shape, err := BroadcastShapes(t.GetShape(), mask.GetShape())
+	if err != nil || !reflect.DeepEqual(shape, t.GetShape()) {
+		return nil, fmt.Errorf("mask of shape %v cannot be broadcast to tensor of shape %v", mask.GetShape(), t.GetShape())
+	}
+	midx := broadcastIndex(mask.GetShape(), shape)
+	data := make([]float64, t.GetSize())
+	copy(data, t.GetData())
+	for i := range data {
+		if fill[at(midx, i)] {
+			data[i] = value
+		}
+	}
+	out, err := NewTensorOf(t.dtype, data, copyShape(t.shape))
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "MaskedFill", func(grad []float64) {
+		dt := make([]float64, len(grad))
+		for i, g := range grad {
+			if !fill[at(midx, i)] {
+				dt[i] = g
+			}
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// Where returns the elements of t1 where the bool cond is true and of t2
+// elsewhere. The three tensors are broadcast to a common shape.
+func Where(cond, t1, t2 *Tensor) (*Tensor, error) {
+	if t1 == nil || t2 == nil {
+		return nil, errors.New("cannot select from nil tensor")
+	}
+	pick, err := maskValues(cond)
+	if err != nil {
+		return nil, err
+	}
+	shape, err := BroadcastShapes(t1.GetShape(), t2.GetShape())
+	if err == nil {
+		shape, err = BroadcastShapes(cond.GetShape(), shape)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("shapes %v, %v and %v cannot be broadcast together", cond.GetShape(), t1.GetShape(), t2.GetShape())
+	}
+	cidx := broadcastIndex(cond.GetShape(), shape)
+	idx1 := broadcastIndex(t1.GetShape(), shape)
+	idx2 := broadcastIndex(t2.GetShape(), shape)
+	data1, data2 := t1.GetData(), t2.GetData()
+	data := make([]float64, shapeSize(shape))
+	for i := range data {
+		if pick[at(cidx, i)] {
+			data[i] = data1[at(idx1, i)]
+		} else {
+			data[i] = data2[at(idx2, i)]
+		}
+	}
+	out, err := NewTensorOf(commonType(t1.dtype, t2.dtype), data, shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t1.backend
+	return recordOp(out, "Where", func(grad []float64) {
+		d1 := make([]float64, len(grad))
+		d2 := make([]float64, len(grad))
+		for i, g := range grad {
+			if pick[at(cidx, i)] {
+				d1[i] = g
+			} else {
+				d2[i] = g
+			}
+		}
+		accumulateGrad(t1, reduceBroadcast(d1, idx1, len(data1)))
+		accumulateGrad(t2, reduceBroadcast(d2, idx2, len(data2)))
+	}, t1, t2)
+}
@@ -0,0 +1,455 @@
+package linalg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/conacts/goten/engine"
+)
+
+// maxSweeps bounds the number of sweeps of the Jacobi methods, which normally
+// converge in well under 20.
+const maxSweeps = 100
+
+// lu holds an LU factorization with partial pivoting: row i of L . U is row
+// perm[i] of the factorized matrix.
+type lu struct {
+	lu   *matrix // L below the diagonal, with an implicit unit diagonal, and U above
+	perm []int
+	sign float64 // Sign of the permutation
+}
+
+func luFactor(m *matrix) *lu {
+	n := m.rows
+	f := &lu{lu: &matrix{rows: n, cols: n, data: append([]float64(nil), m.data...)}, perm: make([]int, n), sign: 1}
+	for i := range f.perm {
+		f.perm[i] = i
+	}
+	a := f.lu
+	for k := 0; k < n; k++ {
+		pivot := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a.at(i, k)) > math.Abs(a.at(pivot, k)) {
+				pivot = i
+			}
+		}
+		if pivot != k {
+			for j := 0; j < n; j++ {
+				a.data[k*n+j], a.data[pivot*n+j] = a.data[pivot*n+j], a.data[k*n+j]
+			}
+			f.perm[k], f.perm[pivot] = f.perm[pivot], f.perm[k]
+			f.sign = -f.sign
+		}
+		if a.at(k, k) == 0 {
+			continue
+		}
+		for i := k + 1; i < n; i++ {
+			l := a.at(i, k) / a.at(k, k)
+			a.set(i, k, l)
+			for j := k + 1; j < n; j++ {
+				a.data[i*n+j] -= l * a.at(k, j)
+			}
+		}
+	}
+	return f
+}
+
+func (f *lu) singular() bool {
+	for i := 0; i < f.lu.rows; i++ {
+		if f.lu.at(i, i) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// solve returns x such that the factorized matrix times x is b.
+func (f *lu) solve(b *matrix) *matrix {
+	n, k := f.lu.rows, b.cols
+	x := newMatrix(n, k)
+	for i, p := range f.perm {
+		copy(x.data[i*k:(i+1)*k], b.data[p*k:(p+1)*k])
+	}
+	// Forward substitution with L, then back substitution with U
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			l := f.lu.at(i, j)
+			for c := 0; c < k; c++ {
+				x.data[i*k+c] -= l * x.at(j, c)
+			}
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			u := f.lu.at(i, j)
+			for c := 0; c < k; c++ {
+				x.data[i*k+c] -= u * x.at(j, c)
+			}
+		}
+		d := f.lu.at(i, i)
+		for c := 0; c < k; c++ {
+			x.data[i*k+c] /= d
+		}
+	}
+	return x
+}
+
+// LU factorizes the square matrix a as p . l . u, where p is a permutation
+// matrix, l is lower triangular with a unit diagonal and u is upper triangular.
+func LU(a *engine.Tensor) (p, l, u *engine.Tensor, err error) {
+	m, err := fromSquareTensor(a)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to compute LU decomposition: %v", err)
+	}
+	f := luFactor(m)
+	n := m.rows
+	pm, lm, um := newMatrix(n, n), newMatrix(n, n), newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		pm.set(f.perm[i], i, 1)
+		for j := 0; j < n; j++ {
+			switch {
+			case j < i:
+				lm.set(i, j, f.lu.at(i, j))
+			case j == i:
+				lm.set(i, j, 1)
+				um.set(i, j, f.lu.at(i, j))
+			default:
+				um.set(i, j, f.lu.at(i, j))
+			}
+		}
+	}
+	return pm.tensor(), lm.tensor(), um.tensor(), nil
+}
+
+// QR factorizes a of shape [m, n] as q . r with Householder reflections. With
+// k = min(m, n), q has shape [m, k] and orthonormal columns and r has shape
+// [k, n], is upper triangular and has a non-negative diagonal.
+func QR(a *engine.Tensor) (q, r *engine.Tensor, err error) {
+	m, err := fromTensor(a)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute QR decomposition: %v", err)
+	}
+	qm, rm := qr(m)
+	return qm.tensor(), rm.tensor(), nil
+}
+
+func qr(a *matrix) (*matrix, *matrix) {
+	rows, cols := a.rows, a.cols
+	k := minInt(rows, cols)
+	r := &matrix{rows: rows, cols: cols, data: append([]float64(nil), a.data...)}
+	// Householder vectors v[j:] of each reflection, nil where none was needed
+	vs := make([][]float64, k)
+	for j := 0; j < k; j++ {
+		// Reflect r[j:, j] onto a multiple of the first basis vector
+		norm := 0.0
+		for i := j; i < rows; i++ {
+			norm += r.at(i, j) * r.at(i, j)
+		}
+		norm = math.Sqrt(norm)
+		if norm == 0 {
+			continue
+		}
+		alpha := -norm
+		if r.at(j, j) < 0 {
+			alpha = norm
+		}
+		v := make([]float64, rows-j)
+		vnorm := 0.0
+		for i := j; i < rows; i++ {
+			v[i-j] = r.at(i, j)
+			if i == j {
+				v[i-j] -= alpha
+			}
+			vnorm += v[i-j] * v[i-j]
+		}
+		if vnorm == 0 {
+			continue
+		}
+		reflect(r, j, v, vnorm)
+		vs[j] = v
+	}
+
+	// Form the thin q = H_0 ... H_{k-1} I[:, :k] from the right, flipping signs
+	// so that the diagonal of r is non-negative
+	q := newMatrix(rows, k)
+	for i := 0; i < k; i++ {
+		q.set(i, i, 1)
+	}
+	for j := k - 1; j >= 0; j-- {
+		if v := vs[j]; v != nil {
+			vnorm := 0.0
+			for _, x := range v {
+				vnorm += x * x
+			}
+			reflect(q, j, v, vnorm)
+		}
+	}
+	rk := newMatrix(k, cols)
+	for i := 0; i < k; i++ {
+		s := 1.0
+		if r.at(i, i) < 0 {
+			s = -1
+		}
+		for c := i; c < cols; c++ {
+			rk.set(i, c, s*r.at(i, c))
+		}
+		for row := 0; row < rows; row++ {
+			q.data[row*k+i] *= s
+		}
+	}
+	return q, rk
+}
+
+// reflect applies the Householder reflection I - 2vv^T/v^Tv, acting on rows
+// j and below, to every column of m in place.
+func reflect(m *matrix, j int, v []float64, vnorm float64) {
+	for c := 0; c < m.cols; c++ {
+		dot := 0.0
+		for i, x := range v {
+			dot += x * m.at(j+i, c)
+		}
+		f := 2 * dot / vnorm
+		for i, x := range v {
+			m.data[(j+i)*m.cols+c] -= f * x
+		}
+	}
+}
+
+// Cholesky returns the lower triangular l with a positive diagonal such that
+// l . l^T = a for a symmetric positive definite a. Only the lower triangle of
+// a is read.
+func Cholesky(a *engine.Tensor) (*engine.Tensor, error) {
+	m, err := fromSquareTensor(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute Cholesky decomposition: %v", err)
+	}
+	n := m.rows
+	l := newMatrix(n, n)
+	for j := 0; j < n; j++ {
+		d := m.at(j, j)
+		for k := 0; k < j; k++ {
+			d -= l.at(j, k) * l.at(j, k)
+		}
+		if d <= 0 || math.IsNaN(d) {
+			return nil, errors.New("failed to compute Cholesky decomposition: matrix is not positive definite")
+		}
+		d = math.Sqrt(d)
+		l.set(j, j, d)
+		for i := j + 1; i < n; i++ {
+			s := m.at(i, j)
+			for k := 0; k < j; k++ {
+				s -= l.at(i, k) * l.at(j, k)
+			}
+			l.set(i, j, s/d)
+		}
+	}
+	return l.tensor(), nil
+}
+
+// SVD computes the thin singular value decomposition a = u . diag(s) . vt of a
+// of shape [m, n] with one-sided Jacobi rotations. With k = min(m, n), u has
+// shape [m, k], s has shape [k] and is sorted in decreasing order, and vt has
+// shape [k, n].
+func SVD(a *engine.Tensor) (u, s, vt *engine.Tensor, err error) {
+	m, err := fromTensor(a)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to compute SVD: %v", err)
+	}
+	um, sv, vm := svd(m)
+	return um.tensor(), vectorTensor(sv), vm.transpose().tensor(), nil
+}
+
+// svd returns u, s and v with a = u . diag(s) . v^T.
+func svd(a *matrix) (*matrix, []float64, *matrix) {
+	if a.rows < a.cols {
+		v, s, u := svd(a.transpose())
+		return u, s, v
+	}
+	rows, cols := a.rows, a.cols
+	u := &matrix{rows: rows, cols: cols, data: append([]float64(nil), a.data...)}
+	v := identity(cols)
+
+	// Rotate pairs of columns of u until they are all orthogonal
+	for sweep := 0; sweep < maxSweeps; sweep++ {
+		rotated := false
+		for p := 0; p < cols-1; p++ {
+			for q := p + 1; q < cols; q++ {
+				alpha, beta, gamma := 0.0, 0.0, 0.0
+				for i := 0; i < rows; i++ {
+					up, uq := u.at(i, p), u.at(i, q)
+					alpha += up * up
+					beta += uq * uq
+					gamma += up * uq
+				}
+				if gamma == 0 || math.Abs(gamma) <= eps*math.Sqrt(alpha*beta) {
+					continue
+				}
+				rotated = true
+				c, s := jacobiRotation(alpha, beta, gamma)
+				rotateColumns(u, p, q, c, s)
+				rotateColumns(v, p, q, c, s)
+			}
+		}
+		if !rotated {
+			break
+		}
+	}
+
+	// The singular values are the norms of the columns of u
+	sv := make([]float64, cols)
+	for j := 0; j < cols; j++ {
+		norm := 0.0
+		for i := 0; i < rows; i++ {
+			norm += u.at(i, j) * u.at(i, j)
+		}
+		sv[j] = math.Sqrt(norm)
+	}
+	order := make([]int, cols)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return sv[order[i]] > sv[order[j]] })
+
+	us, vs := newMatrix(rows, cols), newMatrix(cols, cols)
+	s := make([]float64, cols)
+	for k, j := range order {
+		s[k] = sv[j]
+		for i := 0; i < rows; i++ {
+			if sv[j] > 0 {
+				us.set(i, k, u.at(i, j)/sv[j])
+			}
+		}
+		for i := 0; i < cols; i++ {
+			vs.set(i, k, v.at(i, j))
+		}
+	}
+	completeBasis(us, s)
+	return us, s, vs
+}
+
+// jacobiRotation returns the cosine and sine of the rotation that makes two
+// vectors with squared norms alpha and beta and inner product gamma orthogonal,
+// or equivalently zeroes the off-diagonal element gamma of [[alpha, gamma],
+// [gamma, beta]].
+func jacobiRotation(alpha, beta, gamma float64) (float64, float64) {
+	zeta := (beta - alpha) / (2 * gamma)
+	t := 1 / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+	if zeta < 0 {
+		t = -t
+	}
+	c := 1 / math.Sqrt(1+t*t)
+	return c, c * t
+}
+
+// rotateColumns applies a Givens rotation to columns p and q of m.
+func rotateColumns(m *matrix, p, q int, c, s float64) {
+	for i := 0; i < m.rows; i++ {
+		mp, mq := m.at(i, p), m.at(i, q)
+		m.set(i, p, c*mp-s*mq)
+		m.set(i, q, s*mp+c*mq)
+	}
+}
+
+// completeBasis replaces the columns of u that belong to zero singular values,
+// and are therefore zero, with unit vectors orthogonal to the other columns.
+func completeBasis(u *matrix, s []float64) {
+	for k, sk := range s {
+		if sk > 0 {
+			continue
+		}
+		for e := 0; e < u.rows; e++ {
+			// Orthogonalize the basis vector e against the columns found so far
+			col := make([]float64, u.rows)
+			col[e] = 1
+			for j := 0; j < u.cols; j++ {
+				if j == k || (s[j] == 0 && j > k) {
+					continue
+				}
+				dot := 0.0
+				for i := range col {
+					dot += col[i] * u.at(i, j)
+				}
+				for i := range col {
+					col[i] -= dot * u.at(i, j)
+				}
+			}
+			norm := 0.0
+			for _, x := range col {
+				norm += x * x
+			}
+			if norm = math.Sqrt(norm); norm > 1e-8 {
+				for i, x := range col {
+					u.set(i, k, x/norm)
+				}
+				break
+			}
+		}
+	}
+}
+
+// EigSym returns the eigenvalues, in increasing order, and the eigenvectors of
+// the symmetric matrix a, computed with cyclic Jacobi rotations. Column i of
+// vectors is the unit eigenvector of values[i]. Only the symmetric part of a
+// is used.
+func EigSym(a *engine.Tensor) (values, vectors *engine.Tensor, err error) {
+	m, err := fromSquareTensor(a)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute eigendecomposition: %v", err)
+	}
+	n := m.rows
+	w := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			w.set(i, j, (m.at(i, j)+m.at(j, i))/2)
+		}
+	}
+	v := identity(n)
+
+	for sweep := 0; sweep < maxSweeps; sweep++ {
+		off, total := 0.0, 0.0
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				total += w.at(i, j) * w.at(i, j)
+				if i != j {
+					off += w.at(i, j) * w.at(i, j)
+				}
+			}
+		}
+		if off <= eps*eps*total {
+			break
+		}
+		for p := 0; p < n-1; p++ {
+			for q := p + 1; q < n; q++ {
+				if w.at(p, q) == 0 {
+					continue
+				}
+				// w = J^T w J zeroes w[p][q] and w[q][p]
+				c, s := jacobiRotation(w.at(p, p), w.at(q, q), w.at(p, q))
+				rotateColumns(w, p, q, c, s)
+				for k := 0; k < n; k++ {
+					wp, wq := w.at(p, k), w.at(q, k)
+					w.set(p, k, c*wp-s*wq)
+					w.set(q, k, s*wp+c*wq)
+				}
+				rotateColumns(v, p, q, c, s)
+			}
+		}
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return w.at(order[i], order[i]) < w.at(order[j], order[j]) })
+	vals := make([]float64, n)
+	vecs := newMatrix(n, n)
+	for k, j := range order {
+		vals[k] = w.at(j, j)
+		for i := 0; i < n; i++ {
+			vecs.set(i, k, v.at(i, j))
+		}
+	}
+	return vectorTensor(vals), vecs.tensor(), nil
+}
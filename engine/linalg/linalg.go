@@ -0,0 +1,317 @@
+// Package linalg provides dense linear algebra on 2-D engine tensors: matrix
+// decompositions, solvers and norms. Results are plain float64 tensors and do
+// not take part in autograd.
+package linalg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/conacts/goten/engine"
+)
+
+// eps is the machine epsilon of float64.
+const eps = 0x1p-52
+
+// matrix is a dense row-major matrix used internally by the decompositions.
+type matrix struct {
+	rows, cols int
+	data       []float64
+}
+
+func newMatrix(rows, cols int) *matrix {
+	return &matrix{rows: rows, cols: cols, data: make([]float64, rows*cols)}
+}
+
+func identity(n int) *matrix {
+	m := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.set(i, i, 1)
+	}
+	return m
+}
+
+func (m *matrix) at(i, j int) float64 {
+	return m.data[i*m.cols+j]
+}
+
+func (m *matrix) set(i, j int, v float64) {
+	m.data[i*m.cols+j] = v
+}
+
+func (m *matrix) transpose() *matrix {
+	out := newMatrix(m.cols, m.rows)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			out.set(j, i, m.at(i, j))
+		}
+	}
+	return out
+}
+
+// mul returns the product of a and b.
+func mul(a, b *matrix) *matrix {
+	out := newMatrix(a.rows, b.cols)
+	for i := 0; i < a.rows; i++ {
+		for k := 0; k < a.cols; k++ {
+			aik := a.at(i, k)
+			for j := 0; j < b.cols; j++ {
+				out.data[i*out.cols+j] += aik * b.at(k, j)
+			}
+		}
+	}
+	return out
+}
+
+// fromTensor copies a 2-D tensor into a matrix.
+func fromTensor(t *engine.Tensor) (*matrix, error) {
+	if t == nil {
+		return nil, errors.New("input tensor is nil")
+	}
+	shape := t.GetShape()
+	if len(shape) != 2 {
+		return nil, fmt.Errorf("expected a 2-D tensor, got shape %v", shape)
+	}
+	m := newMatrix(shape[0], shape[1])
+	copy(m.data, t.GetData())
+	return m, nil
+}
+
+// fromSquareTensor copies a square 2-D tensor into a matrix.
+func fromSquareTensor(t *engine.Tensor) (*matrix, error) {
+	m, err := fromTensor(t)
+	if err != nil {
+		return nil, err
+	}
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("expected a square matrix, got shape %v", t.GetShape())
+	}
+	return m, nil
+}
+
+func (m *matrix) tensor() *engine.Tensor {
+	t, _ := engine.NewTensor(m.data, []int{m.rows, m.cols})
+	return t
+}
+
+func vectorTensor(data []float64) *engine.Tensor {
+	t, _ := engine.NewTensor(data, []int{len(data)})
+	return t
+}
+
+// rhsMatrix copies the right-hand side of a linear system with n equations
+// into a matrix. A 1-D b is treated as a single column.
+func rhsMatrix(b *engine.Tensor, n int) (*matrix, bool, error) {
+	if b == nil {
+		return nil, false, errors.New("right-hand side is nil")
+	}
+	shape := b.GetShape()
+	vector := len(shape) == 1
+	var m *matrix
+	switch {
+	case vector:
+		m = &matrix{rows: shape[0], cols: 1, data: append([]float64(nil), b.GetData()...)}
+	case len(shape) == 2:
+		m = newMatrix(shape[0], shape[1])
+		copy(m.data, b.GetData())
+	default:
+		return nil, false, fmt.Errorf("right-hand side must be 1-D or 2-D, got shape %v", shape)
+	}
+	if m.rows != n {
+		return nil, false, fmt.Errorf("right-hand side of shape %v does not match %d equations", shape, n)
+	}
+	return m, vector, nil
+}
+
+// solution converts the solution of a linear system back to the layout of its
+// right-hand side.
+func solution(x *matrix, vector bool) *engine.Tensor {
+	if vector {
+		return vectorTensor(x.data)
+	}
+	return x.tensor()
+}
+
+// Solve returns x such that a . x = b for a square, non-singular a. b may be a
+// vector of shape [n] or a matrix of shape [n, k], and x has the same shape.
+func Solve(a, b *engine.Tensor) (*engine.Tensor, error) {
+	m, err := fromSquareTensor(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve: %v", err)
+	}
+	rhs, vector, err := rhsMatrix(b, m.rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve: %v", err)
+	}
+	f := luFactor(m)
+	if f.singular() {
+		return nil, errors.New("failed to solve: matrix is singular")
+	}
+	return solution(f.solve(rhs), vector), nil
+}
+
+// Inv returns the inverse of the square, non-singular matrix a.
+func Inv(a *engine.Tensor) (*engine.Tensor, error) {
+	m, err := fromSquareTensor(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to invert: %v", err)
+	}
+	f := luFactor(m)
+	if f.singular() {
+		return nil, errors.New("failed to invert: matrix is singular")
+	}
+	return f.solve(identity(m.rows)).tensor(), nil
+}
+
+// Det returns the determinant of the square matrix a.
+func Det(a *engine.Tensor) (float64, error) {
+	m, err := fromSquareTensor(a)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute determinant: %v", err)
+	}
+	f := luFactor(m)
+	det := f.sign
+	for i := 0; i < m.rows; i++ {
+		det *= f.lu.at(i, i)
+	}
+	return det, nil
+}
+
+// Pinv returns the Moore-Penrose pseudo-inverse of a, computed from its SVD.
+// Singular values below rcond times the largest one are treated as zero; a
+// negative rcond selects a default based on the machine precision.
+func Pinv(a *engine.Tensor, rcond float64) (*engine.Tensor, error) {
+	m, err := fromTensor(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute pseudo-inverse: %v", err)
+	}
+	return pinv(m, rcond).tensor(), nil
+}
+
+func pinv(m *matrix, rcond float64) *matrix {
+	if rcond < 0 {
+		rcond = eps * float64(maxInt(m.rows, m.cols))
+	}
+	u, s, v := svd(m)
+	cutoff := 0.0
+	if len(s) > 0 {
+		cutoff = rcond * s[0]
+	}
+	// pinv = V . diag(1/s) . U^T
+	out := newMatrix(m.cols, m.rows)
+	for k, sk := range s {
+		if sk <= cutoff {
+			continue
+		}
+		for i := 0; i < m.cols; i++ {
+			vik := v.at(i, k) / sk
+			for j := 0; j < m.rows; j++ {
+				out.data[i*m.rows+j] += vik * u.at(j, k)
+			}
+		}
+	}
+	return out
+}
+
+// LstSq returns the x minimizing ||a . x - b|| for any a of shape [m, n], the
+// minimum norm solution if there are several. b may be a vector of shape [m]
+// or a matrix of shape [m, k].
+func LstSq(a, b *engine.Tensor) (*engine.Tensor, error) {
+	m, err := fromTensor(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve least squares: %v", err)
+	}
+	rhs, vector, err := rhsMatrix(b, m.rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to solve least squares: %v", err)
+	}
+	return solution(mul(pinv(m, -1), rhs), vector), nil
+}
+
+// Norm returns a norm of t selected by ord:
+//
+//	"" or "fro"  Frobenius norm of a matrix, Euclidean norm of a vector
+//	"1"          maximum absolute column sum, or sum of absolute values
+//	"inf"        maximum absolute row sum, or largest absolute value
+//	"2"          largest singular value, or Euclidean norm
+//	"nuc"        sum of singular values of a matrix
+//
+// The Frobenius norm accepts tensors of any rank, the others only vectors and
+// matrices.
+func Norm(t *engine.Tensor, ord string) (float64, error) {
+	if t == nil {
+		return 0, errors.New("input tensor is nil")
+	}
+	data := t.GetData()
+	shape := t.GetShape()
+	if ord == "" || ord == "fro" || (ord == "2" && len(shape) == 1) {
+		sum := 0.0
+		for _, v := range data {
+			sum += v * v
+		}
+		return math.Sqrt(sum), nil
+	}
+	switch len(shape) {
+	case 1:
+		norm := 0.0
+		for _, v := range data {
+			switch ord {
+			case "1":
+				norm += math.Abs(v)
+			case "inf":
+				norm = math.Max(norm, math.Abs(v))
+			default:
+				return 0, fmt.Errorf("unsupported vector norm %q", ord)
+			}
+		}
+		return norm, nil
+	case 2:
+		m, _ := fromTensor(t)
+		switch ord {
+		case "1":
+			return maxAbsSum(m.transpose()), nil
+		case "inf":
+			return maxAbsSum(m), nil
+		case "2", "nuc":
+			_, s, _ := svd(m)
+			if ord == "2" {
+				return s[0], nil
+			}
+			sum := 0.0
+			for _, v := range s {
+				sum += v
+			}
+			return sum, nil
+		}
+		return 0, fmt.Errorf("unsupported matrix norm %q", ord)
+	}
+	return 0, fmt.Errorf("norm %q is only defined for vectors and matrices, got shape %v", ord, shape)
+}
+
+// maxAbsSum returns the largest sum of absolute values of a row of m.
+func maxAbsSum(m *matrix) float64 {
+	norm := 0.0
+	for i := 0; i < m.rows; i++ {
+		sum := 0.0
+		for j := 0; j < m.cols; j++ {
+			sum += math.Abs(m.at(i, j))
+		}
+		norm = math.Max(norm, sum)
+	}
+	return norm
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,297 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+)
+
+// unaryOp applies f element-wise to t, producing a tensor of dtype(t.dtype).
+// df returns the derivative of f at x, given y = f(x), for the backward pass.
+func unaryOp(t *Tensor, op string, dtype func(...DType) DType, f func(x float64) float64, df func(x, y float64) float64) (*Tensor, error) {
+	if t == nil {
+		return nil, fmt.Errorf("cannot compute %s of nil tensor", op)
+	}
+	in := t.GetData()
+	be := t.GetBackend()
+	data := make([]float64, len(in))
+	be.Map(op, data, in, f)
+	out, err := NewTensorOf(dtype(t.dtype), data, copyShape(t.shape))
+	if err != nil {
+		return nil, err
+	}
+	out.backend = be
+	return recordOp(out, op, func(grad []float64) {
+		dt := make([]float64, len(grad))
+		for i, g := range grad {
+			dt[i] = g * df(in[i], data[i])
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// binaryOp applies f element-wise to t1 and t2 broadcast to a common shape,
+// producing a tensor of dtype(t1.dtype, t2.dtype). df returns the partial
+// derivatives of f at (x, y), given z = f(x, y), for the backward pass.
+func binaryOp(t1, t2 *Tensor, op string, dtype func(...DType) DType, f func(x, y float64) float64, df func(x, y, z float64) (float64, float64)) (*Tensor, error) {
+	if t1 == nil || t2 == nil {
+		return nil, fmt.Errorf("cannot compute %s with nil tensor", op)
+	}
+	shape, idx1, idx2, err := broadcast(t1, t2)
+	if err != nil {
+		return nil, fmt.Errorf("tensors must have broadcastable shapes to compute %s: %v and %v", op, t1.GetShape(), t2.GetShape())
+	}
+	data1, data2 := t1.GetData(), t2.GetData()
+	be := t1.GetBackend()
+	data := make([]float64, shapeSize(shape))
+	be.Map2(op, data, data1, data2, idx1, idx2, f)
+	out, err := NewTensorOf(dtype(t1.dtype, t2.dtype), data, shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = be
+	return recordOp(out, op, func(grad []float64) {
+		d1 := make([]float64, len(grad))
+		d2 := make([]float64, len(grad))
+		for i, g := range grad {
+			dx, dy := df(data1[at(idx1, i)], data2[at(idx2, i)], data[i])
+			d1[i] = g * dx
+			d2[i] = g * dy
+		}
+		accumulateGrad(t1, reduceBroadcast(d1, idx1, len(data1)))
+		accumulateGrad(t2, reduceBroadcast(d2, idx2, len(data2)))
+	}, t1, t2)
+}
+
+// Log returns the element-wise natural logarithm of t.
+func Log(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Log", floatType, math.Log, func(x, y float64) float64 {
+		return 1 / x
+	})
+}
+
+// Log1p returns the element-wise natural logarithm of 1 + t, accurate for small values.
+func Log1p(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Log1p", floatType, math.Log1p, func(x, y float64) float64 {
+		return 1 / (1 + x)
+	})
+}
+
+// Sqrt returns the element-wise square root of t.
+func Sqrt(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Sqrt", floatType, math.Sqrt, func(x, y float64) float64 {
+		return 0.5 / y
+	})
+}
+
+// Rsqrt returns the element-wise reciprocal of the square root of t.
+func Rsqrt(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Rsqrt", floatType, func(x float64) float64 {
+		return 1 / math.Sqrt(x)
+	}, func(x, y float64) float64 {
+		return -0.5 * y / x
+	})
+}
+
+// Reciprocal returns the element-wise reciprocal 1 / t.
+func Reciprocal(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Reciprocal", floatType, func(x float64) float64 {
+		return 1 / x
+	}, func(x, y float64) float64 {
+		return -y * y
+	})
+}
+
+// PowScalar raises every element of t to the power p.
+func PowScalar(t *Tensor, p float64) (*Tensor, error) {
+	return unaryOp(t, "PowScalar", floatType, func(x float64) float64 {
+		return math.Pow(x, p)
+	}, func(x, y float64) float64 {
+		if p == 0 {
+			return 0
+		}
+		return p * math.Pow(x, p-1)
+	})
+}
+
+// Tanh returns the element-wise hyperbolic tangent of t.
+func Tanh(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Tanh", floatType, math.Tanh, func(x, y float64) float64 {
+		return 1 - y*y
+	})
+}
+
+// Sin returns the element-wise sine of t.
+func Sin(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Sin", floatType, math.Sin, func(x, y float64) float64 {
+		return math.Cos(x)
+	})
+}
+
+// Cos returns the element-wise cosine of t.
+func Cos(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Cos", floatType, math.Cos, func(x, y float64) float64 {
+		return -math.Sin(x)
+	})
+}
+
+// Softplus returns log(1 + exp(t)) element-wise, computed without overflow for
+// large inputs. Its derivative is the sigmoid.
+func Softplus(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Softplus", floatType, func(x float64) float64 {
+		return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+	}, func(x, y float64) float64 {
+		return 1 / (1 + math.Exp(-x))
+	})
+}
+
+// LeakyRelu returns t where it is positive and slope * t elsewhere.
+func LeakyRelu(t *Tensor, slope float64) (*Tensor, error) {
+	return unaryOp(t, "LeakyRelu", floatType, func(x float64) float64 {
+		if x > 0 {
+			return x
+		}
+		return slope * x
+	}, func(x, y float64) float64 {
+		if x > 0 {
+			return 1
+		}
+		return slope
+	})
+}
+
+// Elu returns t where it is positive and alpha * (exp(t) - 1) elsewhere.
+func Elu(t *Tensor, alpha float64) (*Tensor, error) {
+	return unaryOp(t, "Elu", floatType, func(x float64) float64 {
+		if x > 0 {
+			return x
+		}
+		return alpha * math.Expm1(x)
+	}, func(x, y float64) float64 {
+		if x > 0 {
+			return 1
+		}
+		return y + alpha
+	})
+}
+
+// Gelu returns the Gaussian error linear unit t * Φ(t) element-wise, where Φ is
+// the standard normal CDF, computed exactly rather than with the tanh
+// approximation.
+func Gelu(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Gelu", floatType, func(x float64) float64 {
+		return 0.5 * x * (1 + math.Erf(x/math.Sqrt2))
+	}, func(x, y float64) float64 {
+		cdf := 0.5 * (1 + math.Erf(x/math.Sqrt2))
+		pdf := math.Exp(-0.5*x*x) / math.Sqrt(2*math.Pi)
+		return cdf + x*pdf
+	})
+}
+
+// Silu returns the sigmoid linear unit t * sigmoid(t) element-wise, also known
+// as swish.
+func Silu(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Silu", floatType, func(x float64) float64 {
+		return x / (1 + math.Exp(-x))
+	}, func(x, y float64) float64 {
+		s := 1 / (1 + math.Exp(-x))
+		return s * (1 + x*(1-s))
+	})
+}
+
+// Abs returns the element-wise absolute value of t. The gradient at 0 is 0.
+func Abs(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Abs", arithmeticType, math.Abs, func(x, y float64) float64 {
+		return sign(x)
+	})
+}
+
+// Sign returns -1, 0 or 1 element-wise depending on the sign of t. Its gradient
+// is zero everywhere.
+func Sign(t *Tensor) (*Tensor, error) {
+	return unaryOp(t, "Sign", arithmeticType, sign, func(x, y float64) float64 {
+		return 0
+	})
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return x
+}
+
+// Clamp limits every element of t to the range [min, max]. Use math.Inf to
+// leave one side unbounded. The gradient flows only to elements inside the range.
+func Clamp(t *Tensor, min, max float64) (*Tensor, error) {
+	if min > max {
+		return nil, fmt.Errorf("clamp range is empty: min %v > max %v", min, max)
+	}
+	return unaryOp(t, "Clamp", arithmeticType, func(x float64) float64 {
+		return math.Min(math.Max(x, min), max)
+	}, func(x, y float64) float64 {
+		if x < min || x > max {
+			return 0
+		}
+		return 1
+	})
+}
+
+// Div returns the element-wise quotient of t1 and t2, broadcasting the two if needed.
+func Div(t1, t2 *Tensor) (*Tensor, error) {
+	return binaryOp(t1, t2, "Div", floatType, func(x, y float64) float64 {
+		return x / y
+	}, func(x, y, z float64) (float64, float64) {
+		return 1 / y, -z / y
+	})
+}
+
+// Pow raises every element of base to the power of the matching element of
+// exponent, broadcasting the two if needed.
+func Pow(base, exponent *Tensor) (*Tensor, error) {
+	return binaryOp(base, exponent, "Pow", floatType, math.Pow, func(x, y, z float64) (float64, float64) {
+		dx := 0.0
+		if y != 0 {
+			dx = y * math.Pow(x, y-1)
+		}
+		// d/dy x^y = x^y log(x), taken as 0 at x = 0 where x^y is constant in y >= 0
+		dy := 0.0
+		if x != 0 || y < 0 {
+			dy = z * math.Log(x)
+		}
+		return dx, dy
+	})
+}
+
+// Maximum returns the element-wise maximum of t1 and t2, broadcasting the two
+// if needed. NaNs propagate and the gradient is split evenly between ties.
+func Maximum(t1, t2 *Tensor) (*Tensor, error) {
+	return binaryOp(t1, t2, "Maximum", commonType, math.Max, func(x, y, z float64) (float64, float64) {
+		return pick(x, y, x > y)
+	})
+}
+
+// Minimum returns the element-wise minimum of t1 and t2, broadcasting the two
+// if needed. NaNs propagate and the gradient is split evenly between ties.
+func Minimum(t1, t2 *Tensor) (*Tensor, error) {
+	return binaryOp(t1, t2, "Minimum", commonType, math.Min, func(x, y, z float64) (float64, float64) {
+		return pick(x, y, x < y)
+	})
+}
+
+// pick returns the gradient weights of the two inputs of Maximum or Minimum.
+func pick(x, y float64, first bool) (float64, float64) {
+	switch {
+	case x == y:
+		return 0.5, 0.5
+	case math.IsNaN(x):
+		return 1, 0
+	case math.IsNaN(y):
+		return 0, 1
+	case first:
+		return 1, 0
+	}
+	return 0, 1
+}
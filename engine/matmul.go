@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"runtime"
+	"sync"
+)
+
+const (
+	// Tile sizes for the row-major kernel, chosen so a tile of the right-hand
+	// operand (blockK x blockN float64s) stays in L2 cache.
+	blockK = 64
+	blockN = 256
+
+	// Products with fewer multiply-adds than this run on a single goroutine.
+	parallelThreshold = 1 << 15
+)
+
+// numThreads is the number of goroutines used by matrix multiplication.
+var numThreads = runtime.NumCPU()
+
+// SetNumThreads sets the number of goroutines used by matrix multiplication and
+// returns the previous value. Values below 1 are treated as 1.
+func SetNumThreads(n int) int {
+	prev := numThreads
+	if n < 1 {
+		n = 1
+	}
+	numThreads = n
+	return prev
+}
+
+// Matrix addresses a 2-D matrix inside a flat buffer: element (i, j) lives at
+// Data[Offset + i*RowStride + j*ColStride].
+type Matrix struct {
+	Data      []float64
+	Offset    int
+	RowStride int
+	ColStride int
+}
+
+// matrixOf returns a view of the 2-D tensor t. Float64 tensors are read in
+// place whatever their strides, other dtypes are converted first.
+func matrixOf(t *Tensor) Matrix {
+	if t.dtype == Float64 {
+		return Matrix{Data: t.data, Offset: t.offset, RowStride: t.strides[0], ColStride: t.strides[1]}
+	}
+	return Matrix{Data: t.GetData(), RowStride: t.shape[1], ColStride: 1}
+}
+
+// rowMajor returns a view of a packed rows x cols matrix.
+func rowMajor(data []float64, cols int) Matrix {
+	return Matrix{Data: data, RowStride: cols, ColStride: 1}
+}
+
+// T returns the transpose of v without copying.
+func (v Matrix) T() Matrix {
+	return Matrix{Data: v.Data, Offset: v.Offset, RowStride: v.ColStride, ColStride: v.RowStride}
+}
+
+// packed returns v copied into a row-major rows x cols matrix.
+func (v Matrix) packed(rows, cols int) Matrix {
+	out := make([]float64, rows*cols)
+	for i := 0; i < rows; i++ {
+		base := v.Offset + i*v.RowStride
+		for j := 0; j < cols; j++ {
+			out[i*cols+j] = v.Data[base+j*v.ColStride]
+		}
+	}
+	return rowMajor(out, cols)
+}
+
+// matmul accumulates a · b into c, where a is m x k, b is k x n and c is a packed
+// m x n matrix. Rows of c are split between goroutines for large products.
+func matmul(c []float64, a, b Matrix, m, k, n int) {
+	// Pick the kernel from the layout of b: contiguous rows stream through an
+	// axpy loop, contiguous columns become dot products against rows of a.
+	kernel := matmulRows
+	switch {
+	case b.ColStride == 1:
+	case b.RowStride == 1 && k > 1:
+		kernel = matmulCols
+		if a.ColStride != 1 {
+			a = a.packed(m, k)
+		}
+	default:
+		b = b.packed(k, n)
+	}
+
+	workers := numThreads
+	if m*k*n < parallelThreshold || workers < 2 || m < 2 {
+		kernel(c, a, b, 0, m, k, n)
+		return
+	}
+	if workers > m {
+		workers = m
+	}
+	chunk := (m + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < m; lo += chunk {
+		hi := lo + chunk
+		if hi > m {
+			hi = m
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			kernel(c, a, b, lo, hi, k, n)
+		}(lo, hi)
+	}
+	wg.Wait()
+}
+
+// matmulRows computes rows [lo, hi) of c += a · b for b with contiguous rows,
+// tiling k and n so the active block of b is reused across rows of a.
+func matmulRows(c []float64, a, b Matrix, lo, hi, k, n int) {
+	for jj := 0; jj < n; jj += blockN {
+		jEnd := jj + blockN
+		if jEnd > n {
+			jEnd = n
+		}
+		for pp := 0; pp < k; pp += blockK {
+			pEnd := pp + blockK
+			if pEnd > k {
+				pEnd = k
+			}
+			for i := lo; i < hi; i++ {
+				crow := c[i*n+jj : i*n+jEnd]
+				abase := a.Offset + i*a.RowStride
+				for p := pp; p < pEnd; p++ {
+					aip := a.Data[abase+p*a.ColStride]
+					start := b.Offset + p*b.RowStride + jj
+					brow := b.Data[start : start+len(crow)]
+					for j, bv := range brow {
+						crow[j] += aip * bv
+					}
+				}
+			}
+		}
+	}
+}
+
+// matmulCols computes rows [lo, hi) of c += a · b for b with contiguous columns
+// and a with contiguous rows, i.e. each element is a dot product of two
+// contiguous vectors.
+func matmulCols(c []float64, a, b Matrix, lo, hi, k, n int) {
+	for jj := 0; jj < n; jj += blockN {
+		jEnd := jj + blockN
+		if jEnd > n {
+			jEnd = n
+		}
+		for i := lo; i < hi; i++ {
+			arow := a.Data[a.Offset+i*a.RowStride : a.Offset+i*a.RowStride+k]
+			for j := jj; j < jEnd; j++ {
+				start := b.Offset + j*b.ColStride
+				bcol := b.Data[start : start+k]
+				sum := 0.0
+				for p, av := range arow {
+					sum += av * bcol[p]
+				}
+				c[i*n+j] += sum
+			}
+		}
+	}
+}
+
+// batchedView returns a view of the trailing two dimensions of t along with the
+// buffer offset of the matrix for every element of batch, the broadcast shape of
+// the leading dimensions. Broadcast dimensions map every index to the same matrix.
+func batchedView(t *Tensor, batch []int) (Matrix, []int) {
+	data, strides, offset := t.data, t.strides, t.offset
+	if t.dtype != Float64 {
+		data, strides, offset = t.GetData(), contiguousStrides(t.shape), 0
+	}
+	return Matrix{Data: data, RowStride: strides[len(strides)-2], ColStride: strides[len(strides)-1]},
+		batchOffsets(t.shape, strides, batch, offset)
+}
+
+// batchOffsets returns the position of the first element of every matrix of a
+// tensor with the given shape and strides, broadcast to the batch shape.
+func batchOffsets(shape, strides, batch []int, offset int) []int {
+	lead := len(shape) - 2
+	bstrides := make([]int, len(batch))
+	for i := 1; i <= lead; i++ {
+		if shape[lead-i] != 1 {
+			bstrides[len(batch)-i] = strides[lead-i]
+		}
+	}
+	return stridedIndex(batch, bstrides, offset)
+}
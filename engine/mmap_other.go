@@ -0,0 +1,17 @@
+//go:build !linux
+
+package engine
+
+import (
+	"os"
+)
+
+// mapFile reads the file at path into memory. Memory mapping is only used on
+// Linux.
+func mapFile(path string) ([]byte, func() error, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buf, func() error { return nil }, nil
+}
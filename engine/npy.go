@@ -0,0 +1,198 @@
+package engine
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// npyMagic starts every NumPy .npy file.
+const npyMagic = "\x93NUMPY"
+
+// maxNpyHeader bounds the header size of version 2 and 3 files, which is far
+// larger than any header describing a supported array.
+const maxNpyHeader = 1 << 16
+
+// npyDescr maps dtypes to NumPy type descriptors.
+var npyDescr = map[DType]string{
+	Float64: "<f8",
+	Float32: "<f4",
+	Int64:   "<i8",
+	Bool:    "|b1",
+}
+
+var (
+	npyDescrField   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortranField = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapeField   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// WriteNpy writes t to w in the NumPy .npy format, version 1.0, as a C-order
+// array of little-endian float64, float32, int64 or bool.
+func WriteNpy(w io.Writer, t *Tensor) error {
+	if t == nil {
+		return errors.New("cannot save nil tensor")
+	}
+	dims := make([]string, len(t.GetShape()))
+	for i, dim := range t.GetShape() {
+		dims[i] = strconv.Itoa(dim)
+	}
+	shape := "(" + strings.Join(dims, ", ") + ")"
+	if len(dims) == 1 {
+		shape = "(" + dims[0] + ",)"
+	}
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", npyDescr[t.dtype], shape)
+
+	// The header is padded with spaces and ended by a newline so that the data
+	// starts at a multiple of 64 bytes
+	size := len(npyMagic) + 4 + len(dict) + 1
+	dict += strings.Repeat(" ", (64-size%64)%64) + "\n"
+	if len(dict) > 0xffff {
+		return fmt.Errorf("npy header of %d bytes is too long", len(dict))
+	}
+	header := append([]byte(npyMagic), 1, 0)
+	header = binary.LittleEndian.AppendUint16(header, uint16(len(dict)))
+	header = append(header, dict...)
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write npy header: %v", err)
+	}
+	if err := writeElements(w, t); err != nil {
+		return fmt.Errorf("failed to write npy data: %v", err)
+	}
+	return nil
+}
+
+// ReadNpy reads a tensor from a NumPy .npy file. The array must be in C order
+// and hold little-endian float64, float32, int64 or bool values.
+func ReadNpy(r io.Reader) (*Tensor, error) {
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, fmt.Errorf("failed to read npy header: %v", err)
+	}
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, errors.New("not a npy file")
+	}
+	var headerLen int
+	switch major := prefix[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read npy header: %v", err)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("failed to read npy header: %v", err)
+		}
+		if n > maxNpyHeader {
+			return nil, fmt.Errorf("invalid npy header size %d", n)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported npy version %d", major)
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read npy header: %v", err)
+	}
+	dtype, shape, err := parseNpyHeader(string(header))
+	if err != nil {
+		return nil, err
+	}
+	t, err := readElements(r, dtype, shape)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read npy data: %v", err)
+	}
+	return t, nil
+}
+
+// parseNpyHeader returns the dtype and shape described by the Python dict
+// literal of a .npy header.
+func parseNpyHeader(header string) (DType, []int, error) {
+	descr := npyDescrField.FindStringSubmatch(header)
+	fortran := npyFortranField.FindStringSubmatch(header)
+	shapeField := npyShapeField.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shapeField == nil {
+		return 0, nil, fmt.Errorf("invalid npy header %q", header)
+	}
+	dtype := DType(-1)
+	for d, s := range npyDescr {
+		if s == descr[1] {
+			dtype = d
+		}
+	}
+	if dtype < 0 {
+		return 0, nil, fmt.Errorf("unsupported npy dtype %q", descr[1])
+	}
+	if fortran[1] == "True" {
+		return 0, nil, errors.New("fortran-ordered npy arrays are not supported")
+	}
+	shape := []int{}
+	for _, dim := range strings.Split(shapeField[1], ",") {
+		dim = strings.TrimSpace(dim)
+		if dim == "" {
+			continue
+		}
+		n, err := strconv.Atoi(dim)
+		if err != nil || n <= 0 {
+			return 0, nil, fmt.Errorf("invalid npy shape (%s)", shapeField[1])
+		}
+		shape = append(shape, n)
+	}
+	return dtype, shape, nil
+}
+
+// WriteNpz writes tensors to w as an uncompressed NumPy .npz archive, like
+// numpy.savez, storing each tensor as the .npy file of its name.
+func WriteNpz(w io.Writer, tensors map[string]*Tensor) error {
+	names := make([]string, 0, len(tensors))
+	for name := range tensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return fmt.Errorf("failed to write npz entry %s: %v", name, err)
+		}
+		if err := WriteNpy(f, tensors[name]); err != nil {
+			return fmt.Errorf("failed to write npz entry %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write npz archive: %v", err)
+	}
+	return nil
+}
+
+// ReadNpz reads the tensors of a NumPy .npz archive of the given size, compressed
+// or not, keyed by their names without the .npy extension.
+func ReadNpz(r io.ReaderAt, size int64) (map[string]*Tensor, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read npz archive: %v", err)
+	}
+	tensors := make(map[string]*Tensor, len(zr.File))
+	for _, f := range zr.File {
+		name := strings.TrimSuffix(f.Name, ".npy")
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read npz entry %s: %v", name, err)
+		}
+		t, err := ReadNpy(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read npz entry %s: %v", name, err)
+		}
+		tensors[name] = t
+	}
+	return tensors, nil
+}
@@ -1,10 +1,7 @@
 package engine
 
 import (
-	"errors"
 	"fmt"
-	"math"
-	"reflect"
 )
 
 // ----------------------- TENSOR -----------------------
@@ -21,7 +18,14 @@ func Flatten(t *Tensor) (*Tensor, error) {
 	if shape == nil {
 		return nil, fmt.Errorf("cannot flatten tensor with nil shape")
 	}
-	return NewTensor(data, []int{len(data), 1})
+	out, err := NewTensorOf(t.dtype, data, []int{len(data), 1})
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "Flatten", func(grad []float64) {
+		accumulateGrad(t, grad)
+	}, t)
 }
 
 func Scale(t *Tensor, v float64) (*Tensor, error) {
@@ -33,20 +37,29 @@ func Scale(t *Tensor, v float64) (*Tensor, error) {
 		return nil, fmt.Errorf("error getting tensor data")
 	}
 	shape := t.GetShape()
+	be := t.GetBackend()
 	newData := make([]float64, len(data))
-	for i, val := range data {
-		newData[i] = val * v
+	be.Scale(newData, data, v)
+	out, err := NewTensorOf(floatType(t.dtype), newData, copyShape(shape))
+	if err != nil {
+		return nil, err
 	}
-	return NewTensor(newData, shape)
+	out.backend = be
+	return recordOp(out, "Scale", func(grad []float64) {
+		dt := make([]float64, len(grad))
+		be.Scale(dt, grad, v)
+		accumulateGrad(t, dt)
+	}, t)
 }
 
 // Add returns a new tensor that is the elementwise sum of this tensor and another tensor.
-// The tensors must have the same shape.
+// The tensors must have the same shape or be broadcastable to a common shape.
 func Add(t1, t2 *Tensor) (*Tensor, error) {
 	if t1 == nil || t2 == nil {
 		return nil, fmt.Errorf("cannot perform element-wise addition with nil tensor")
 	}
-	if !SameShape(t1, t2) {
+	shape, idx1, idx2, err := broadcast(t1, t2)
+	if err != nil {
 		return nil, fmt.Errorf("cannot add tensors with different shapes { t1: %v and t2: %v }", t1.GetShape(), t2.GetShape())
 	}
 	data1 := t1.GetData()
@@ -57,104 +70,187 @@ func Add(t1, t2 *Tensor) (*Tensor, error) {
 	if data2 == nil {
 		return nil, fmt.Errorf("error getting data from tensor 2")
 	}
-	data := make([]float64, t1.GetSize())
-	for i := 0; i < t1.GetSize(); i++ {
-		data[i] = data1[i] + data2[i]
-	}
+	be := t1.GetBackend()
+	data := make([]float64, shapeSize(shape))
+	be.Add(data, data1, data2, idx1, idx2)
 
-	return NewTensor(data, t1.GetShape())
+	out, err := NewTensorOf(arithmeticType(t1.dtype, t2.dtype), data, shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = be
+	return recordOp(out, "Add", func(grad []float64) {
+		accumulateGrad(t1, reduceBroadcast(grad, idx1, len(data1)))
+		accumulateGrad(t2, reduceBroadcast(grad, idx2, len(data2)))
+	}, t1, t2)
 }
 
 // Returns a new tensor that is the element-wise product of t1 and t2.
+// The tensors must have the same shape or be broadcastable to a common shape.
 func Mul(t1, t2 *Tensor) (*Tensor, error) {
 	if t1 == nil || t2 == nil {
 		return nil, fmt.Errorf("cannot perform element-wise multiplication with nil tensor")
 	}
-	if !SameShape(t1, t2) {
-		return nil, fmt.Errorf("tensors must have the same shape to perform element-wise multiplication: %v and %v", t1.GetShape(), t2.GetShape())
+	shape, idx1, idx2, err := broadcast(t1, t2)
+	if err != nil {
+		return nil, fmt.Errorf("tensors must have broadcastable shapes to perform element-wise multiplication: %v and %v", t1.GetShape(), t2.GetShape())
 	}
-	data := make([]float64, len(t1.data))
-	for i := range t1.data {
-		data[i] = t1.data[i] * t2.data[i]
+	data1, data2 := t1.GetData(), t2.GetData()
+	be := t1.GetBackend()
+	data := make([]float64, shapeSize(shape))
+	be.Mul(data, data1, data2, idx1, idx2)
+	out, err := NewTensorOf(arithmeticType(t1.dtype, t2.dtype), data, shape)
+	if err != nil {
+		return nil, err
 	}
-	return NewTensor(data, t1.shape)
+	out.backend = be
+	return recordOp(out, "Mul", func(grad []float64) {
+		d1 := make([]float64, len(grad))
+		d2 := make([]float64, len(grad))
+		be.Mul(d1, grad, data2, nil, idx2)
+		be.Mul(d2, grad, data1, nil, idx1)
+		accumulateGrad(t1, reduceBroadcast(d1, idx1, len(data1)))
+		accumulateGrad(t2, reduceBroadcast(d2, idx2, len(data2)))
+	}, t1, t2)
 }
 
 // Dot returns a new tensor that is the matrix product of t1 and t2.
 // This is not a dot product...
 func Dot(t1, t2 *Tensor) (*Tensor, error) {
+	if t1 == nil || t2 == nil {
+		return nil, fmt.Errorf("cannot compute dot product with nil tensor")
+	}
 	// Check that the input tensors have compatible shapes
 	if len(t1.GetShape()) != 2 || len(t2.GetShape()) != 2 || t1.GetShape()[1] != t2.GetShape()[0] {
 		return nil, fmt.Errorf("incompatible shapes for dot product: t1: %v and t2: %v", t1.GetShape(), t2.GetShape())
 	}
+	m, k, n := t1.GetShape()[0], t1.GetShape()[1], t2.GetShape()[1]
 
-	// Create a new tensor to hold the result
-	shape := []int{t1.GetShape()[0], t2.GetShape()[1]}
-	result, err := NewZeroTensor(shape)
+	// Transposed views are multiplied in place, without packing them first
+	a, b := matrixOf(t1), matrixOf(t2)
+	be := t1.GetBackend()
+	data := make([]float64, m*n)
+	be.MatMul(data, a, b, m, k, n)
+
+	result, err := NewTensorOf(arithmeticType(t1.dtype, t2.dtype), data, []int{m, n})
 	if err != nil {
 		return nil, err
 	}
-
-	// Compute the dot product for each pair of rows and columns
-	for i := 0; i < shape[0]; i++ {
-		for j := 0; j < shape[1]; j++ {
-			sum := 0.0
-			for k := 0; k < t1.GetShape()[1]; k++ {
-				v1, err1 := t1.GetValue([]int{i, k})
-				if err1 != nil {
-					return nil, fmt.Errorf("error getting value from tensor 1: %v", err1)
-				}
-				v2, err2 := t2.GetValue([]int{k, j})
-				if err2 != nil {
-					return nil, fmt.Errorf("error getting value from tensor 2: %v", err2)
-				}
-				sum += v1 * v2
-			}
-			result.SetValue(sum, []int{i, j})
+	result.backend = be
+	return recordOp(result, "Dot", func(grad []float64) {
+		// d(t1) = grad . t2^T and d(t2) = t1^T . grad
+		g := rowMajor(grad, n)
+		if t1.requiresGrad {
+			d1 := make([]float64, m*k)
+			be.MatMul(d1, g, b.T(), m, n, k)
+			accumulateGrad(t1, d1)
 		}
-	}
-	return result, nil
+		if t2.requiresGrad {
+			d2 := make([]float64, k*n)
+			be.MatMul(d2, a.T(), g, k, m, n)
+			accumulateGrad(t2, d2)
+		}
+	}, t1, t2)
 }
 
-// Transpose returns a new tensor that is the transpose of the input tensor.
-func Transpose(t *Tensor) (*Tensor, error) {
-	shape := t.GetShape()
-	data := t.GetData()
-	if len(shape) == 2 {
-		rows, cols := shape[0], shape[1]
-		transposed := make([]float64, rows*cols)
-		for i := 0; i < rows; i++ {
-			for j := 0; j < cols; j++ {
-				transposed[j*rows+i] = data[i*cols+j]
+// BatchMatMul returns the matrix products of the trailing two dimensions of t1
+// and t2, e.g. [b, m, k] x [b, k, n] -> [b, m, n]. The leading batch dimensions
+// are broadcast against each other, so a [m, k] or [1, m, k] operand is reused
+// for every matrix of the other one.
+func BatchMatMul(t1, t2 *Tensor) (*Tensor, error) {
+	if t1 == nil || t2 == nil {
+		return nil, fmt.Errorf("cannot compute batched matrix product with nil tensor")
+	}
+	s1, s2 := t1.GetShape(), t2.GetShape()
+	r1, r2 := len(s1), len(s2)
+	if r1 < 2 || r2 < 2 || s1[r1-1] != s2[r2-2] {
+		return nil, fmt.Errorf("incompatible shapes for batched matrix product: t1: %v and t2: %v", s1, s2)
+	}
+	batch, err := BroadcastShapes(s1[:r1-2], s2[:r2-2])
+	if err != nil {
+		return nil, fmt.Errorf("incompatible batch dimensions for batched matrix product: %v", err)
+	}
+	m, k, n := s1[r1-2], s1[r1-1], s2[r2-1]
+
+	a, offs1 := batchedView(t1, batch)
+	b, offs2 := batchedView(t2, batch)
+	be := t1.GetBackend()
+	data := make([]float64, len(offs1)*m*n)
+	for i := range offs1 {
+		a.Offset, b.Offset = offs1[i], offs2[i]
+		be.MatMul(data[i*m*n:(i+1)*m*n], a, b, m, k, n)
+	}
+
+	shape := append(copyShape(batch), m, n)
+	result, err := NewTensorOf(arithmeticType(t1.dtype, t2.dtype), data, shape)
+	if err != nil {
+		return nil, err
+	}
+	result.backend = be
+	return recordOp(result, "BatchMatMul", func(grad []float64) {
+		// Same as Dot for every matrix. Gradients of broadcast matrices land on
+		// the same packed offset, and MatMul accumulates, which sums them.
+		if t1.requiresGrad {
+			d1 := make([]float64, shapeSize(s1))
+			dst := batchOffsets(s1, contiguousStrides(s1), batch, 0)
+			for i := range offs1 {
+				b.Offset = offs2[i]
+				g := rowMajor(grad[i*m*n:(i+1)*m*n], n)
+				be.MatMul(d1[dst[i]:dst[i]+m*k], g, b.T(), m, n, k)
 			}
+			accumulateGrad(t1, d1)
 		}
-		return NewTensor(transposed, []int{shape[1], shape[0]})
-	} else if len(shape) == 3 {
-		return nil, fmt.Errorf("transpose is not defined for tensors with shape %v", shape)
-	} else {
-		return nil, fmt.Errorf("transpose is not defined for tensors with shape %v", shape)
-	}
+		if t2.requiresGrad {
+			d2 := make([]float64, shapeSize(s2))
+			dst := batchOffsets(s2, contiguousStrides(s2), batch, 0)
+			for i := range offs2 {
+				a.Offset = offs1[i]
+				g := rowMajor(grad[i*m*n:(i+1)*m*n], n)
+				be.MatMul(d2[dst[i]:dst[i]+k*n], a.T(), g, k, m, n)
+			}
+			accumulateGrad(t2, d2)
+		}
+	}, t1, t2)
 }
 
 // Relu applies the rectified linear unit (ReLU) function element-wise to the tensor.
 func Relu(t *Tensor) (*Tensor, error) {
-	data := make([]float64, len(t.data))
-	for i, v := range t.data {
-		if v > 0 {
-			data[i] = v
-		} else {
-			data[i] = 0
-		}
+	in := t.GetData()
+	be := t.GetBackend()
+	data := make([]float64, len(in))
+	be.Relu(data, in)
+	out, err := NewTensorOf(arithmeticType(t.dtype), data, copyShape(t.shape))
+	if err != nil {
+		return nil, err
 	}
-	return NewTensor(data, t.shape)
+	out.backend = be
+	return recordOp(out, "Relu", func(grad []float64) {
+		dt := make([]float64, len(grad))
+		be.Map2("ReluBackward", dt, grad, in, nil, nil, func(g, x float64) float64 {
+			if x > 0 {
+				return g
+			}
+			return 0
+		})
+		accumulateGrad(t, dt)
+	}, t)
 }
 
 func Sigmoid(t *Tensor) (*Tensor, error) {
-	data := make([]float64, len(t.data))
-	for i, v := range t.data {
-		data[i] = 1 / (1 + math.Exp(-v))
+	in := t.GetData()
+	be := t.GetBackend()
+	data := make([]float64, len(in))
+	be.Sigmoid(data, in)
+	out, err := NewTensorOf(floatType(t.dtype), data, copyShape(t.shape))
+	if err != nil {
+		return nil, err
 	}
-	return NewTensor(data, t.shape)
+	out.backend = be
+	return recordOp(out, "Sigmoid", func(grad []float64) {
+		dt := make([]float64, len(grad))
+		be.Map2("SigmoidBackward", dt, grad, data, nil, nil, func(g, y float64) float64 { return g * y * (1 - y) })
+		accumulateGrad(t, dt)
+	}, t)
 }
 
 // Square computes the element-wise square of the input tensor.
@@ -163,110 +259,46 @@ func Square(t *Tensor) (*Tensor, error) {
 		return nil, fmt.Errorf("input tensor is nil")
 	}
 	data := t.GetData()
+	be := t.GetBackend()
 	sqData := make([]float64, len(data))
-	for i := 0; i < len(data); i++ {
-		sqData[i] = data[i] * data[i]
-	}
-	out, _ := NewTensor(sqData, t.GetShape())
-	return out, nil
-}
-
-// Mean computes the mean of each row of the input tensor and returns a tensor of shape (1, lenout).
-func Mean(t *Tensor) (*Tensor, error) {
-	if t == nil {
-		return nil, fmt.Errorf("input tensor is nil")
-	}
-
-	shape := t.GetShape()
-	if len(shape) != 2 {
-		return nil, fmt.Errorf("input tensor must have 2 dimensions")
-	}
-	rows, cols := shape[0], shape[1]
-
-	meanData := make([]float64, rows)
-	for i := 0; i < rows; i++ {
-		rowStart := i * cols
-		rowEnd := rowStart + cols
-		rowSum := 0.0
-		for j := rowStart; j < rowEnd; j++ {
-			rowSum += t.GetData()[j]
-		}
-		meanData[i] = rowSum / float64(cols)
-	}
-
-	meanTensor, _ := NewTensor(meanData, []int{rows, 1})
-	return meanTensor, nil
-}
-
-func Max(t *Tensor) (*Tensor, error) {
-	if t == nil {
-		return nil, errors.New("input tensor is nil")
-	}
-	data := t.GetData()
-	if len(data) == 0 {
-		return nil, fmt.Errorf("input tensor has no elements")
-	}
-	shape := t.GetShape()
-	if len(shape) != 2 {
-		return nil, fmt.Errorf("input tensor should have 2 dimensions")
-	}
-	maxData := make([]float64, shape[0])
-	for i := 0; i < shape[0]; i++ {
-		max := math.Inf(-1)
-		for j := 0; j < shape[1]; j++ {
-			if data[i*shape[1]+j] > max {
-				max = data[i*shape[1]+j]
-			}
-		}
-		maxData[i] = max
-	}
-	out, _ := NewTensor(maxData, []int{shape[0], 1})
-	return out, nil
-}
-
-func Min(t *Tensor) (*Tensor, error) {
-	if t == nil {
-		return nil, errors.New("input tensor is nil")
-	}
-	data := t.GetData()
-	if len(data) == 0 {
-		return nil, fmt.Errorf("input tensor has no elements")
-	}
-	shape := t.GetShape()
-	if len(shape) != 2 {
-		return nil, fmt.Errorf("input tensor should have 2 dimensions")
-	}
-	minData := make([]float64, shape[0])
-	for i := 0; i < shape[0]; i++ {
-		min := math.Inf(1)
-		for j := 0; j < shape[1]; j++ {
-			if data[i*shape[1]+j] < min {
-				min = data[i*shape[1]+j]
-			}
-		}
-		minData[i] = min
+	be.Square(sqData, data)
+	out, err := NewTensorOf(arithmeticType(t.dtype), sqData, copyShape(t.GetShape()))
+	if err != nil {
+		return nil, err
 	}
-	out, _ := NewTensor(minData, []int{shape[0], 1})
-	return out, nil
+	out.backend = be
+	return recordOp(out, "Square", func(grad []float64) {
+		dt := make([]float64, len(grad))
+		be.Mul(dt, grad, data, nil, nil)
+		be.Scale(dt, dt, 2)
+		accumulateGrad(t, dt)
+	}, t)
 }
 
 func Neg(t *Tensor) (*Tensor, error) {
 	data := t.GetData()
+	be := t.GetBackend()
 	outdata := make([]float64, len(data))
-	for i := 0; i < len(data); i++ {
-		outdata[i] = -data[i]
-	}
-	out, err := NewTensor(outdata, t.GetShape())
+	be.Neg(outdata, data)
+	out, err := NewTensorOf(arithmeticType(t.dtype), outdata, copyShape(t.GetShape()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create negated tensor in Neg(): %v", err)
 	}
-	return out, nil
+	out.backend = be
+	return recordOp(out, "Neg", func(grad []float64) {
+		dt := make([]float64, len(grad))
+		be.Neg(dt, grad)
+		accumulateGrad(t, dt)
+	}, t)
 }
 
-// Sub subtracts tensor y from tensor x.
+// Sub subtracts tensor y from tensor x, broadcasting the two if needed.
 func Sub(t1, t2 *Tensor) (*Tensor, error) {
-	if !reflect.DeepEqual(t1.shape, t2.shape) {
-		return nil, fmt.Errorf("tensors are not of the same shape t1: %v compare to t2: %v", t1.GetShape(), t2.GetShape())
+	if t1 == nil || t2 == nil {
+		return nil, fmt.Errorf("cannot perform element-wise subtraction with nil tensor")
+	}
+	if _, err := BroadcastShapes(t1.GetShape(), t2.GetShape()); err != nil {
+		return nil, fmt.Errorf("tensors are not of broadcastable shapes t1: %v compare to t2: %v", t1.GetShape(), t2.GetShape())
 	}
 
 	// Negate y and add it to x
@@ -284,40 +316,22 @@ func Sub(t1, t2 *Tensor) (*Tensor, error) {
 
 // Exp applies the exponential function element-wise to the input tensor.
 func Exp(t *Tensor) (*Tensor, error) {
-	// Create a new tensor to hold the output values
-	out, err := NewZeroTensor(t.GetShape())
-	if err != nil {
-		return nil, err
-	}
-
 	// Apply the exponential function element-wise to the input tensor
-	for i, x := range t.GetData() {
-		out.data[i] = math.Exp(x)
-	}
+	in := t.GetData()
+	be := t.GetBackend()
+	data := make([]float64, len(in))
+	be.Exp(data, in)
 
-	return out, nil
-}
-
-func SumCols(t *Tensor) (*Tensor, error) {
-	shape := t.GetShape()
-	data := t.GetData()
-	if len(shape) < 2 {
-		return nil, fmt.Errorf("cannot sum columns of a tensor with less than 2 dimensions")
-	}
-	cols := shape[len(shape)-1]
-	rows := 1
-	if len(shape) > 2 {
-		rows = len(shape[:len(shape)-1])
-	}
-	colSums := make([]float64, cols)
-	for i := 0; i < rows; i++ {
-		for j := 0; j < cols; j++ {
-			colSums[j] += data[i*cols+j]
-		}
-	}
-	out, err := NewTensor(colSums, []int{1, cols})
+	// Create a new tensor to hold the output values
+	out, err := NewTensorOf(floatType(t.dtype), data, copyShape(t.GetShape()))
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	out.backend = be
+
+	return recordOp(out, "Exp", func(grad []float64) {
+		dt := make([]float64, len(grad))
+		be.Mul(dt, grad, data, nil, nil)
+		accumulateGrad(t, dt)
+	}, t)
 }
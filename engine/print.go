@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SciMode selects when floats are printed in scientific notation.
+type SciMode int
+
+const (
+	// SciAuto uses scientific notation when fixed-point would print very large
+	// or very small values badly, like NumPy.
+	SciAuto SciMode = iota
+	// SciAlways always uses scientific notation.
+	SciAlways
+	// SciNever always uses fixed-point notation.
+	SciNever
+)
+
+// PrintOptions controls how tensors are formatted by String.
+type PrintOptions struct {
+	Precision int     // Digits after the decimal point of floats
+	Threshold int     // Tensors with more elements than this are summarised
+	EdgeItems int     // Items kept at each end of a summarised dimension
+	LineWidth int     // Rows longer than this are wrapped
+	Sci       SciMode // When to use scientific notation
+}
+
+// DefaultPrintOptions returns the options used until SetPrintOptions is called.
+func DefaultPrintOptions() PrintOptions {
+	return PrintOptions{Precision: 4, Threshold: 1000, EdgeItems: 3, LineWidth: 75, Sci: SciAuto}
+}
+
+// printOptions are the options used by String.
+var printOptions = DefaultPrintOptions()
+
+// SetPrintOptions sets the options used by String and returns the previous ones.
+func SetPrintOptions(opts PrintOptions) PrintOptions {
+	prev := printOptions
+	printOptions = opts
+	return prev
+}
+
+// GetPrintOptions returns the options used by String.
+func GetPrintOptions() PrintOptions {
+	return printOptions
+}
+
+// String formats the tensor with the global print options, nesting one pair of
+// brackets per dimension like NumPy.
+func (t *Tensor) String() string {
+	return t.Format(printOptions)
+}
+
+// Format formats the tensor with the given options. Tensors with more than
+// opts.Threshold elements only show opts.EdgeItems items at both ends of each
+// long dimension, with "..." in between.
+func (t *Tensor) Format(opts PrintOptions) string {
+	if t == nil {
+		return "<nil>"
+	}
+	if !t.hasBuffer() {
+		return "[]"
+	}
+	shape := t.GetShape()
+	if opts.Precision < 0 {
+		opts.Precision = 0
+	}
+	if opts.EdgeItems < 1 {
+		opts.EdgeItems = 1
+	}
+
+	// Indices shown along every dimension, -1 standing for the ellipsis
+	summarise := t.GetSize() > opts.Threshold
+	shown := make([][]int, len(shape))
+	for d, dim := range shape {
+		if summarise && dim > 2*opts.EdgeItems {
+			for i := 0; i < opts.EdgeItems; i++ {
+				shown[d] = append(shown[d], i)
+			}
+			shown[d] = append(shown[d], -1)
+			for i := dim - opts.EdgeItems; i < dim; i++ {
+				shown[d] = append(shown[d], i)
+			}
+		} else {
+			for i := 0; i < dim; i++ {
+				shown[d] = append(shown[d], i)
+			}
+		}
+	}
+	p := &printer{shape: shape, strides: contiguousStrides(shape), shown: shown, lineWidth: opts.LineWidth}
+
+	// Format the shown elements first so that they can be padded to one width
+	var visit func(d, base int)
+	var positions []int
+	visit = func(d, base int) {
+		if d == len(shape) {
+			positions = append(positions, base)
+			return
+		}
+		for _, i := range shown[d] {
+			if i >= 0 {
+				visit(d+1, base+i*p.strides[d])
+			}
+		}
+	}
+	visit(0, 0)
+	p.items = make(map[int]string, len(positions))
+	switch t.dtype {
+	case Int64:
+		data := t.GetInt64Data()
+		for _, pos := range positions {
+			p.items[pos] = strconv.FormatInt(data[pos], 10)
+		}
+	case Bool:
+		data := t.GetBoolData()
+		for _, pos := range positions {
+			p.items[pos] = strconv.FormatBool(data[pos])
+		}
+	default:
+		data := t.GetData()
+		values := make([]float64, len(positions))
+		for i, pos := range positions {
+			values[i] = data[pos]
+		}
+		format := floatFormat(values, opts)
+		for i, pos := range positions {
+			p.items[pos] = format(values[i])
+		}
+	}
+	for _, s := range p.items {
+		if len(s) > p.width {
+			p.width = len(s)
+		}
+	}
+	return p.format(0, 0, 0)
+}
+
+// floatFormat returns the formatter of the given float values. Automatic
+// scientific notation follows NumPy: it is used when the largest absolute value
+// is at least 1e8, the smallest non-zero one is below 1e-4, or they are more
+// than three orders of magnitude apart.
+func floatFormat(values []float64, opts PrintOptions) func(float64) string {
+	sci := opts.Sci == SciAlways
+	if opts.Sci == SciAuto {
+		maxAbs, minAbs := 0.0, math.Inf(1)
+		for _, v := range values {
+			a := math.Abs(v)
+			if a == 0 || math.IsInf(a, 0) || math.IsNaN(a) {
+				continue
+			}
+			maxAbs = math.Max(maxAbs, a)
+			minAbs = math.Min(minAbs, a)
+		}
+		sci = maxAbs >= 1e8 || (maxAbs > 0 && (minAbs < 1e-4 || maxAbs/minAbs > 1e3))
+	}
+	return func(v float64) string {
+		switch {
+		case math.IsNaN(v):
+			return "nan"
+		case math.IsInf(v, 1):
+			return "inf"
+		case math.IsInf(v, -1):
+			return "-inf"
+		case sci:
+			return strconv.FormatFloat(v, 'e', opts.Precision, 64)
+		}
+		return strconv.FormatFloat(v, 'f', opts.Precision, 64)
+	}
+}
+
+// printer lays out the formatted elements of a tensor.
+type printer struct {
+	shape     []int
+	strides   []int
+	shown     [][]int
+	items     map[int]string // Formatted elements by row-major position
+	width     int            // Width every element is padded to
+	lineWidth int
+}
+
+// format returns the brackets of dimension d starting at row-major position
+// base, which is printed after indent characters.
+func (p *printer) format(d, base, indent int) string {
+	if d == len(p.shape) {
+		return p.pad(p.items[base])
+	}
+	var sb strings.Builder
+	sb.WriteString("[")
+	if d == len(p.shape)-1 {
+		// Innermost dimension: wrap the elements to the line width
+		line := indent + 1
+		for n, i := range p.shown[d] {
+			item := "..."
+			if i >= 0 {
+				item = p.pad(p.items[base+i*p.strides[d]])
+			}
+			if n > 0 {
+				if line+2+len(item)+1 > p.lineWidth {
+					sb.WriteString(",\n" + strings.Repeat(" ", indent+1))
+					line = indent + 1
+				} else {
+					sb.WriteString(", ")
+					line += 2
+				}
+			}
+			sb.WriteString(item)
+			line += len(item)
+		}
+	} else {
+		// Outer dimensions: one line per row, with a blank line between blocks
+		// of higher dimensions
+		sep := "," + strings.Repeat("\n", len(p.shape)-1-d) + strings.Repeat(" ", indent+1)
+		for n, i := range p.shown[d] {
+			if n > 0 {
+				sb.WriteString(sep)
+			}
+			if i < 0 {
+				sb.WriteString("...")
+				continue
+			}
+			sb.WriteString(p.format(d+1, base+i*p.strides[d], indent+1))
+		}
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// pad right-aligns s to the width of the widest element.
+func (p *printer) pad(s string) string {
+	if len(s) >= p.width {
+		return s
+	}
+	return strings.Repeat(" ", p.width-len(s)) + s
+}
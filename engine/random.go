@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// DefaultSeed is the seed of the default generator, so that programs that never
+// call ManualSeed still produce the same random tensors on every run.
+const DefaultSeed = 42
+
+// Generator is a seeded source of random numbers. The random constructors take
+// a *Generator and use the default generator when it is nil. A Generator is
+// safe for concurrent use.
+type Generator struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewGenerator returns a generator seeded with seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rng: rand.New(rand.NewSource(seed))}
+}
+
+var defaultGenerator = NewGenerator(DefaultSeed)
+
+// DefaultGenerator returns the generator used when nil is passed to a random
+// constructor.
+func DefaultGenerator() *Generator {
+	return defaultGenerator
+}
+
+// ManualSeed reseeds the default generator.
+func ManualSeed(seed int64) {
+	defaultGenerator.Seed(seed)
+}
+
+// generator returns g, or the default generator if g is nil.
+func generator(g *Generator) *Generator {
+	if g == nil {
+		return defaultGenerator
+	}
+	return g
+}
+
+// Seed resets g to the state of NewGenerator(seed).
+func (g *Generator) Seed(seed int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rng.Seed(seed)
+}
+
+// Float64 returns a uniform number in [0, 1).
+func (g *Generator) Float64() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.Float64()
+}
+
+// NormFloat64 returns a standard normally distributed number.
+func (g *Generator) NormFloat64() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.NormFloat64()
+}
+
+// Intn returns a uniform integer in [0, n). It panics if n <= 0.
+func (g *Generator) Intn(n int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.Intn(n)
+}
+
+// Perm returns a uniform random permutation of [0, n).
+func (g *Generator) Perm(n int) []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rng.Perm(n)
+}
+
+// Shuffle shuffles n elements with swap, like rand.Shuffle.
+func (g *Generator) Shuffle(n int, swap func(i, j int)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rng.Shuffle(n, swap)
+}
+
+// fill returns a float64 tensor of the given shape whose element i is drawn by
+// sample(rng, i), holding the lock of g for the whole tensor.
+func (g *Generator) fill(shape []int, sample func(rng *rand.Rand, i int) float64) (*Tensor, error) {
+	size := shapeSize(shape)
+	if err := checkShape(size, shape); err != nil {
+		return nil, err
+	}
+	data := make([]float64, size)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := range data {
+		data[i] = sample(g.rng, i)
+	}
+	return NewTensor(data, copyShape(shape))
+}
+
+// Uniform returns a tensor of the given shape with elements drawn uniformly
+// from [low, high).
+func Uniform(g *Generator, shape []int, low, high float64) (*Tensor, error) {
+	if !(low < high) {
+		return nil, fmt.Errorf("invalid uniform range [%v, %v)", low, high)
+	}
+	return generator(g).fill(shape, func(rng *rand.Rand, _ int) float64 {
+		return low + (high-low)*rng.Float64()
+	})
+}
+
+// Normal returns a tensor of the given shape with elements drawn from the
+// normal distribution of the given mean and standard deviation.
+func Normal(g *Generator, shape []int, mean, std float64) (*Tensor, error) {
+	if !(std >= 0) {
+		return nil, fmt.Errorf("standard deviation must be non-negative, got %v", std)
+	}
+	return generator(g).fill(shape, func(rng *rand.Rand, _ int) float64 {
+		return mean + std*rng.NormFloat64()
+	})
+}
+
+// TruncatedNormal returns a tensor of the given shape with elements drawn from
+// the normal distribution of the given mean and standard deviation restricted
+// to [low, high]. The samples are drawn by inverting the normal CDF, so bounds
+// far in the tails do not slow it down.
+func TruncatedNormal(g *Generator, shape []int, mean, std, low, high float64) (*Tensor, error) {
+	if !(std > 0) {
+		return nil, fmt.Errorf("standard deviation must be positive, got %v", std)
+	}
+	if !(low < high) {
+		return nil, fmt.Errorf("invalid truncation range [%v, %v]", low, high)
+	}
+	cdf := func(x float64) float64 { return 0.5 * (1 + math.Erf((x-mean)/(std*math.Sqrt2))) }
+	lo, hi := cdf(low), cdf(high)
+	return generator(g).fill(shape, func(rng *rand.Rand, _ int) float64 {
+		p := lo + (hi-lo)*rng.Float64()
+		x := mean + std*math.Sqrt2*math.Erfinv(2*p-1)
+		return math.Min(math.Max(x, low), high)
+	})
+}
+
+// Bernoulli returns a tensor of the shape of p whose elements are 1 with the
+// probability given by the matching element of p and 0 otherwise.
+func Bernoulli(g *Generator, p *Tensor) (*Tensor, error) {
+	if p == nil {
+		return nil, errors.New("probability tensor is nil")
+	}
+	probs := p.GetData()
+	for _, v := range probs {
+		if !(v >= 0 && v <= 1) {
+			return nil, fmt.Errorf("probability %v is not in [0, 1]", v)
+		}
+	}
+	return generator(g).fill(p.GetShape(), func(rng *rand.Rand, i int) float64 {
+		if rng.Float64() < probs[i] {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Multinomial draws n category indices from the unnormalized, non-negative
+// weights in probs. For 1-D probs the result is an int64 tensor of shape [n];
+// for 2-D probs every row is a separate distribution and the result has shape
+// [rows, n]. Without replacement, a category is never drawn twice and n must
+// not exceed the number of categories of non-zero weight.
+func Multinomial(g *Generator, probs *Tensor, n int, replacement bool) (*Tensor, error) {
+	if probs == nil {
+		return nil, errors.New("probability tensor is nil")
+	}
+	shape := probs.GetShape()
+	if len(shape) != 1 && len(shape) != 2 {
+		return nil, fmt.Errorf("probabilities must be 1-D or 2-D, got shape %v", shape)
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("number of samples must be positive, got %d", n)
+	}
+	k := shape[len(shape)-1]
+	rows := probs.GetSize() / k
+	data := probs.GetData()
+	for r := 0; r < rows; r++ {
+		total, nonzero := 0.0, 0
+		for _, w := range data[r*k : (r+1)*k] {
+			if !(w >= 0) || math.IsInf(w, 0) {
+				return nil, fmt.Errorf("invalid multinomial weight %v", w)
+			}
+			total += w
+			if w > 0 {
+				nonzero++
+			}
+		}
+		if total == 0 {
+			return nil, errors.New("multinomial weights sum to zero")
+		}
+		if !replacement && n > nonzero {
+			return nil, fmt.Errorf("cannot draw %d samples without replacement from %d categories", n, nonzero)
+		}
+	}
+
+	gen := generator(g)
+	out := make([]int64, rows*n)
+	weights := make([]float64, k)
+	gen.mu.Lock()
+	for r := 0; r < rows; r++ {
+		copy(weights, data[r*k:(r+1)*k])
+		for s := 0; s < n; s++ {
+			total := 0.0
+			for _, w := range weights {
+				total += w
+			}
+			// Pick the first category whose cumulative weight exceeds u, skipping
+			// zero weights so that they are never drawn
+			u := gen.rng.Float64() * total
+			c := 0
+			for i, w := range weights {
+				if w == 0 {
+					continue
+				}
+				c = i
+				if u < w {
+					break
+				}
+				u -= w
+			}
+			out[r*n+s] = int64(c)
+			if !replacement {
+				weights[c] = 0
+			}
+		}
+	}
+	gen.mu.Unlock()
+	oshape := []int{n}
+	if len(shape) == 2 {
+		oshape = []int{rows, n}
+	}
+	return NewInt64Tensor(out, oshape)
+}
+
+// RandPerm returns a 1-D int64 tensor holding a random permutation of [0, n).
+func RandPerm(g *Generator, n int) (*Tensor, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("permutation size must be positive, got %d", n)
+	}
+	perm := generator(g).Perm(n)
+	data := make([]int64, n)
+	for i, v := range perm {
+		data[i] = int64(v)
+	}
+	return NewInt64Tensor(data, []int{n})
+}
@@ -0,0 +1,335 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// reduction describes how the elements of a tensor fold onto the result of a
+// reduction over some of its axes.
+type reduction struct {
+	shape []int // shape of the result
+	index []int // flat index of the result each input element reduces into
+	count int   // number of input elements per result element
+}
+
+// normalizeAxes resolves negative axes and checks for duplicates. An empty
+// list selects every axis.
+func normalizeAxes(axes []int, rank int) ([]int, error) {
+	if len(axes) == 0 {
+		all := make([]int, rank)
+		for i := range all {
+			all[i] = i
+		}
+		return all, nil
+	}
+	out := make([]int, len(axes))
+	for i, axis := range axes {
+		a, err := normalizeAxis(axis, rank)
+		if err != nil {
+			return nil, err
+		}
+		if IsNumIn(a, out[:i]) {
+			return nil, fmt.Errorf("duplicate axis %d in %v", axis, axes)
+		}
+		out[i] = a
+	}
+	return out, nil
+}
+
+// normalizeAxis resolves a possibly negative axis against a tensor of the given rank.
+func normalizeAxis(axis, rank int) (int, error) {
+	if axis < -rank || axis >= rank {
+		return 0, fmt.Errorf("axis %d is out of range for tensor of rank %d", axis, rank)
+	}
+	if axis < 0 {
+		axis += rank
+	}
+	return axis, nil
+}
+
+func newReduction(t *Tensor, axes []int, keepdims bool) (*reduction, error) {
+	if t == nil {
+		return nil, errors.New("input tensor is nil")
+	}
+	shape := t.GetShape()
+	axes, err := normalizeAxes(axes, len(shape))
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make([]int, len(shape))
+	out := make([]int, 0, len(shape))
+	count := 1
+	for i, dim := range shape {
+		if IsNumIn(i, axes) {
+			kept[i] = 1
+			count *= dim
+			if keepdims {
+				out = append(out, 1)
+			}
+			continue
+		}
+		kept[i] = dim
+		out = append(out, dim)
+	}
+
+	index := broadcastIndex(kept, shape)
+	if index == nil {
+		index = make([]int, t.GetSize())
+		for i := range index {
+			index[i] = i
+		}
+	}
+	return &reduction{shape: out, index: index, count: count}, nil
+}
+
+// size returns the number of elements in the result of the reduction.
+func (r *reduction) size() int {
+	return shapeSize(r.shape)
+}
+
+// Sum returns the sum of the elements of t over the given axes. If axes is empty
+// every axis is reduced. With keepdims the reduced axes are kept with size 1.
+func Sum(t *Tensor, axes []int, keepdims bool) (*Tensor, error) {
+	r, err := newReduction(t, axes, keepdims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute sum: %v", err)
+	}
+	data := t.GetData()
+	be := t.GetBackend()
+	sums := make([]float64, r.size())
+	be.Sum(sums, data, r.index)
+	out, err := NewTensorOf(arithmeticType(t.dtype), sums, r.shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = be
+	return recordOp(out, "Sum", func(grad []float64) {
+		dt := make([]float64, len(data))
+		for i, idx := range r.index {
+			dt[i] = grad[idx]
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// Mean returns the mean of the elements of t over the given axes. If axes is
+// empty every axis is reduced. With keepdims the reduced axes are kept with size 1.
+func Mean(t *Tensor, axes []int, keepdims bool) (*Tensor, error) {
+	r, err := newReduction(t, axes, keepdims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute mean: %v", err)
+	}
+	data := t.GetData()
+	be := t.GetBackend()
+	means := make([]float64, r.size())
+	be.Sum(means, data, r.index)
+	n := float64(r.count)
+	for i := range means {
+		means[i] /= n
+	}
+	out, err := NewTensorOf(floatType(t.dtype), means, r.shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = be
+	return recordOp(out, "Mean", func(grad []float64) {
+		dt := make([]float64, len(data))
+		for i, idx := range r.index {
+			dt[i] = grad[idx] / n
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// Max returns the largest element of t over the given axes. The gradient flows
+// to the first occurrence of the maximum.
+func Max(t *Tensor, axes []int, keepdims bool) (*Tensor, error) {
+	return extremum(t, axes, keepdims, "Max", func(a, b float64) bool { return a > b })
+}
+
+// Min returns the smallest element of t over the given axes. The gradient flows
+// to the first occurrence of the minimum.
+func Min(t *Tensor, axes []int, keepdims bool) (*Tensor, error) {
+	return extremum(t, axes, keepdims, "Min", func(a, b float64) bool { return a < b })
+}
+
+// extremum reduces t keeping, for every result element, the input element that
+// wins according to better.
+func extremum(t *Tensor, axes []int, keepdims bool, op string, better func(a, b float64) bool) (*Tensor, error) {
+	r, err := newReduction(t, axes, keepdims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute %s: %v", op, err)
+	}
+	data := t.GetData()
+	if len(data) == 0 {
+		return nil, fmt.Errorf("input tensor has no elements")
+	}
+	pos := argExtremum(data, r, better)
+	values := make([]float64, len(pos))
+	for i, p := range pos {
+		values[i] = data[p]
+	}
+	out, err := NewTensorOf(t.dtype, values, r.shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, op, func(grad []float64) {
+		dt := make([]float64, len(data))
+		for i, p := range pos {
+			dt[p] += grad[i]
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// argExtremum returns, for every result element of r, the flat input position of
+// the first element that wins according to better. NaNs always win so that they
+// propagate like in NumPy.
+func argExtremum(data []float64, r *reduction, better func(a, b float64) bool) []int {
+	pos := make([]int, r.size())
+	seen := make([]bool, len(pos))
+	for i, v := range data {
+		idx := r.index[i]
+		if !seen[idx] {
+			seen[idx] = true
+			pos[idx] = i
+			continue
+		}
+		cur := data[pos[idx]]
+		if math.IsNaN(cur) {
+			continue
+		}
+		if math.IsNaN(v) || better(v, cur) {
+			pos[idx] = i
+		}
+	}
+	return pos
+}
+
+// ArgMax returns the int64 index along axis of the largest element of t.
+func ArgMax(t *Tensor, axis int, keepdims bool) (*Tensor, error) {
+	return argReduce(t, axis, keepdims, func(a, b float64) bool { return a > b })
+}
+
+// ArgMin returns the int64 index along axis of the smallest element of t.
+func ArgMin(t *Tensor, axis int, keepdims bool) (*Tensor, error) {
+	return argReduce(t, axis, keepdims, func(a, b float64) bool { return a < b })
+}
+
+func argReduce(t *Tensor, axis int, keepdims bool, better func(a, b float64) bool) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("input tensor is nil")
+	}
+	shape := t.GetShape()
+	a, err := normalizeAxis(axis, len(shape))
+	if err != nil {
+		return nil, err
+	}
+	r, err := newReduction(t, []int{a}, keepdims)
+	if err != nil {
+		return nil, err
+	}
+	stride := shapeSize(shape[a+1:])
+	pos := argExtremum(t.GetData(), r, better)
+	indices := make([]int64, len(pos))
+	for i, p := range pos {
+		indices[i] = int64((p / stride) % shape[a])
+	}
+	out, err := NewInt64Tensor(indices, r.shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return out, nil
+}
+
+// Var returns the variance of t over the given axes, dividing by N - ddof where
+// N is the number of elements reduced. Use ddof = 1 for the unbiased estimator.
+func Var(t *Tensor, axes []int, ddof int, keepdims bool) (*Tensor, error) {
+	return variance(t, axes, ddof, keepdims, false)
+}
+
+// Std returns the standard deviation of t over the given axes, i.e. the square
+// root of Var with the same arguments.
+func Std(t *Tensor, axes []int, ddof int, keepdims bool) (*Tensor, error) {
+	return variance(t, axes, ddof, keepdims, true)
+}
+
+func variance(t *Tensor, axes []int, ddof int, keepdims, std bool) (*Tensor, error) {
+	op := "Var"
+	if std {
+		op = "Std"
+	}
+	r, err := newReduction(t, axes, keepdims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute %s: %v", op, err)
+	}
+	if r.count-ddof <= 0 {
+		return nil, fmt.Errorf("degrees of freedom <= 0 for %s over %d elements with ddof %d", op, r.count, ddof)
+	}
+	data := t.GetData()
+	be := t.GetBackend()
+	means := make([]float64, r.size())
+	be.Sum(means, data, r.index)
+	for i := range means {
+		means[i] /= float64(r.count)
+	}
+	denom := float64(r.count - ddof)
+	values := make([]float64, len(means))
+	for i, v := range data {
+		d := v - means[r.index[i]]
+		values[r.index[i]] += d * d
+	}
+	for i := range values {
+		values[i] /= denom
+		if std {
+			values[i] = math.Sqrt(values[i])
+		}
+	}
+	out, err := NewTensorOf(floatType(t.dtype), values, r.shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = be
+	return recordOp(out, op, func(grad []float64) {
+		// d var / dx = 2 (x - mean) / (N - ddof) and d std = d var / (2 std)
+		dt := make([]float64, len(data))
+		for i, v := range data {
+			idx := r.index[i]
+			g := grad[idx] * 2 * (v - means[idx]) / denom
+			if std {
+				g /= 2 * values[idx]
+			}
+			dt[i] = g
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// SumCols sums a tensor over every axis but the last and returns a tensor of
+// shape (1, cols).
+func SumCols(t *Tensor) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("input tensor is nil")
+	}
+	shape := t.GetShape()
+	if len(shape) < 2 {
+		return nil, fmt.Errorf("cannot sum columns of a tensor with less than 2 dimensions")
+	}
+	axes := make([]int, len(shape)-1)
+	for i := range axes {
+		axes[i] = i
+	}
+	out, err := Sum(t, axes, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := out.Reshape([]int{1, shape[len(shape)-1]}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
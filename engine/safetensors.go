@@ -0,0 +1,220 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"unsafe"
+)
+
+// safetensorsDType maps dtypes to safetensors dtype names.
+var safetensorsDType = map[DType]string{
+	Float64: "F64",
+	Float32: "F32",
+	Int64:   "I64",
+	Bool:    "BOOL",
+}
+
+// maxSafetensorsHeader bounds the JSON header size, as the reference
+// implementation does, so that corrupt files fail before a huge allocation.
+const maxSafetensorsHeader = 100 << 20
+
+// hostLittleEndian reports whether the machine stores numbers little-endian,
+// which is required to use a safetensors buffer without copying it.
+var hostLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// safetensorsEntry is the header entry of one tensor.
+type safetensorsEntry struct {
+	DType       string `json:"dtype"`
+	Shape       []int  `json:"shape"`
+	DataOffsets [2]int `json:"data_offsets"`
+}
+
+// WriteSafetensors writes the named tensors to w in the safetensors format: a
+// little-endian uint64 header size, a JSON header giving the dtype, shape and
+// byte range of every tensor, and the raw little-endian data. metadata may be
+// nil and is stored under "__metadata__".
+func WriteSafetensors(w io.Writer, tensors map[string]*Tensor, metadata map[string]string) error {
+	names := make([]string, 0, len(tensors))
+	for name, t := range tensors {
+		if t == nil {
+			return fmt.Errorf("cannot save nil tensor %s", name)
+		}
+		if name == "__metadata__" {
+			return errors.New("tensor name __metadata__ is reserved")
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	header := make(map[string]interface{}, len(tensors)+1)
+	if len(metadata) > 0 {
+		header["__metadata__"] = metadata
+	}
+	offset := 0
+	for _, name := range names {
+		t := tensors[name]
+		size := t.GetSize() * t.dtype.ItemSize()
+		header[name] = safetensorsEntry{
+			DType:       safetensorsDType[t.dtype],
+			Shape:       t.GetShape(),
+			DataOffsets: [2]int{offset, offset + size},
+		}
+		offset += size
+	}
+	js, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to encode safetensors header: %v", err)
+	}
+	// Pad the header with spaces so that the data starts 8-byte aligned
+	for len(js)%8 != 0 {
+		js = append(js, ' ')
+	}
+	prefix := binary.LittleEndian.AppendUint64(nil, uint64(len(js)))
+	if _, err := w.Write(append(prefix, js...)); err != nil {
+		return fmt.Errorf("failed to write safetensors header: %v", err)
+	}
+	for _, name := range names {
+		if err := writeElements(w, tensors[name]); err != nil {
+			return fmt.Errorf("failed to write tensor %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// ReadSafetensors reads the tensors and metadata of a safetensors file from r,
+// copying the data into new buffers.
+func ReadSafetensors(r io.Reader) (map[string]*Tensor, map[string]string, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read safetensors: %v", err)
+	}
+	return parseSafetensors(buf, false)
+}
+
+// SafetensorsFile is a safetensors file opened with OpenSafetensors.
+type SafetensorsFile struct {
+	Tensors  map[string]*Tensor
+	Metadata map[string]string
+	unmap    func() error
+}
+
+// OpenSafetensors loads the safetensors file at path. On Linux the file is
+// memory-mapped and the tensors use the mapping directly where their alignment
+// allows it; writes to them are private and never reach the file. The tensors
+// must not be used after Close.
+func OpenSafetensors(path string) (*SafetensorsFile, error) {
+	buf, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open safetensors %s: %v", path, err)
+	}
+	tensors, metadata, err := parseSafetensors(buf, true)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	return &SafetensorsFile{Tensors: tensors, Metadata: metadata, unmap: unmap}, nil
+}
+
+// Close releases the memory of the file.
+func (f *SafetensorsFile) Close() error {
+	if f.unmap == nil {
+		return nil
+	}
+	err := f.unmap()
+	f.unmap = nil
+	f.Tensors = nil
+	return err
+}
+
+// parseSafetensors decodes a whole safetensors file. With zeroCopy, float and
+// int64 tensors whose data is suitably aligned share memory with buf.
+func parseSafetensors(buf []byte, zeroCopy bool) (map[string]*Tensor, map[string]string, error) {
+	if len(buf) < 8 {
+		return nil, nil, errors.New("safetensors file is too short")
+	}
+	n := binary.LittleEndian.Uint64(buf)
+	if n > maxSafetensorsHeader || n > uint64(len(buf)-8) {
+		return nil, nil, fmt.Errorf("invalid safetensors header size %d", n)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(buf[8:8+n], &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid safetensors header: %v", err)
+	}
+	data := buf[8+n:]
+
+	var metadata map[string]string
+	tensors := make(map[string]*Tensor, len(raw))
+	for name, msg := range raw {
+		if name == "__metadata__" {
+			if err := json.Unmarshal(msg, &metadata); err != nil {
+				return nil, nil, fmt.Errorf("invalid safetensors metadata: %v", err)
+			}
+			continue
+		}
+		var entry safetensorsEntry
+		if err := json.Unmarshal(msg, &entry); err != nil {
+			return nil, nil, fmt.Errorf("invalid safetensors entry %s: %v", name, err)
+		}
+		t, err := safetensorsTensor(entry, data, zeroCopy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid safetensors entry %s: %v", name, err)
+		}
+		tensors[name] = t
+	}
+	return tensors, metadata, nil
+}
+
+// safetensorsTensor builds the tensor described by entry from the data section
+// of a safetensors file.
+func safetensorsTensor(entry safetensorsEntry, data []byte, zeroCopy bool) (*Tensor, error) {
+	dtype := DType(-1)
+	for d, s := range safetensorsDType {
+		if s == entry.DType {
+			dtype = d
+		}
+	}
+	if dtype < 0 {
+		return nil, fmt.Errorf("unsupported dtype %q", entry.DType)
+	}
+	shape := entry.Shape
+	if shape == nil {
+		shape = []int{}
+	}
+	size, err := checkFileShape(shape, dtype.ItemSize())
+	if err != nil {
+		return nil, err
+	}
+	begin, end := entry.DataOffsets[0], entry.DataOffsets[1]
+	if begin < 0 || end < begin || end > len(data) {
+		return nil, fmt.Errorf("data offsets %v out of range", entry.DataOffsets)
+	}
+	if end-begin != size*dtype.ItemSize() {
+		return nil, fmt.Errorf("data offsets %v do not match shape %v of dtype %s", entry.DataOffsets, shape, entry.DType)
+	}
+	chunk := data[begin:end]
+	if len(chunk) == 0 {
+		return nil, fmt.Errorf("empty data for shape %v", shape)
+	}
+
+	aligned := uintptr(unsafe.Pointer(&chunk[0]))%uintptr(dtype.ItemSize()) == 0
+	if zeroCopy && hostLittleEndian && aligned {
+		ptr := unsafe.Pointer(&chunk[0])
+		switch dtype {
+		case Float64:
+			return NewTensor(unsafe.Slice((*float64)(ptr), size), shape)
+		case Float32:
+			return NewFloat32Tensor(unsafe.Slice((*float32)(ptr), size), shape)
+		case Int64:
+			return NewInt64Tensor(unsafe.Slice((*int64)(ptr), size), shape)
+		}
+	}
+	return readElements(bytes.NewReader(chunk), dtype, shape)
+}
@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// saveMagic starts every tensor written by Save.
+const saveMagic = "GOTEN"
+
+// formatVersion is the version of the format written by Save.
+const formatVersion = 1
+
+// maxLoadRank bounds the rank read from a file before the shape is allocated.
+const maxLoadRank = 64
+
+// Save writes t to w in GoTen's binary format: the magic string "GOTEN", a
+// version byte, a dtype byte, the rank as a uint32 and every dimension as a
+// uint64, followed by the elements in row-major order. All numbers are
+// little-endian. Gradients and autograd state are not saved.
+func Save(w io.Writer, t *Tensor) error {
+	if t == nil {
+		return errors.New("cannot save nil tensor")
+	}
+	shape := t.GetShape()
+	header := make([]byte, 0, len(saveMagic)+6+8*len(shape))
+	header = append(header, saveMagic...)
+	header = append(header, formatVersion, byte(t.dtype))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(shape)))
+	for _, dim := range shape {
+		header = binary.LittleEndian.AppendUint64(header, uint64(dim))
+	}
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write tensor header: %v", err)
+	}
+	if err := writeElements(w, t); err != nil {
+		return fmt.Errorf("failed to write tensor data: %v", err)
+	}
+	return nil
+}
+
+// Load reads a tensor written by Save from r.
+func Load(r io.Reader) (*Tensor, error) {
+	header := make([]byte, len(saveMagic)+6)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("failed to read tensor header: %v", err)
+	}
+	if string(header[:len(saveMagic)]) != saveMagic {
+		return nil, errors.New("not a GoTen tensor file")
+	}
+	if v := header[len(saveMagic)]; v != formatVersion {
+		return nil, fmt.Errorf("unsupported tensor format version %d", v)
+	}
+	dtype := DType(header[len(saveMagic)+1])
+	switch dtype {
+	case Float64, Float32, Int64, Bool:
+	default:
+		return nil, fmt.Errorf("unknown dtype %d", int(dtype))
+	}
+	rank := binary.LittleEndian.Uint32(header[len(saveMagic)+2:])
+	if rank > maxLoadRank {
+		return nil, fmt.Errorf("invalid tensor rank %d", rank)
+	}
+	dims := make([]uint64, rank)
+	if err := binary.Read(r, binary.LittleEndian, dims); err != nil {
+		return nil, fmt.Errorf("failed to read tensor shape: %v", err)
+	}
+	shape := make([]int, rank)
+	for i, dim := range dims {
+		if dim > math.MaxInt {
+			return nil, fmt.Errorf("invalid tensor shape %v", dims)
+		}
+		shape[i] = int(dim)
+	}
+	t, err := readElements(r, dtype, shape)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tensor data: %v", err)
+	}
+	return t, nil
+}
+
+// writeElements writes the elements of t to w in row-major order as
+// little-endian values of its dtype, one byte per element for bool.
+func writeElements(w io.Writer, t *Tensor) error {
+	switch t.dtype {
+	case Float32:
+		return binary.Write(w, binary.LittleEndian, t.GetFloat32Data())
+	case Int64:
+		return binary.Write(w, binary.LittleEndian, t.GetInt64Data())
+	case Bool:
+		return binary.Write(w, binary.LittleEndian, t.GetBoolData())
+	}
+	return binary.Write(w, binary.LittleEndian, t.GetData())
+}
+
+// readElements reads a tensor of the given dtype and shape written by
+// writeElements from r. The shape comes from an untrusted header, so it is
+// validated and the data read in bounded chunks before anything is decoded.
+func readElements(r io.Reader, dtype DType, shape []int) (*Tensor, error) {
+	size, err := checkFileShape(shape, dtype.ItemSize())
+	if err != nil {
+		return nil, err
+	}
+	buf, err := readBytes(r, size*dtype.ItemSize())
+	if err != nil {
+		return nil, err
+	}
+	br := bytes.NewReader(buf)
+	switch dtype {
+	case Float32:
+		data := make([]float32, size)
+		if err := binary.Read(br, binary.LittleEndian, data); err != nil {
+			return nil, err
+		}
+		return NewFloat32Tensor(data, shape)
+	case Int64:
+		data := make([]int64, size)
+		if err := binary.Read(br, binary.LittleEndian, data); err != nil {
+			return nil, err
+		}
+		return NewInt64Tensor(data, shape)
+	case Bool:
+		data := make([]bool, size)
+		if err := binary.Read(br, binary.LittleEndian, data); err != nil {
+			return nil, err
+		}
+		return NewBoolTensor(data, shape)
+	}
+	data := make([]float64, size)
+	if err := binary.Read(br, binary.LittleEndian, data); err != nil {
+		return nil, err
+	}
+	return NewTensor(data, shape)
+}
+
+// checkFileShape returns the number of elements of a shape read from a file. It
+// fails if a dimension is not positive or the data would not fit in memory
+// addressable by an int with itemSize bytes per element.
+func checkFileShape(shape []int, itemSize int) (int, error) {
+	size := 1
+	for _, dim := range shape {
+		if dim <= 0 || size > math.MaxInt/itemSize/dim {
+			return 0, fmt.Errorf("invalid shape %v", shape)
+		}
+		size *= dim
+	}
+	return size, nil
+}
+
+// readBytes reads exactly n bytes from r. The buffer grows with the data
+// received, so a corrupt size fails on a short read instead of exhausting memory.
+func readBytes(r io.Reader, n int) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
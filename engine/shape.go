@@ -0,0 +1,341 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Concat joins tensors along axis. They must have the same rank and the same
+// size in every other dimension.
+func Concat(tensors []*Tensor, axis int) (*Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("cannot concatenate an empty list of tensors")
+	}
+	for _, t := range tensors {
+		if t == nil {
+			return nil, errors.New("cannot concatenate nil tensor")
+		}
+	}
+	first := tensors[0].GetShape()
+	a, err := normalizeAxis(axis, len(first))
+	if err != nil {
+		return nil, err
+	}
+	shape := copyShape(first)
+	shape[a] = 0
+	dtypes := make([]DType, len(tensors))
+	for i, t := range tensors {
+		s := t.GetShape()
+		if len(s) != len(first) {
+			return nil, fmt.Errorf("cannot concatenate tensors of shapes %v and %v", first, s)
+		}
+		for d := range s {
+			if d != a && s[d] != first[d] {
+				return nil, fmt.Errorf("cannot concatenate tensors of shapes %v and %v along axis %d", first, s, axis)
+			}
+		}
+		shape[a] += s[a]
+		dtypes[i] = t.dtype
+	}
+
+	// Each input contributes a contiguous block of every outer row of the result
+	outer := shapeSize(shape[:a])
+	inner := shapeSize(shape[a+1:])
+	blocks := make([]int, len(tensors))
+	inputs := make([][]float64, len(tensors))
+	for i, t := range tensors {
+		blocks[i] = t.shape[a] * inner
+		inputs[i] = t.GetData()
+	}
+	data := make([]float64, 0, shapeSize(shape))
+	for o := 0; o < outer; o++ {
+		for i, in := range inputs {
+			data = append(data, in[o*blocks[i]:(o+1)*blocks[i]]...)
+		}
+	}
+
+	out, err := NewTensorOf(commonType(dtypes...), data, shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = tensors[0].backend
+	return recordOp(out, "Concat", func(grad []float64) {
+		grads := make([][]float64, len(tensors))
+		for i := range tensors {
+			grads[i] = make([]float64, 0, len(inputs[i]))
+		}
+		pos := 0
+		for o := 0; o < outer; o++ {
+			for i, block := range blocks {
+				grads[i] = append(grads[i], grad[pos:pos+block]...)
+				pos += block
+			}
+		}
+		for i, t := range tensors {
+			accumulateGrad(t, grads[i])
+		}
+	}, tensors...)
+}
+
+// Stack joins tensors of the same shape along a new axis.
+func Stack(tensors []*Tensor, axis int) (*Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("cannot stack an empty list of tensors")
+	}
+	expanded := make([]*Tensor, len(tensors))
+	for i, t := range tensors {
+		if t == nil {
+			return nil, errors.New("cannot stack nil tensor")
+		}
+		if !SameShape(t, tensors[0]) {
+			return nil, fmt.Errorf("cannot stack tensors of shapes %v and %v", tensors[0].GetShape(), t.GetShape())
+		}
+		u, err := Unsqueeze(t, axis)
+		if err != nil {
+			return nil, err
+		}
+		expanded[i] = u
+	}
+	return Concat(expanded, axis)
+}
+
+// Split returns views of t cut along axis into pieces of the given sizes, which
+// must add up to the size of the axis.
+func Split(t *Tensor, sizes []int, axis int) ([]*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot split nil tensor")
+	}
+	shape := t.GetShape()
+	a, err := normalizeAxis(axis, len(shape))
+	if err != nil {
+		return nil, err
+	}
+	total := 0
+	for _, s := range sizes {
+		if s <= 0 {
+			return nil, fmt.Errorf("invalid split sizes %v", sizes)
+		}
+		total += s
+	}
+	if total != shape[a] {
+		return nil, fmt.Errorf("split sizes %v do not add up to size %d of axis %d", sizes, shape[a], axis)
+	}
+	parts := make([]*Tensor, len(sizes))
+	start := 0
+	for i, s := range sizes {
+		if parts[i], err = Narrow(t, a, start, s); err != nil {
+			return nil, err
+		}
+		start += s
+	}
+	return parts, nil
+}
+
+// Chunk splits t along axis into n views of equal size, except for the last one
+// which is smaller if the axis does not divide evenly. Fewer than n chunks are
+// returned if the axis is too small.
+func Chunk(t *Tensor, n, axis int) ([]*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot chunk nil tensor")
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("number of chunks must be positive, got %d", n)
+	}
+	shape := t.GetShape()
+	a, err := normalizeAxis(axis, len(shape))
+	if err != nil {
+		return nil, err
+	}
+	size := (shape[a] + n - 1) / n
+	sizes := make([]int, 0, n)
+	for left := shape[a]; left > 0; left -= size {
+		if left < size {
+			sizes = append(sizes, left)
+			break
+		}
+		sizes = append(sizes, size)
+	}
+	return Split(t, sizes, a)
+}
+
+// Repeat repeats every element of t the given number of times along axis, e.g.
+// [1, 2] becomes [1, 1, 2, 2] for 2 repeats.
+func Repeat(t *Tensor, repeats, axis int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot repeat nil tensor")
+	}
+	if repeats <= 0 {
+		return nil, fmt.Errorf("number of repeats must be positive, got %d", repeats)
+	}
+	shape := t.GetShape()
+	a, err := normalizeAxis(axis, len(shape))
+	if err != nil {
+		return nil, err
+	}
+	tables := identityTables(shape)
+	tables[a] = make([]int, shape[a]*repeats)
+	for c := range tables[a] {
+		tables[a][c] = c / repeats
+	}
+	return gatherAxes(t, "Repeat", tables, 0)
+}
+
+// Tile repeats the whole of t reps[i] times along dimension i. If reps has more
+// entries than t has dimensions, t is treated as having leading dimensions of
+// size 1; if it has fewer, the leading dimensions are not repeated.
+func Tile(t *Tensor, reps []int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot tile nil tensor")
+	}
+	shape := t.GetShape()
+	for len(reps) < len(shape) {
+		reps = append([]int{1}, reps...)
+	}
+	for _, r := range reps {
+		if r <= 0 {
+			return nil, fmt.Errorf("invalid tile repetitions %v", reps)
+		}
+	}
+	for len(shape) < len(reps) {
+		var err error
+		if t, err = Unsqueeze(t, 0); err != nil {
+			return nil, err
+		}
+		shape = t.GetShape()
+	}
+	tables := make([][]int, len(shape))
+	for d, dim := range shape {
+		tables[d] = make([]int, dim*reps[d])
+		for c := range tables[d] {
+			tables[d][c] = c % dim
+		}
+	}
+	return gatherAxes(t, "Tile", tables, 0)
+}
+
+// PadMode selects the values Pad fills the border with.
+type PadMode int
+
+const (
+	// PadConstant fills the border with a constant value.
+	PadConstant PadMode = iota
+	// PadReflect mirrors the tensor around its edges, without repeating the edge.
+	PadReflect
+	// PadReplicate repeats the edge values.
+	PadReplicate
+)
+
+// Pad adds widths[i][0] elements before and widths[i][1] elements after
+// dimension i of t. widths must have one entry per dimension. value is the
+// fill value for PadConstant and is ignored otherwise.
+func Pad(t *Tensor, widths [][2]int, mode PadMode, value float64) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot pad nil tensor")
+	}
+	shape := t.GetShape()
+	if len(widths) != len(shape) {
+		return nil, fmt.Errorf("pad widths %v do not match tensor of shape %v", widths, shape)
+	}
+	switch mode {
+	case PadConstant, PadReflect, PadReplicate:
+	default:
+		return nil, fmt.Errorf("unknown pad mode %d", mode)
+	}
+	tables := make([][]int, len(shape))
+	for d, dim := range shape {
+		before, after := widths[d][0], widths[d][1]
+		if before < 0 || after < 0 {
+			return nil, fmt.Errorf("invalid pad widths %v", widths)
+		}
+		if mode == PadReflect && (before >= dim || after >= dim) {
+			return nil, fmt.Errorf("reflect padding %v must be smaller than dimension %d of shape %v", widths[d], d, shape)
+		}
+		tables[d] = make([]int, before+dim+after)
+		for c := range tables[d] {
+			i := c - before
+			switch {
+			case i >= 0 && i < dim:
+			case mode == PadConstant:
+				i = -1
+			case mode == PadReflect && i < 0:
+				i = -i
+			case mode == PadReflect:
+				i = 2*(dim-1) - i
+			case i < 0:
+				i = 0
+			default:
+				i = dim - 1
+			}
+			tables[d][c] = i
+		}
+	}
+	return gatherAxes(t, "Pad", tables, value)
+}
+
+// identityTables returns gather tables that read every dimension of shape as is.
+func identityTables(shape []int) [][]int {
+	tables := make([][]int, len(shape))
+	for d, dim := range shape {
+		tables[d] = make([]int, dim)
+		for c := range tables[d] {
+			tables[d][c] = c
+		}
+	}
+	return tables
+}
+
+// gatherAxes builds a tensor whose element at coordinates (c0, c1, ...) is the
+// element of t at (tables[0][c0], tables[1][c1], ...), or fill if any of them
+// is -1. The gradient is summed back onto the elements that were read.
+func gatherAxes(t *Tensor, op string, tables [][]int, fill float64) (*Tensor, error) {
+	shape := make([]int, len(tables))
+	for d, table := range tables {
+		shape[d] = len(table)
+	}
+	strides := contiguousStrides(t.shape)
+	src := make([]int, shapeSize(shape))
+	coords := make([]int, len(shape))
+	for i := range src {
+		pos := 0
+		for d, c := range coords {
+			in := tables[d][c]
+			if in < 0 {
+				pos = -1
+				break
+			}
+			pos += in * strides[d]
+		}
+		src[i] = pos
+		for d := len(shape) - 1; d >= 0; d-- {
+			coords[d]++
+			if coords[d] < shape[d] {
+				break
+			}
+			coords[d] = 0
+		}
+	}
+
+	in := t.GetData()
+	data := make([]float64, len(src))
+	for i, pos := range src {
+		if pos < 0 {
+			data[i] = fill
+		} else {
+			data[i] = in[pos]
+		}
+	}
+	out, err := NewTensorOf(t.dtype, data, shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, op, func(grad []float64) {
+		dt := make([]float64, len(in))
+		for i, pos := range src {
+			if pos >= 0 {
+				dt[pos] += grad[i]
+			}
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
@@ -0,0 +1,108 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+)
+
+// shiftedExp returns exp(x - m) for every element of data, where m is the
+// largest element reduced into the same result element of r, along with the
+// sums of those exponentials and the shifts m. Subtracting the maximum keeps
+// exp from overflowing; infinite maxima are not subtracted to avoid inf - inf.
+func shiftedExp(be Backend, data []float64, r *reduction) ([]float64, []float64, []float64) {
+	shifts := make([]float64, r.size())
+	for i, p := range argExtremum(data, r, func(a, b float64) bool { return a > b }) {
+		if !math.IsInf(data[p], 0) {
+			shifts[i] = data[p]
+		}
+	}
+	exps := make([]float64, len(data))
+	be.Map2("ShiftedExp", exps, data, shifts, nil, r.index, func(x, m float64) float64 { return math.Exp(x - m) })
+	sums := make([]float64, len(shifts))
+	be.Sum(sums, exps, r.index)
+	return exps, sums, shifts
+}
+
+// Softmax returns exp(t) normalized to sum to 1 along axis.
+func Softmax(t *Tensor, axis int) (*Tensor, error) {
+	r, err := newReduction(t, []int{axis}, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute softmax: %v", err)
+	}
+	be := t.GetBackend()
+	exps, sums, _ := shiftedExp(be, t.GetData(), r)
+	data := make([]float64, len(exps))
+	be.Map2("Softmax", data, exps, sums, nil, r.index, func(e, s float64) float64 { return e / s })
+	out, err := NewTensorOf(floatType(t.dtype), data, copyShape(t.shape))
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "Softmax", func(grad []float64) {
+		// dx = y * (g - sum(g * y)) along axis
+		dt := make([]float64, len(grad))
+		be.Mul(dt, grad, data, nil, nil)
+		dots := make([]float64, r.size())
+		be.Sum(dots, dt, r.index)
+		be.Map2("SoftmaxBackward", dt, grad, dots, nil, r.index, func(g, dot float64) float64 { return g - dot })
+		be.Mul(dt, dt, data, nil, nil)
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// LogSoftmax returns the logarithm of Softmax along axis, computed directly as
+// t - LogSumExp(t) so that it stays finite where Softmax underflows to 0.
+func LogSoftmax(t *Tensor, axis int) (*Tensor, error) {
+	r, err := newReduction(t, []int{axis}, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute log softmax: %v", err)
+	}
+	in := t.GetData()
+	be := t.GetBackend()
+	_, sums, shifts := shiftedExp(be, in, r)
+	logSums := make([]float64, len(sums))
+	be.Map2("LogSumExp", logSums, shifts, sums, nil, nil, func(m, s float64) float64 { return m + math.Log(s) })
+	data := make([]float64, len(in))
+	be.Map2("LogSoftmax", data, in, logSums, nil, r.index, func(x, l float64) float64 { return x - l })
+	out, err := NewTensorOf(floatType(t.dtype), data, copyShape(t.shape))
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "LogSoftmax", func(grad []float64) {
+		// dx = g - softmax * sum(g) along axis
+		sumGrad := make([]float64, r.size())
+		be.Sum(sumGrad, grad, r.index)
+		dt := make([]float64, len(grad))
+		be.Map2("LogSoftmaxBackward", dt, data, sumGrad, nil, r.index, func(y, s float64) float64 { return math.Exp(y) * s })
+		be.Map2("Sub", dt, grad, dt, nil, nil, func(g, d float64) float64 { return g - d })
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// LogSumExp returns log(sum(exp(t))) over the given axes without overflowing.
+// If axes is empty every axis is reduced. With keepdims the reduced axes are
+// kept with size 1.
+func LogSumExp(t *Tensor, axes []int, keepdims bool) (*Tensor, error) {
+	r, err := newReduction(t, axes, keepdims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute log-sum-exp: %v", err)
+	}
+	in := t.GetData()
+	be := t.GetBackend()
+	_, sums, shifts := shiftedExp(be, in, r)
+	values := make([]float64, len(sums))
+	be.Map2("LogSumExp", values, shifts, sums, nil, nil, func(m, s float64) float64 { return m + math.Log(s) })
+	out, err := NewTensorOf(floatType(t.dtype), values, r.shape)
+	if err != nil {
+		return nil, err
+	}
+	out.backend = t.backend
+	return recordOp(out, "LogSumExp", func(grad []float64) {
+		// dx = g * exp(x - logsumexp), i.e. the softmax of x
+		dt := make([]float64, len(in))
+		be.Map2("LogSumExpBackward", dt, in, values, nil, r.index, func(x, l float64) float64 { return math.Exp(x - l) })
+		be.Mul(dt, dt, grad, nil, r.index)
+		accumulateGrad(t, dt)
+	}, t)
+}
@@ -2,54 +2,72 @@ package engine
 
 import (
 	"fmt"
-	"math/rand"
 	"reflect"
-	"strings"
 )
 
 // COULD CHANGE `data` TO HOLD ACTUAL ARRAYS
 type Tensor struct {
-	data  []float64 // The data stored in the tensor
-	shape []int     // The shape of the tensor, [row, col, ...]
-	dw    *Tensor   // Gradients of the weights
-	db    *Tensor   // Gradients of the biases
-	dx    *Tensor   // Gradient of the tensor
+	data    []float64 // The buffer holding the tensor's elements, possibly shared with views
+	f32     []float32 // Buffer used instead of data for float32 tensors
+	i64     []int64   // Buffer used instead of data for int64 tensors
+	bools   []bool    // Buffer used instead of data for bool tensors
+	dtype   DType     // The element type of the tensor
+	shape   []int     // The shape of the tensor, [row, col, ...]
+	strides []int     // Step in data between consecutive indices of each dimension
+	offset  int       // Position in data of the first element
+	base    *Tensor   // Tensor owning data if this tensor is a view, nil otherwise
+	backend Backend   // Backend running the ops on the tensor
+	dw      *Tensor   // Gradients of the weights
+	db      *Tensor   // Gradients of the biases
+	dx      *Tensor   // Gradient of the tensor
+
+	grad         *Tensor   // Gradient accumulated by Backward
+	requiresGrad bool      // Whether ops on the tensor are recorded for autograd
+	retainGrad   bool      // Whether Backward stores the gradient of a non-leaf tensor
+	parents      []*Tensor // Inputs of the op that produced the tensor
+	backward     gradFn    // Propagates grad to parents
+	op           string    // Name of the op that produced the tensor
+	site         string    // Call site of the op, recorded in anomaly mode
 }
 
+// NewTensor creates a float64 tensor backed by data.
 func NewTensor(data []float64, shape []int) (*Tensor, error) {
-	size := 1
-	for _, dim := range shape {
-		size *= dim
-		if dim == 0 {
-			return nil, fmt.Errorf("invalid shape: shape contains a zero %v", shape)
-		}
-	}
-	if size != len(data) {
-		return nil, fmt.Errorf("data size %d does not match shape %v", len(data), shape)
+	if err := checkShape(len(data), shape); err != nil {
+		return nil, err
 	}
 	t := &Tensor{
-		data:  data,
-		shape: shape,
-		dw:    nil,
-		db:    nil,
-		dx:    nil,
+		data:    data,
+		shape:   shape,
+		strides: contiguousStrides(shape),
+		offset:  0,
+		dtype:   Float64,
+		backend: defaultBackend,
+		dw:      nil,
+		db:      nil,
+		dx:      nil,
 	}
 	return t, nil
 }
 
-func NewRandomTensor(shape []int) (*Tensor, error) {
-	size := 1
+// checkShape returns an error if shape is invalid or does not hold size elements.
+func checkShape(size int, shape []int) error {
+	n := 1
 	for _, dim := range shape {
-		size *= dim
+		n *= dim
 		if dim == 0 {
-			return nil, fmt.Errorf("invalid shape: shape contains a zero %v", shape)
+			return fmt.Errorf("invalid shape: shape contains a zero %v", shape)
 		}
 	}
-	data := make([]float64, size)
-	for i := range data {
-		data[i] = rand.Float64()*2 - 1
+	if n != size {
+		return fmt.Errorf("data size %d does not match shape %v", size, shape)
 	}
-	return NewTensor(data, shape)
+	return nil
+}
+
+// NewRandomTensor creates a float64 tensor with elements drawn uniformly from
+// [-1, 1) by the default generator. Use Uniform to draw from another generator.
+func NewRandomTensor(shape []int) (*Tensor, error) {
+	return Uniform(nil, shape, -1, 1)
 }
 
 func NewZeroTensor(shape []int) (*Tensor, error) {
@@ -90,6 +108,8 @@ func SameShape(t1, t2 *Tensor) bool {
 	return true
 }
 
+// Reshape changes the shape of the tensor in place. A non-contiguous view is
+// first packed into its own buffer and stops sharing data with its base.
 func (t *Tensor) Reshape(shape []int) error {
 	size := 1
 	for _, dim := range shape {
@@ -101,7 +121,13 @@ func (t *Tensor) Reshape(shape []int) error {
 	if size != t.GetSize() {
 		return fmt.Errorf("invalid shape: new shape %v is not of size %d", shape, t.GetSize())
 	}
+	if !t.IsContiguous() {
+		t.shareBuffer(t.packed())
+		t.offset = 0
+		t.base = nil
+	}
 	t.shape = shape
+	t.strides = contiguousStrides(shape)
 	return nil
 }
 
@@ -121,14 +147,25 @@ func (t *Tensor) GetSize() int {
 	return size
 }
 
-// Returns a copy of the data stored in the tensor
+// Returns the data stored in the tensor as float64 in row-major order. Contiguous
+// float64 tensors return their underlying buffer, other tensors return a copy.
 func (t *Tensor) GetData() []float64 {
-	if t.data == nil {
+	if !t.hasBuffer() {
 		return nil
 	}
-	return t.data
+	if t.dtype == Float64 && t.IsContiguous() {
+		return t.data[t.offset : t.offset+t.GetSize()]
+	}
+	out := make([]float64, t.GetSize())
+	for i, pos := range stridedIndex(t.shape, t.strides, t.offset) {
+		out[i] = t.load(pos)
+	}
+	return out
 }
 
+// SetData replaces the data of the tensor, converting it to the tensor's dtype.
+// The values are copied into the existing buffer, so views keep sharing memory
+// with the tensor; a new buffer is only allocated if the old one has another size.
 func (t *Tensor) SetData(data []float64) error {
 	if t.shape == nil {
 		return fmt.Errorf("tensor shape is nil")
@@ -137,10 +174,86 @@ func (t *Tensor) SetData(data []float64) error {
 	if len(data) != size {
 		return fmt.Errorf("data size does not match tensor size: tensor: %d, other: %d", size, len(data))
 	}
-	t.data = data
+	if t.base != nil {
+		for i, pos := range stridedIndex(t.shape, t.strides, t.offset) {
+			t.store(pos, data[i])
+		}
+		return nil
+	}
+	if t.bufferLen() != size {
+		t.allocate(size)
+	}
+	for i, v := range data {
+		t.store(i, v)
+	}
+	t.strides = contiguousStrides(t.shape)
+	t.offset = 0
 	return nil
 }
 
+// GetStrides returns the step in the underlying buffer for each dimension.
+func (t *Tensor) GetStrides() []int {
+	return t.strides
+}
+
+// IsView returns true if the tensor shares its buffer with another tensor.
+func (t *Tensor) IsView() bool {
+	return t.base != nil
+}
+
+// IsContiguous returns true if the elements of the tensor are laid out in
+// row-major order without gaps in the underlying buffer.
+func (t *Tensor) IsContiguous() bool {
+	expected := 1
+	for i := len(t.shape) - 1; i >= 0; i-- {
+		if t.shape[i] == 1 {
+			continue
+		}
+		if t.strides[i] != expected {
+			return false
+		}
+		expected *= t.shape[i]
+	}
+	return true
+}
+
+// Contiguous returns t if it is already contiguous, otherwise a packed copy.
+func (t *Tensor) Contiguous() *Tensor {
+	if t.IsContiguous() {
+		return t
+	}
+	return record(t.packed(), "Contiguous", func(grad []float64) {
+		accumulateGrad(t, grad)
+	}, t)
+}
+
+// packed returns a contiguous copy of t with the same dtype.
+func (t *Tensor) packed() *Tensor {
+	out := newTyped(t.dtype, copyShape(t.shape))
+	out.backend = t.backend
+	out.allocate(t.GetSize())
+	index := stridedIndex(t.shape, t.strides, t.offset)
+	switch t.dtype {
+	case Float32:
+		for i, pos := range index {
+			out.f32[i] = t.f32[pos]
+		}
+	case Int64:
+		for i, pos := range index {
+			out.i64[i] = t.i64[pos]
+		}
+	case Bool:
+		for i, pos := range index {
+			out.bools[i] = t.bools[pos]
+		}
+	default:
+		for i, pos := range index {
+			out.data[i] = t.data[pos]
+		}
+	}
+	return out
+}
+
 func (t *Tensor) GetValue(indices []int) (float64, error) {
 	if t.shape == nil {
 		return 0, fmt.Errorf("tensor shape is nil")
@@ -149,14 +262,14 @@ func (t *Tensor) GetValue(indices []int) (float64, error) {
 		return 0, fmt.Errorf("invalid index length for tensor shape")
 	}
 
-	index := 0
+	index := t.offset
 	for i, dim := range t.shape {
 		if indices[i] >= dim || indices[i] < 0 {
 			return 0, fmt.Errorf("index out of range")
 		}
-		index = index*dim + indices[i]
+		index += indices[i] * t.strides[i]
 	}
-	return t.data[index], nil
+	return t.load(index), nil
 }
 
 func (t *Tensor) SetValue(value float64, index []int) error {
@@ -164,11 +277,14 @@ func (t *Tensor) SetValue(value float64, index []int) error {
 		return fmt.Errorf("invalid number of indices: expected %d, got %d", len(t.shape), len(index))
 	}
 	shape := t.GetShape()
-	encodedIndex, err2 := EncodePos(index, shape)
-	if err2 != nil {
+	if _, err2 := EncodePos(index, shape); err2 != nil {
 		return fmt.Errorf("error encoding index: %v", err2)
 	}
-	t.data[encodedIndex] = value
+	pos := t.offset
+	for i, idx := range index {
+		pos += idx * t.strides[i]
+	}
+	t.store(pos, value)
 	return nil
 }
 
@@ -209,11 +325,16 @@ func DecodePos(index int, shape []int) ([]int, error) {
 	return coords, nil
 }
 
+// Equals reports whether t1 and t2 have the same dtype, shape and exactly the
+// same elements. Use AllClose to compare floating point results.
 func (t1 *Tensor) Equals(t2 *Tensor) bool {
+	if t1.dtype != t2.dtype {
+		return false
+	}
 	if !reflect.DeepEqual(t1.shape, t2.shape) {
 		return false
 	}
-	if !reflect.DeepEqual(t1.data, t2.data) {
+	if !reflect.DeepEqual(t1.GetData(), t2.GetData()) {
 		return false
 	}
 	return true
@@ -243,35 +364,36 @@ func (t *Tensor) SetInputGrads(dx *Tensor) {
 	t.dx = dx
 }
 
-// String returns a string representation of the tensor.
-// Currently changes the shape due to logic error in `StringHelper`
-func (t *Tensor) String() string {
-	shape := append([]int{t.shape[1], t.shape[0]}, t.shape[2:]...)
-	return "[" + t.StringHelper(t.data, shape, len(shape)-1, 0) + "]"
+// contiguousStrides returns the row-major strides of a packed tensor of the given shape.
+func contiguousStrides(shape []int) []int {
+	strides := make([]int, len(shape))
+	stride := 1
+	for i := len(shape) - 1; i >= 0; i-- {
+		strides[i] = stride
+		stride *= shape[i]
+	}
+	return strides
 }
 
-// Recursively builds string of tensor data
-func (t *Tensor) StringHelper(data []float64, shape []int, dim, index int) string {
-	var sb strings.Builder
-	if dim == 0 {
-		for i, v := range data {
-			if i > 0 {
-				sb.WriteString(", ")
-			}
-			sb.WriteString(fmt.Sprintf("%.4f", v))
-		}
-	} else {
-		for i := 0; i < shape[dim]; i++ {
-			if i > 0 {
-				sb.WriteString(" ")
+// stridedIndex returns the buffer position of every element of a strided
+// layout, visiting the elements in row-major order.
+func stridedIndex(shape, strides []int, offset int) []int {
+	index := make([]int, shapeSize(shape))
+	coords := make([]int, len(shape))
+	pos := offset
+	for i := range index {
+		index[i] = pos
+		for d := len(shape) - 1; d >= 0; d-- {
+			coords[d]++
+			pos += strides[d]
+			if coords[d] < shape[d] {
+				break
 			}
-			sb.WriteString("[")
-			sb.WriteString(t.StringHelper(data[index:index+shape[dim-1]], shape, dim-1, index))
-			sb.WriteString("]")
-			index += shape[dim-1]
+			pos -= coords[d] * strides[d]
+			coords[d] = 0
 		}
 	}
-	return sb.String()
+	return index
 }
 
 func IsNumIn(n int, list []int) bool {
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// layoutFn maps the shape, strides and offset of a tensor to those of a view of it.
+type layoutFn func(shape, strides []int, offset int) ([]int, []int, int)
+
+// newView returns a tensor sharing the buffer of t with the layout produced by
+// layout. The gradient of the view is scattered back onto the elements of t it
+// was read from, summing elements that were read more than once.
+func newView(t *Tensor, op string, layout layoutFn) *Tensor {
+	shape, strides, offset := layout(t.shape, t.strides, t.offset)
+	base := t
+	if t.base != nil {
+		base = t.base
+	}
+	out := &Tensor{
+		shape:   shape,
+		strides: strides,
+		offset:  offset,
+		base:    base,
+	}
+	out.shareBuffer(t)
+
+	inShape := copyShape(t.shape)
+	return record(out, op, func(grad []float64) {
+		// Apply the same layout to a packed tensor to find where each element came from
+		vshape, vstrides, voffset := layout(inShape, contiguousStrides(inShape), 0)
+		dt := make([]float64, shapeSize(inShape))
+		for i, pos := range stridedIndex(vshape, vstrides, voffset) {
+			dt[pos] += grad[i]
+		}
+		accumulateGrad(t, dt)
+	}, t)
+}
+
+// Permute returns a view of t with its dimensions reordered so that dimension
+// i of the result is dimension dims[i] of t.
+func Permute(t *Tensor, dims ...int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot permute nil tensor")
+	}
+	rank := len(t.GetShape())
+	if len(dims) != rank {
+		return nil, fmt.Errorf("permutation %v does not match tensor of rank %d", dims, rank)
+	}
+	perm := make([]int, rank)
+	for i, d := range dims {
+		axis, err := normalizeAxis(d, rank)
+		if err != nil {
+			return nil, err
+		}
+		if IsNumIn(axis, perm[:i]) {
+			return nil, fmt.Errorf("repeated dimension %d in permutation %v", d, dims)
+		}
+		perm[i] = axis
+	}
+	return newView(t, "Permute", func(shape, strides []int, offset int) ([]int, []int, int) {
+		newShape := make([]int, len(perm))
+		newStrides := make([]int, len(perm))
+		for i, p := range perm {
+			newShape[i] = shape[p]
+			newStrides[i] = strides[p]
+		}
+		return newShape, newStrides, offset
+	}), nil
+}
+
+// Transpose returns a view of t with two dimensions swapped. Without dims the
+// last two dimensions are swapped, which is the matrix transpose for 2-D tensors.
+func Transpose(t *Tensor, dims ...int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot transpose nil tensor")
+	}
+	shape := t.GetShape()
+	rank := len(shape)
+	var dim0, dim1 int
+	switch len(dims) {
+	case 0:
+		if rank < 2 {
+			return nil, fmt.Errorf("transpose is not defined for tensors with shape %v", shape)
+		}
+		dim0, dim1 = rank-2, rank-1
+	case 2:
+		var err error
+		if dim0, err = normalizeAxis(dims[0], rank); err != nil {
+			return nil, err
+		}
+		if dim1, err = normalizeAxis(dims[1], rank); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("transpose takes two dimensions, got %v", dims)
+	}
+	perm := make([]int, rank)
+	for i := range perm {
+		perm[i] = i
+	}
+	perm[dim0], perm[dim1] = perm[dim1], perm[dim0]
+	return Permute(t, perm...)
+}
+
+// Slice returns a view of t keeping the indices start, start+step, ... below end
+// along axis. Negative start and end count from the end of the axis and out of
+// range bounds are clamped like Go and Python slices.
+func Slice(t *Tensor, axis, start, end, step int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot slice nil tensor")
+	}
+	shape := t.GetShape()
+	a, err := normalizeAxis(axis, len(shape))
+	if err != nil {
+		return nil, err
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("slice step must be positive, got %d", step)
+	}
+	dim := shape[a]
+	start, end = clampIndex(start, dim), clampIndex(end, dim)
+	if start >= end {
+		return nil, fmt.Errorf("slice [%d:%d] along axis %d of shape %v is empty", start, end, axis, shape)
+	}
+	length := (end - start + step - 1) / step
+	return newView(t, "Slice", func(shape, strides []int, offset int) ([]int, []int, int) {
+		newShape, newStrides := copyShape(shape), copyShape(strides)
+		newShape[a] = length
+		newStrides[a] = strides[a] * step
+		return newShape, newStrides, offset + start*strides[a]
+	}), nil
+}
+
+// clampIndex resolves a negative index against dim and clamps it to [0, dim].
+func clampIndex(i, dim int) int {
+	if i < 0 {
+		i += dim
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > dim {
+		return dim
+	}
+	return i
+}
+
+// Narrow returns a view of t holding length elements along axis starting at start.
+func Narrow(t *Tensor, axis, start, length int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot narrow nil tensor")
+	}
+	shape := t.GetShape()
+	a, err := normalizeAxis(axis, len(shape))
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || length <= 0 || start+length > shape[a] {
+		return nil, fmt.Errorf("cannot narrow axis %d of shape %v to [%d:%d]", axis, shape, start, start+length)
+	}
+	return Slice(t, a, start, start+length, 1)
+}
+
+// Unsqueeze returns a view of t with a dimension of size 1 inserted at axis.
+func Unsqueeze(t *Tensor, axis int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot unsqueeze nil tensor")
+	}
+	a, err := normalizeAxis(axis, len(t.GetShape())+1)
+	if err != nil {
+		return nil, err
+	}
+	return newView(t, "Unsqueeze", func(shape, strides []int, offset int) ([]int, []int, int) {
+		stride := 1
+		if a < len(shape) {
+			stride = strides[a] * shape[a]
+		}
+		newShape := append(append(copyShape(shape[:a]), 1), shape[a:]...)
+		newStrides := append(append(copyShape(strides[:a]), stride), strides[a:]...)
+		return newShape, newStrides, offset
+	}), nil
+}
+
+// Squeeze returns a view of t with the given dimensions of size 1 removed. If no
+// axes are given every dimension of size 1 is removed.
+func Squeeze(t *Tensor, axes ...int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot squeeze nil tensor")
+	}
+	shape := t.GetShape()
+	var drop []int
+	if len(axes) == 0 {
+		for i, dim := range shape {
+			if dim == 1 {
+				drop = append(drop, i)
+			}
+		}
+	} else {
+		var err error
+		if drop, err = normalizeAxes(axes, len(shape)); err != nil {
+			return nil, err
+		}
+		for _, a := range drop {
+			if shape[a] != 1 {
+				return nil, fmt.Errorf("cannot squeeze axis %d of size %d", a, shape[a])
+			}
+		}
+	}
+	return newView(t, "Squeeze", func(shape, strides []int, offset int) ([]int, []int, int) {
+		newShape := make([]int, 0, len(shape))
+		newStrides := make([]int, 0, len(shape))
+		for i := range shape {
+			if !IsNumIn(i, drop) {
+				newShape = append(newShape, shape[i])
+				newStrides = append(newStrides, strides[i])
+			}
+		}
+		return newShape, newStrides, offset
+	}), nil
+}
+
+// Expand returns a view of t broadcast to shape without copying. Dimensions of
+// size 1 may be expanded to any size and new leading dimensions may be added.
+// A size of -1 keeps the existing dimension.
+func Expand(t *Tensor, shape []int) (*Tensor, error) {
+	if t == nil {
+		return nil, errors.New("cannot expand nil tensor")
+	}
+	inShape := t.GetShape()
+	if len(shape) < len(inShape) {
+		return nil, fmt.Errorf("cannot expand shape %v to fewer dimensions %v", inShape, shape)
+	}
+	lead := len(shape) - len(inShape)
+	target := copyShape(shape)
+	for i, dim := range target {
+		if i >= lead && dim == -1 {
+			target[i] = inShape[i-lead]
+			continue
+		}
+		if dim <= 0 {
+			return nil, fmt.Errorf("invalid expanded shape %v", shape)
+		}
+		if i >= lead && inShape[i-lead] != 1 && inShape[i-lead] != dim {
+			return nil, fmt.Errorf("cannot expand shape %v to %v", inShape, shape)
+		}
+	}
+	return newView(t, "Expand", func(shape, strides []int, offset int) ([]int, []int, int) {
+		newStrides := make([]int, len(target))
+		for i := lead; i < len(target); i++ {
+			if shape[i-lead] == target[i] {
+				newStrides[i] = strides[i-lead]
+			}
+		}
+		return copyShape(target), newStrides, offset
+	}), nil
+}
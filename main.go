@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 
@@ -10,9 +11,16 @@ import (
 )
 
 func main() {
+	detectAnomaly := flag.Bool("detect-anomaly", false, "fail at the first op producing NaN or Inf")
+	flag.Parse()
+	engine.SetDetectAnomaly(*detectAnomaly)
+
 	// hyper parameters
 	lr := 0.0001
-	net, err := nn.NewMLP([]int{2, 1})
+	seed := int64(42)
+
+	engine.ManualSeed(seed)
+	net, err := nn.NewMLP([]int{2, 1}, nn.MLPOptions{Hidden: nn.ReLU, Output: nn.Sigmoid})
 	if err != nil {
 		log.Fatalf("Failed to create new MLP: %v", err)
 	}
@@ -38,26 +46,29 @@ func main() {
 		log.Fatalf("Failed to encode CSV to tensor: %v", err)
 	}
 
+	threshold, _ := engine.NewTensor([]float64{.5}, []int{1})
+
 	for i := 0; i < 100000; i++ {
 		totaloutloss, _ := engine.NewZeroTensor([]int{1, 1})
 		accuracy := 0.0
-		net.ZeroGrad()
 		for j := 0; j < len(Xs); j++ {
+			optimizer.ZeroGrad()
 			out, err := net.Forward(Xs[j])
 			if err != nil {
 				log.Fatalf("Forward pass failed: %v", err)
 			}
-			out, err = engine.Sigmoid(out)
-			if err != nil {
-				log.Fatalf("Forward pass failed: %v", err)
-			}
 
 			// accuracy
-			if out.GetData()[0] > .5 && Ys[j].GetData()[0] == 1. {
-				accuracy += 1
-			} else if out.GetData()[0] <= .5 && Ys[j].GetData()[0] == 0. {
-				accuracy += 1
+			pred, err := engine.Gt(out, threshold)
+			if err != nil {
+				log.Fatalf("Accuracy computation failed: %v", err)
+			}
+			correct, err := engine.Eq(pred, Ys[j])
+			if err != nil {
+				log.Fatalf("Accuracy computation failed: %v", err)
 			}
+			hits, _ := engine.Sum(correct, nil, false)
+			accuracy += hits.GetData()[0]
 
 			outloss, err := loss.Criterion(out, Ys[j])
 			if err != nil {
@@ -70,8 +81,12 @@ func main() {
 			if err != nil {
 				log.Fatalf("Backward pass failed: %v", err)
 			}
-            net.Backward(dout)
-            optimizer.Step()
+			if _, err := net.Backward(dout); err != nil {
+				log.Fatalf("Backward pass failed: %v", err)
+			}
+			if err := optimizer.Step(); err != nil {
+				log.Fatalf("Optimizer step failed: %v", err)
+			}
 		}
 		if (i+1)%100 == 0 {
 			fmt.Printf("Epoch: %d, accuracy: %.1f%%  loss: %.4f\n", i+1, accuracy, totaloutloss.GetData()[0]/float64(len(Xs)))
@@ -0,0 +1,187 @@
+package nn
+
+import (
+	"fmt"
+
+	"github.com/conacts/goten/engine"
+)
+
+// ActivationType selects an element-wise activation function.
+type ActivationType int
+
+const (
+	Identity  ActivationType = iota // No activation, the input is returned as is
+	ReLU                            // max(x, 0)
+	LeakyReLU                       // x if x > 0, slope * x otherwise
+	GELU                            // x * Φ(x), with Φ the standard normal CDF
+	Tanh                            // tanh(x)
+	Sigmoid                         // 1 / (1 + exp(-x))
+	SiLU                            // x * sigmoid(x)
+	ELU                             // x if x > 0, alpha * (exp(x) - 1) otherwise
+)
+
+// Default parameters of the activations created by NewActivation.
+const (
+	DefaultLeakySlope = 0.01
+	DefaultELUAlpha   = 1.0
+)
+
+func (a ActivationType) String() string {
+	switch a {
+	case Identity:
+		return "Identity"
+	case ReLU:
+		return "ReLU"
+	case LeakyReLU:
+		return "LeakyReLU"
+	case GELU:
+		return "GELU"
+	case Tanh:
+		return "Tanh"
+	case Sigmoid:
+		return "Sigmoid"
+	case SiLU:
+		return "SiLU"
+	case ELU:
+		return "ELU"
+	}
+	return fmt.Sprintf("ActivationType(%d)", int(a))
+}
+
+// Activation is a module applying an activation function element-wise. It has
+// no parameters and behaves the same in training and evaluation mode.
+type Activation struct {
+	kind     ActivationType
+	param    float64 // Negative slope of LeakyReLU, alpha of ELU
+	intensor *engine.Tensor
+	training bool
+}
+
+// NewActivation creates an activation module of the given type, using
+// DefaultLeakySlope and DefaultELUAlpha as parameters.
+func NewActivation(kind ActivationType) (*Activation, error) {
+	param := 0.0
+	switch kind {
+	case Identity, ReLU, GELU, Tanh, Sigmoid, SiLU:
+	case LeakyReLU:
+		param = DefaultLeakySlope
+	case ELU:
+		param = DefaultELUAlpha
+	default:
+		return nil, fmt.Errorf("unknown activation %v", kind)
+	}
+	return &Activation{kind: kind, param: param, training: true}, nil
+}
+
+// NewLeakyReLU creates a LeakyReLU module with the given negative slope.
+func NewLeakyReLU(slope float64) *Activation {
+	return &Activation{kind: LeakyReLU, param: slope, training: true}
+}
+
+// NewELU creates an ELU module with the given alpha.
+func NewELU(alpha float64) *Activation {
+	return &Activation{kind: ELU, param: alpha, training: true}
+}
+
+// Type returns the activation function of the module.
+func (a *Activation) Type() ActivationType {
+	return a.kind
+}
+
+func (a *Activation) Forward(x *engine.Tensor) (*engine.Tensor, error) {
+	if x == nil {
+		return nil, fmt.Errorf("cannot apply %v to nil tensor", a.kind)
+	}
+	a.intensor = x
+	return a.apply(x)
+}
+
+// apply runs the engine op of the activation on x.
+func (a *Activation) apply(x *engine.Tensor) (*engine.Tensor, error) {
+	switch a.kind {
+	case Identity:
+		return x, nil
+	case ReLU:
+		return engine.Relu(x)
+	case LeakyReLU:
+		return engine.LeakyRelu(x, a.param)
+	case GELU:
+		return engine.Gelu(x)
+	case Tanh:
+		return engine.Tanh(x)
+	case Sigmoid:
+		return engine.Sigmoid(x)
+	case SiLU:
+		return engine.Silu(x)
+	case ELU:
+		return engine.Elu(x, a.param)
+	}
+	return nil, fmt.Errorf("unknown activation %v", a.kind)
+}
+
+// Backward returns the gradient of the input of the last Forward. It reruns the
+// activation on a detached copy of the input and differentiates it with
+// autograd, so every activation shares the derivatives of the engine ops.
+func (a *Activation) Backward(dout *engine.Tensor) (*engine.Tensor, error) {
+	if a.intensor == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	if !engine.SameShape(a.intensor, dout) {
+		return nil, fmt.Errorf("invalid shape for output gradient, expected %v, got %v", a.intensor.GetShape(), dout.GetShape())
+	}
+	if a.kind == Identity {
+		return dout, nil
+	}
+
+	x := a.intensor.Detach()
+	x.SetRequiresGrad(true)
+	prev := engine.SetGradEnabled(true)
+	defer engine.SetGradEnabled(prev)
+	y, err := a.apply(x)
+	if err != nil {
+		return nil, err
+	}
+	weighted, err := engine.Mul(y, dout.Detach())
+	if err != nil {
+		return nil, err
+	}
+	sum, err := engine.Sum(weighted, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := sum.Backward(); err != nil {
+		return nil, fmt.Errorf("failed to differentiate %v: %v", a.kind, err)
+	}
+	return x.GetGrad(), nil
+}
+
+// Parameters returns nil, activations have no parameters.
+func (a *Activation) Parameters() []*engine.Tensor {
+	return nil
+}
+
+func (a *Activation) NamedParameters() []NamedParameter {
+	return nil
+}
+
+func (a *Activation) NamedChildren() []NamedModule {
+	return nil
+}
+
+func (a *Activation) Train(training bool) {
+	a.training = training
+}
+
+func (a *Activation) IsTraining() bool {
+	return a.training
+}
+
+func (a *Activation) String() string {
+	switch a.kind {
+	case LeakyReLU:
+		return fmt.Sprintf("LeakyReLU(slope=%g)", a.param)
+	case ELU:
+		return fmt.Sprintf("ELU(alpha=%g)", a.param)
+	}
+	return a.kind.String()
+}
@@ -0,0 +1,101 @@
+package nn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/conacts/goten/engine"
+)
+
+// Stateful is implemented by the optimizers and learning rate schedulers whose
+// state is saved in checkpoints.
+type Stateful interface {
+	State() State
+	LoadState(state State) error
+}
+
+// modelPrefix names the parameters of the model within a checkpoint.
+const modelPrefix = "model"
+
+// SaveCheckpoint writes the parameters of model and the states of components,
+// such as an optimizer and its scheduler, to w in the safetensors format. The
+// parameters are named "model.<parameter>", the tensors of a component
+// "<component>.<tensor>" and its scalars are stored as metadata under the same
+// scheme. Component names must not contain dots.
+func SaveCheckpoint(w io.Writer, model Module, components map[string]Stateful) error {
+	tensors := make(map[string]*engine.Tensor)
+	metadata := make(map[string]string)
+	if model != nil {
+		for _, p := range model.NamedParameters() {
+			tensors[modelPrefix+"."+p.Name] = p.Tensor.Detach()
+		}
+	}
+	for name, c := range components {
+		if name == "" || name == modelPrefix || strings.Contains(name, ".") {
+			return fmt.Errorf("invalid checkpoint component name %q", name)
+		}
+		state := c.State()
+		for key, t := range state.Tensors {
+			tensors[name+"."+key] = t
+		}
+		for key, v := range state.Scalars {
+			metadata[name+"."+key] = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	if err := engine.WriteSafetensors(w, tensors, metadata); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return nil
+}
+
+// LoadCheckpoint reads a checkpoint written by SaveCheckpoint from r, copies the
+// saved parameters into those of model and loads the state of every component.
+// The model must have the same parameters as the saved one.
+func LoadCheckpoint(r io.Reader, model Module, components map[string]Stateful) error {
+	tensors, metadata, err := engine.ReadSafetensors(r)
+	if err != nil {
+		return fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	states := make(map[string]State)
+	state := func(name string) State {
+		s, ok := states[name]
+		if !ok {
+			s = State{Scalars: make(map[string]float64), Tensors: make(map[string]*engine.Tensor)}
+			states[name] = s
+		}
+		return s
+	}
+	for key, t := range tensors {
+		name, rest, _ := strings.Cut(key, ".")
+		state(name).Tensors[rest] = t
+	}
+	for key, v := range metadata {
+		name, rest, ok := strings.Cut(key, ".")
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid checkpoint scalar %s = %q: %v", key, v, err)
+		}
+		state(name).Scalars[rest] = f
+	}
+
+	if model != nil {
+		if err := LoadParameters(model, state(modelPrefix).Tensors); err != nil {
+			return fmt.Errorf("failed to load model: %v", err)
+		}
+	}
+	for name, c := range components {
+		s, ok := states[name]
+		if !ok {
+			return fmt.Errorf("checkpoint has no state for %s", name)
+		}
+		if err := c.LoadState(s); err != nil {
+			return fmt.Errorf("failed to load state of %s: %v", name, err)
+		}
+	}
+	return nil
+}
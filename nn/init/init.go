@@ -0,0 +1,204 @@
+// Package initializer, imported from nn/init, creates the initial values of
+// parameters: Xavier/Glorot, Kaiming/He, LeCun, orthogonal and constant
+// initialisation. It cannot be named init, which Go reserves for functions.
+//
+// The fans of a parameter follow the layout of the linear layers of nn, whose
+// weights have shape [in, out]: for a shape [a, b, k...] the fan in is a times
+// the product of k and the fan out is b times it.
+package initializer
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/engine/linalg"
+)
+
+// Initializer creates a tensor of the given shape, drawing random values from g,
+// or from the default generator if g is nil.
+type Initializer func(g *engine.Generator, shape []int) (*engine.Tensor, error)
+
+// FanMode selects which fan scales the Kaiming initialisers.
+type FanMode int
+
+const (
+	FanIn  FanMode = iota // Preserve the variance of the activations in the forward pass
+	FanOut                // Preserve the variance of the gradients in the backward pass
+)
+
+// Fans returns the fan in and fan out of a parameter of the given shape, which
+// must have at least two dimensions.
+func Fans(shape []int) (fanIn, fanOut int, err error) {
+	if len(shape) < 2 {
+		return 0, 0, fmt.Errorf("fans need a shape of at least 2 dimensions, got %v", shape)
+	}
+	receptive := 1
+	for _, dim := range shape[2:] {
+		receptive *= dim
+	}
+	return shape[0] * receptive, shape[1] * receptive, nil
+}
+
+// Constant fills the tensor with v.
+func Constant(v float64) Initializer {
+	return func(_ *engine.Generator, shape []int) (*engine.Tensor, error) {
+		size := 1
+		for _, dim := range shape {
+			size *= dim
+		}
+		data := make([]float64, size)
+		for i := range data {
+			data[i] = v
+		}
+		return engine.NewTensor(data, shape)
+	}
+}
+
+// Zeros fills the tensor with zeros.
+func Zeros() Initializer {
+	return Constant(0)
+}
+
+// Ones fills the tensor with ones.
+func Ones() Initializer {
+	return Constant(1)
+}
+
+// Uniform draws the elements uniformly from [low, high).
+func Uniform(low, high float64) Initializer {
+	return func(g *engine.Generator, shape []int) (*engine.Tensor, error) {
+		return engine.Uniform(g, shape, low, high)
+	}
+}
+
+// Normal draws the elements from the normal distribution of the given mean and
+// standard deviation.
+func Normal(mean, std float64) Initializer {
+	return func(g *engine.Generator, shape []int) (*engine.Tensor, error) {
+		return engine.Normal(g, shape, mean, std)
+	}
+}
+
+// scaled returns an initializer drawing from a zero-centred distribution whose
+// scale is computed from the fans of the shape.
+func scaled(name string, scale func(fanIn, fanOut int) float64, sample func(g *engine.Generator, shape []int, scale float64) (*engine.Tensor, error)) Initializer {
+	return func(g *engine.Generator, shape []int) (*engine.Tensor, error) {
+		fanIn, fanOut, err := Fans(shape)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute %s initialisation: %v", name, err)
+		}
+		return sample(g, shape, scale(fanIn, fanOut))
+	}
+}
+
+// uniform samples from U(-bound, bound).
+func uniform(g *engine.Generator, shape []int, bound float64) (*engine.Tensor, error) {
+	return engine.Uniform(g, shape, -bound, bound)
+}
+
+// normal samples from N(0, std²).
+func normal(g *engine.Generator, shape []int, std float64) (*engine.Tensor, error) {
+	return engine.Normal(g, shape, 0, std)
+}
+
+// XavierUniform draws from U(-a, a) with a = gain * sqrt(6 / (fanIn + fanOut)),
+// the Glorot initialisation, suited to tanh and sigmoid activations.
+func XavierUniform(gain float64) Initializer {
+	return scaled("Xavier uniform", func(fanIn, fanOut int) float64 {
+		return gain * math.Sqrt(6/float64(fanIn+fanOut))
+	}, uniform)
+}
+
+// XavierNormal draws from N(0, std²) with std = gain * sqrt(2 / (fanIn + fanOut)).
+func XavierNormal(gain float64) Initializer {
+	return scaled("Xavier normal", func(fanIn, fanOut int) float64 {
+		return gain * math.Sqrt(2/float64(fanIn+fanOut))
+	}, normal)
+}
+
+// kaimingStd returns the standard deviation of the He initialisation for a
+// leaky ReLU of negative slope a, 0 for a ReLU.
+func kaimingStd(a float64, mode FanMode, fanIn, fanOut int) float64 {
+	fan := fanIn
+	if mode == FanOut {
+		fan = fanOut
+	}
+	gain := math.Sqrt(2 / (1 + a*a))
+	return gain / math.Sqrt(float64(fan))
+}
+
+// KaimingUniform draws from U(-b, b) with b = sqrt(3) times the standard
+// deviation of KaimingNormal, the He initialisation for ReLU activations, or
+// leaky ReLU activations of negative slope a.
+func KaimingUniform(a float64, mode FanMode) Initializer {
+	return scaled("Kaiming uniform", func(fanIn, fanOut int) float64 {
+		return math.Sqrt(3) * kaimingStd(a, mode, fanIn, fanOut)
+	}, uniform)
+}
+
+// KaimingNormal draws from N(0, std²) with std = sqrt(2 / (1 + a²)) / sqrt(fan),
+// the fan being selected by mode.
+func KaimingNormal(a float64, mode FanMode) Initializer {
+	return scaled("Kaiming normal", func(fanIn, fanOut int) float64 {
+		return kaimingStd(a, mode, fanIn, fanOut)
+	}, normal)
+}
+
+// LeCunUniform draws from U(-b, b) with b = sqrt(3 / fanIn), suited to SELU and
+// other self-normalising activations.
+func LeCunUniform() Initializer {
+	return scaled("LeCun uniform", func(fanIn, _ int) float64 {
+		return math.Sqrt(3 / float64(fanIn))
+	}, uniform)
+}
+
+// LeCunNormal draws from N(0, 1 / fanIn).
+func LeCunNormal() Initializer {
+	return scaled("LeCun normal", func(fanIn, _ int) float64 {
+		return math.Sqrt(1 / float64(fanIn))
+	}, normal)
+}
+
+// Orthogonal draws a random matrix with orthonormal rows or columns, whichever
+// are fewer, scaled by gain. A shape [a, b...] is treated as a matrix of a rows.
+// The matrix is the Q factor of the QR decomposition of a Gaussian matrix, which
+// is uniformly distributed over the orthogonal matrices.
+func Orthogonal(gain float64) Initializer {
+	return func(g *engine.Generator, shape []int) (*engine.Tensor, error) {
+		if len(shape) < 2 {
+			return nil, fmt.Errorf("orthogonal initialisation needs a shape of at least 2 dimensions, got %v", shape)
+		}
+		rows, cols := shape[0], 1
+		for _, dim := range shape[1:] {
+			cols *= dim
+		}
+		// Decompose a tall matrix so that Q has the smaller dimension as columns
+		transposed := rows < cols
+		m, n := rows, cols
+		if transposed {
+			m, n = cols, rows
+		}
+		a, err := engine.Normal(g, []int{m, n}, 0, 1)
+		if err != nil {
+			return nil, err
+		}
+		q, _, err := linalg.QR(a)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute orthogonal initialisation: %v", err)
+		}
+		if transposed {
+			if q, err = engine.Transpose(q); err != nil {
+				return nil, err
+			}
+		}
+		out, err := engine.Scale(q.Contiguous(), gain)
+		if err != nil {
+			return nil, err
+		}
+		if err := out.Reshape(append([]int(nil), shape...)); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+}
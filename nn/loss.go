@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"reflect"
 
-	"math"
-
 	"github.com/conacts/goten/engine"
 )
 
@@ -42,6 +40,8 @@ func Backward(preds, ans *engine.Tensor) (*engine.Tensor, error) {
 	return scale, nil
 }
 
+// MSE returns the mean squared error of each row of pred against y, keeping the
+// last dimension with size 1.
 func MSE(pred, y *engine.Tensor) (*engine.Tensor, error) {
 	diff, err := engine.Sub(pred, y)
 	if err != nil {
@@ -53,7 +53,7 @@ func MSE(pred, y *engine.Tensor) (*engine.Tensor, error) {
 		return nil, fmt.Errorf(fmt.Sprintf("failed to compute square of difference: %v", err))
 	}
 
-	mse, err := engine.Mean(square)
+	mse, err := engine.Mean(square, []int{-1}, true)
 	if err != nil {
 		return nil, fmt.Errorf(fmt.Sprintf("failed to compute mean of square: %v", err))
 	}
@@ -66,10 +66,7 @@ func MSE(pred, y *engine.Tensor) (*engine.Tensor, error) {
 // They must have the same length and contain values between 0 and 1
 func LogLoss(yPred, yTrue *engine.Tensor) (*engine.Tensor, error) {
 	// Check if inputs are valid
-	PredData := yPred.GetData()
 	PredShape := yPred.GetShape()
-
-	TrueData := yTrue.GetData()
 	TrueShape := yTrue.GetShape()
 
 	if !reflect.DeepEqual(PredShape, TrueShape) {
@@ -81,12 +78,39 @@ func LogLoss(yPred, yTrue *engine.Tensor) (*engine.Tensor, error) {
 	if yPred.GetData()[0] < 0 || yPred.GetData()[0] > 1 {
 		return nil, fmt.Errorf("yPred must contain values between 0 and 1")
 	}
-	// Compute log loss
-	var loss float64
-	for i := range TrueData {
-		loss += -TrueData[i]*math.Log(PredData[i]) - (1-TrueData[i])*math.Log(1-PredData[i])
+	// Compute log loss as -mean(y*log(p) + (1-y)*log(1-p))
+	one, _ := engine.NewTensor([]float64{1}, []int{1})
+	logPred, err := engine.Log(yPred)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute log of yPred: %v", err)
+	}
+	predComplement, err := engine.Sub(one, yPred)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute 1 - yPred: %v", err)
+	}
+	logComplement, err := engine.Log(predComplement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute log of 1 - yPred: %v", err)
+	}
+	trueComplement, err := engine.Sub(one, yTrue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute 1 - yTrue: %v", err)
+	}
+	positive, err := engine.Mul(yTrue, logPred)
+	if err != nil {
+		return nil, fmt.Errorf("failed to weight log of yPred: %v", err)
+	}
+	negative, err := engine.Mul(trueComplement, logComplement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to weight log of 1 - yPred: %v", err)
+	}
+	sum, err := engine.Add(positive, negative)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum log likelihoods: %v", err)
+	}
+	mean, err := engine.Mean(sum, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute mean of log likelihoods: %v", err)
 	}
-	loss /= float64(len(TrueData))
-	out, _ := engine.NewTensor([]float64{loss}, []int{1, 1})
-	return out, nil
+	return engine.Neg(mean)
 }
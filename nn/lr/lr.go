@@ -0,0 +1,241 @@
+// Package lr provides learning rate schedules that drive the learning rate of
+// any nn.Optimizer.
+//
+// A Schedule computes the learning rate after a number of steps from the
+// learning rate the optimizer started with. A Scheduler applies one to an
+// optimizer: call its Step once per batch or once per epoch, whichever unit the
+// schedule's step counts are expressed in. ReduceLROnPlateau instead lowers the
+// learning rate when a metric stops improving.
+package lr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/conacts/goten/nn"
+)
+
+// Schedule returns the learning rate after step steps, given the base learning rate.
+type Schedule func(base float64, step int) float64
+
+// StepLR multiplies the learning rate by gamma every stepSize steps.
+func StepLR(stepSize int, gamma float64) (Schedule, error) {
+	if stepSize <= 0 {
+		return nil, fmt.Errorf("step size must be positive, got %d", stepSize)
+	}
+	if !(gamma > 0) {
+		return nil, fmt.Errorf("gamma must be positive, got %v", gamma)
+	}
+	return func(base float64, step int) float64 {
+		return base * math.Pow(gamma, float64(step/stepSize))
+	}, nil
+}
+
+// MultiStepLR multiplies the learning rate by gamma at each of the milestones,
+// which must be increasing step counts.
+func MultiStepLR(milestones []int, gamma float64) (Schedule, error) {
+	for i, m := range milestones {
+		if m <= 0 || (i > 0 && m <= milestones[i-1]) {
+			return nil, fmt.Errorf("milestones must be positive and increasing, got %v", milestones)
+		}
+	}
+	if !(gamma > 0) {
+		return nil, fmt.Errorf("gamma must be positive, got %v", gamma)
+	}
+	milestones = append([]int(nil), milestones...)
+	return func(base float64, step int) float64 {
+		passed := 0
+		for _, m := range milestones {
+			if step >= m {
+				passed++
+			}
+		}
+		return base * math.Pow(gamma, float64(passed))
+	}, nil
+}
+
+// ExponentialLR multiplies the learning rate by gamma every step.
+func ExponentialLR(gamma float64) (Schedule, error) {
+	if !(gamma > 0) {
+		return nil, fmt.Errorf("gamma must be positive, got %v", gamma)
+	}
+	return func(base float64, step int) float64 {
+		return base * math.Pow(gamma, float64(step))
+	}, nil
+}
+
+// cosine interpolates from start to end along half a cosine as pct goes from 0 to 1.
+func cosine(start, end, pct float64) float64 {
+	return end + (start-end)*(1+math.Cos(math.Pi*pct))/2
+}
+
+// CosineAnnealingLR anneals the learning rate from its base value to minLR over
+// tMax steps along half a cosine, then keeps it at minLR.
+func CosineAnnealingLR(tMax int, minLR float64) (Schedule, error) {
+	if tMax <= 0 {
+		return nil, fmt.Errorf("tMax must be positive, got %d", tMax)
+	}
+	if !(minLR >= 0) {
+		return nil, fmt.Errorf("minimum learning rate must be non-negative, got %v", minLR)
+	}
+	return func(base float64, step int) float64 {
+		if step >= tMax {
+			return minLR
+		}
+		return cosine(base, minLR, float64(step)/float64(tMax))
+	}, nil
+}
+
+// CosineAnnealingWarmRestarts anneals the learning rate like CosineAnnealingLR,
+// restarting from the base value after t0 steps, then after tMult times longer
+// cycles each time (SGDR).
+func CosineAnnealingWarmRestarts(t0, tMult int, minLR float64) (Schedule, error) {
+	if t0 <= 0 || tMult < 1 {
+		return nil, fmt.Errorf("t0 must be positive and tMult at least 1, got %d and %d", t0, tMult)
+	}
+	if !(minLR >= 0) {
+		return nil, fmt.Errorf("minimum learning rate must be non-negative, got %v", minLR)
+	}
+	return func(base float64, step int) float64 {
+		cur, period := step, t0
+		if tMult == 1 {
+			cur %= t0
+		}
+		for cur >= period {
+			cur -= period
+			period *= tMult
+		}
+		return cosine(base, minLR, float64(cur)/float64(period))
+	}, nil
+}
+
+// LinearWarmup raises the learning rate linearly from startFactor times its base
+// value to the base value over warmupSteps steps, then follows after with its
+// step counts starting at the end of the warmup. A nil after keeps the base
+// learning rate.
+func LinearWarmup(warmupSteps int, startFactor float64, after Schedule) (Schedule, error) {
+	if warmupSteps <= 0 {
+		return nil, fmt.Errorf("warmup steps must be positive, got %d", warmupSteps)
+	}
+	if !(startFactor >= 0 && startFactor <= 1) {
+		return nil, fmt.Errorf("start factor must be in [0, 1], got %v", startFactor)
+	}
+	return func(base float64, step int) float64 {
+		if step < warmupSteps {
+			return base * (startFactor + (1-startFactor)*float64(step)/float64(warmupSteps))
+		}
+		if after == nil {
+			return base
+		}
+		return after(base, step-warmupSteps)
+	}, nil
+}
+
+// OneCycleOptions configures OneCycleLR.
+type OneCycleOptions struct {
+	MaxLR          float64 // Peak learning rate
+	TotalSteps     int     // Length of the cycle
+	PctStart       float64 // Fraction of the cycle spent raising the learning rate
+	DivFactor      float64 // The cycle starts at MaxLR / DivFactor
+	FinalDivFactor float64 // The cycle ends at MaxLR / DivFactor / FinalDivFactor
+}
+
+// DefaultOneCycleOptions returns a cycle of totalSteps peaking at maxLR after 30%
+// of it, starting 25 times lower and ending 10^4 times lower than that.
+func DefaultOneCycleOptions(maxLR float64, totalSteps int) OneCycleOptions {
+	return OneCycleOptions{MaxLR: maxLR, TotalSteps: totalSteps, PctStart: 0.3, DivFactor: 25, FinalDivFactor: 1e4}
+}
+
+// OneCycleLR raises the learning rate along half a cosine from MaxLR / DivFactor
+// to MaxLR, then anneals it far below the start by the end of the cycle, where
+// it stays. It ignores the base learning rate of the optimizer.
+func OneCycleLR(opts OneCycleOptions) (Schedule, error) {
+	if !(opts.MaxLR > 0) || opts.TotalSteps < 2 {
+		return nil, fmt.Errorf("one cycle needs a positive maximum learning rate and at least 2 steps, got %v and %d", opts.MaxLR, opts.TotalSteps)
+	}
+	if !(opts.PctStart > 0 && opts.PctStart < 1) {
+		return nil, fmt.Errorf("fraction of the cycle spent warming up must be in (0, 1), got %v", opts.PctStart)
+	}
+	if !(opts.DivFactor > 0) || !(opts.FinalDivFactor > 0) {
+		return nil, errors.New("division factors must be positive")
+	}
+	initial := opts.MaxLR / opts.DivFactor
+	final := initial / opts.FinalDivFactor
+	// The learning rate peaks at step peak and reaches final at the last step
+	peak := math.Max(opts.PctStart*float64(opts.TotalSteps)-1, 0)
+	last := float64(opts.TotalSteps - 1)
+	return func(_ float64, step int) float64 {
+		s := float64(step)
+		switch {
+		case s >= last:
+			return final
+		case s < peak:
+			return cosine(initial, opts.MaxLR, s/peak)
+		}
+		return cosine(opts.MaxLR, final, (s-peak)/(last-peak))
+	}, nil
+}
+
+// Scheduler sets the learning rate of an optimizer following a schedule.
+type Scheduler struct {
+	opt      nn.Optimizer
+	schedule Schedule
+	base     float64 // Learning rate of the optimizer when the scheduler was created
+	steps    int
+}
+
+// NewScheduler drives the learning rate of opt with schedule, starting from the
+// current learning rate of opt, which it sets to the value at step 0.
+func NewScheduler(opt nn.Optimizer, schedule Schedule) (*Scheduler, error) {
+	if opt == nil || schedule == nil {
+		return nil, errors.New("scheduler needs an optimizer and a schedule")
+	}
+	s := &Scheduler{opt: opt, schedule: schedule, base: opt.GetLearningRate()}
+	if err := s.apply(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// apply sets the learning rate of the optimizer for the current step.
+func (s *Scheduler) apply() error {
+	lr := s.schedule(s.base, s.steps)
+	if math.IsNaN(lr) || lr < 0 {
+		return fmt.Errorf("schedule returned invalid learning rate %v at step %d", lr, s.steps)
+	}
+	s.opt.SetLearningRate(lr)
+	return nil
+}
+
+// Step advances the schedule by one step and updates the learning rate.
+func (s *Scheduler) Step() error {
+	s.steps++
+	return s.apply()
+}
+
+// GetLearningRate returns the current learning rate of the optimizer.
+func (s *Scheduler) GetLearningRate() float64 {
+	return s.opt.GetLearningRate()
+}
+
+// Steps returns the number of steps taken.
+func (s *Scheduler) Steps() int {
+	return s.steps
+}
+
+func (s *Scheduler) State() nn.State {
+	return nn.State{Scalars: map[string]float64{"base_lr": s.base, "steps": float64(s.steps)}}
+}
+
+// LoadState restores the base learning rate and step count and sets the
+// learning rate of the optimizer accordingly.
+func (s *Scheduler) LoadState(state nn.State) error {
+	base, ok1 := state.Scalars["base_lr"]
+	steps, ok2 := state.Scalars["steps"]
+	if !ok1 || !ok2 || steps < 0 {
+		return fmt.Errorf("invalid scheduler state %v", state.Scalars)
+	}
+	s.base, s.steps = base, int(steps)
+	return s.apply()
+}
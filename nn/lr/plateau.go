@@ -0,0 +1,143 @@
+package lr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/conacts/goten/nn"
+)
+
+// PlateauMode tells whether the metric watched by ReduceLROnPlateau should go
+// down or up.
+type PlateauMode int
+
+const (
+	Min PlateauMode = iota // The metric is a loss
+	Max                    // The metric is a score such as an accuracy
+)
+
+// PlateauOptions configures ReduceLROnPlateau.
+type PlateauOptions struct {
+	Mode      PlateauMode
+	Factor    float64 // Multiplies the learning rate on a plateau
+	Patience  int     // Steps without improvement tolerated before reducing
+	Threshold float64 // Relative change of the best metric that counts as an improvement
+	Cooldown  int     // Steps to wait after a reduction before counting again
+	MinLR     float64 // Floor of the learning rate
+}
+
+// DefaultPlateauOptions returns options reducing the learning rate tenfold when
+// a loss has not improved by 0.01% for 10 steps.
+func DefaultPlateauOptions() PlateauOptions {
+	return PlateauOptions{Mode: Min, Factor: 0.1, Patience: 10, Threshold: 1e-4}
+}
+
+// ReduceLROnPlateau multiplies the learning rate of an optimizer by a factor when
+// a metric, usually the validation loss of each epoch, stops improving.
+type ReduceLROnPlateau struct {
+	opt      nn.Optimizer
+	opts     PlateauOptions
+	best     float64
+	bad      int // Steps since the last improvement
+	cooldown int // Steps left before bad steps are counted again
+	steps    int
+}
+
+func NewReduceLROnPlateau(opt nn.Optimizer, opts PlateauOptions) (*ReduceLROnPlateau, error) {
+	if opt == nil {
+		return nil, errors.New("scheduler needs an optimizer")
+	}
+	if opts.Mode != Min && opts.Mode != Max {
+		return nil, fmt.Errorf("unknown plateau mode %d", opts.Mode)
+	}
+	if !(opts.Factor > 0 && opts.Factor < 1) {
+		return nil, fmt.Errorf("factor must be in (0, 1), got %v", opts.Factor)
+	}
+	if opts.Patience < 0 || opts.Cooldown < 0 || !(opts.Threshold >= 0) || !(opts.MinLR >= 0) {
+		return nil, errors.New("patience, cooldown, threshold and minimum learning rate must be non-negative")
+	}
+	p := &ReduceLROnPlateau{opt: opt, opts: opts}
+	p.best = p.worst()
+	return p, nil
+}
+
+// worst returns the metric every value improves on.
+func (p *ReduceLROnPlateau) worst() float64 {
+	if p.opts.Mode == Max {
+		return math.Inf(-1)
+	}
+	return math.Inf(1)
+}
+
+// improves reports whether metric is better than the best one by the threshold.
+func (p *ReduceLROnPlateau) improves(metric float64) bool {
+	margin := p.opts.Threshold * math.Abs(p.best)
+	if math.IsInf(p.best, 0) {
+		margin = 0
+	}
+	if p.opts.Mode == Max {
+		return metric > p.best+margin
+	}
+	return metric < p.best-margin
+}
+
+// Step records the metric of the latest epoch and reduces the learning rate if
+// it has not improved for more than Patience steps.
+func (p *ReduceLROnPlateau) Step(metric float64) error {
+	if math.IsNaN(metric) {
+		return errors.New("metric is NaN")
+	}
+	p.steps++
+	if p.improves(metric) {
+		p.best = metric
+		p.bad = 0
+	} else {
+		p.bad++
+	}
+	if p.cooldown > 0 {
+		p.cooldown--
+		p.bad = 0
+	}
+	if p.bad > p.opts.Patience {
+		lr := p.opt.GetLearningRate()
+		p.opt.SetLearningRate(math.Max(lr*p.opts.Factor, p.opts.MinLR))
+		p.cooldown = p.opts.Cooldown
+		p.bad = 0
+	}
+	return nil
+}
+
+// GetLearningRate returns the current learning rate of the optimizer.
+func (p *ReduceLROnPlateau) GetLearningRate() float64 {
+	return p.opt.GetLearningRate()
+}
+
+// Best returns the best metric seen so far.
+func (p *ReduceLROnPlateau) Best() float64 {
+	return p.best
+}
+
+func (p *ReduceLROnPlateau) State() nn.State {
+	return nn.State{Scalars: map[string]float64{
+		"best":     p.best,
+		"bad":      float64(p.bad),
+		"cooldown": float64(p.cooldown),
+		"steps":    float64(p.steps),
+	}}
+}
+
+// LoadState restores the best metric and counters. The learning rate itself is
+// part of the state of the optimizer.
+func (p *ReduceLROnPlateau) LoadState(state nn.State) error {
+	for _, name := range []string{"best", "bad", "cooldown", "steps"} {
+		if _, ok := state.Scalars[name]; !ok {
+			return fmt.Errorf("plateau scheduler state has no %s", name)
+		}
+	}
+	p.best = state.Scalars["best"]
+	p.bad = int(state.Scalars["bad"])
+	p.cooldown = int(state.Scalars["cooldown"])
+	p.steps = int(state.Scalars["steps"])
+	return nil
+}
@@ -0,0 +1,132 @@
+package nn
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/conacts/goten/engine"
+)
+
+// Module is a layer, or a model built out of layers, that maps an input tensor
+// to an output tensor.
+//
+// Gradients can be computed in two ways. Forward records the graph of the ops it
+// runs, so calling Backward on a loss built from its output accumulates the
+// gradients of the parameters through autograd. Modules with a hand-written
+// backward pass also implement Backward, which takes the gradient of the output
+// of the last Forward and returns the gradient of its input, accumulating the
+// gradients of the parameters on the way. Modules without one return
+// ErrNoBackward.
+type Module interface {
+	Forward(x *engine.Tensor) (*engine.Tensor, error)
+	Backward(dout *engine.Tensor) (*engine.Tensor, error)
+
+	// Parameters returns the parameters of the module and its children.
+	Parameters() []*engine.Tensor
+	// NamedParameters returns the parameters of the module and its children,
+	// named by their dotted path such as "0.weight".
+	NamedParameters() []NamedParameter
+	// NamedChildren returns the direct submodules of the module in order.
+	NamedChildren() []NamedModule
+
+	// Train switches the module and its children to training mode, or to
+	// evaluation mode if training is false.
+	Train(training bool)
+	IsTraining() bool
+}
+
+// NamedModule is a submodule and its name within its parent.
+type NamedModule struct {
+	Name   string
+	Module Module
+}
+
+// NamedParameter is a parameter and its dotted path within a module.
+type NamedParameter struct {
+	Name   string
+	Tensor *engine.Tensor
+}
+
+// ErrNoBackward is returned by the Backward method of modules whose gradients
+// are only computed through autograd.
+var ErrNoBackward = errors.New("module has no hand-written backward pass, call Backward on the loss instead")
+
+// Eval switches m to evaluation mode.
+func Eval(m Module) {
+	m.Train(false)
+}
+
+// ZeroGrad resets the gradients of the parameters of m.
+func ZeroGrad(m Module) {
+	for _, p := range m.Parameters() {
+		p.ZeroGrad()
+	}
+}
+
+// LoadParameters copies tensors into the parameters of m with the same names,
+// such as the weights read from a safetensors file. Every parameter must be
+// present with its shape and no other tensor may be given; the values are
+// converted to float64.
+func LoadParameters(m Module, tensors map[string]*engine.Tensor) error {
+	params := m.NamedParameters()
+	for _, p := range params {
+		t, ok := tensors[p.Name]
+		if !ok || t == nil {
+			return fmt.Errorf("no tensor for parameter %s", p.Name)
+		}
+		if !engine.SameShape(t, p.Tensor) {
+			return fmt.Errorf("parameter %s has shape %v, tensor has shape %v", p.Name, p.Tensor.GetShape(), t.GetShape())
+		}
+	}
+	if len(tensors) != len(params) {
+		known := make(map[string]bool, len(params))
+		for _, p := range params {
+			known[p.Name] = true
+		}
+		for name := range tensors {
+			if !known[name] {
+				return fmt.Errorf("module has no parameter %s", name)
+			}
+		}
+	}
+	for _, p := range params {
+		data := append([]float64(nil), tensors[p.Name].GetData()...)
+		if err := p.Tensor.SetData(data); err != nil {
+			return fmt.Errorf("failed to load parameter %s: %v", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// childParameters names the parameters of the children of a module after the
+// child they belong to.
+func childParameters(children []NamedModule) []NamedParameter {
+	var params []NamedParameter
+	for _, child := range children {
+		for _, p := range child.Module.NamedParameters() {
+			params = append(params, NamedParameter{child.Name + "." + p.Name, p.Tensor})
+		}
+	}
+	return params
+}
+
+// accumulateGrad adds grad into the gradient of the parameter p, the way
+// autograd does, so that hand-written and autograd backward passes can be mixed.
+func accumulateGrad(p, grad *engine.Tensor) error {
+	if p.GetGrad() == nil {
+		// Copy so that the gradient shares no memory with grad or p
+		data := append([]float64(nil), grad.GetData()...)
+		g, err := engine.NewTensor(data, append([]int(nil), p.GetShape()...))
+		if err != nil {
+			return fmt.Errorf("failed to create gradient: %v", err)
+		}
+		p.SetGrad(g)
+		return nil
+	}
+	sum, err := engine.Add(p.GetGrad(), grad)
+	if err != nil {
+		return fmt.Errorf("failed to accumulate gradient: %v", err)
+	}
+	p.SetGrad(sum)
+	return nil
+}
@@ -5,70 +5,116 @@ import (
 	"strings"
 
 	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn/init"
 )
 
+// MLPOptions selects the activations of an MLP. The zero value builds a purely
+// linear network.
+type MLPOptions struct {
+	Hidden ActivationType // Activation after every layer but the last
+	Output ActivationType // Activation after the last layer, Identity for none
+
+	// Parameters of LeakyReLU and ELU, DefaultLeakySlope and DefaultELUAlpha if zero
+	LeakySlope float64
+	ELUAlpha   float64
+
+	// Initialisation of the layers. If WeightInit is nil, each layer uses the
+	// initialisation suited to the activation following it, see WeightInitFor.
+	WeightInit initializer.Initializer
+	BiasInit   initializer.Initializer // initializer.Zeros() if nil
+	Generator  *engine.Generator       // Source of the random values, the default generator if nil
+}
+
+// DefaultMLPOptions returns ReLU hidden activations and no output activation.
+func DefaultMLPOptions() MLPOptions {
+	return MLPOptions{Hidden: ReLU, Output: Identity}
+}
+
+// activation creates a module for kind with the parameters of the options.
+func (o MLPOptions) activation(kind ActivationType) (*Activation, error) {
+	switch {
+	case kind == LeakyReLU && o.LeakySlope != 0:
+		return NewLeakyReLU(o.LeakySlope), nil
+	case kind == ELU && o.ELUAlpha != 0:
+		return NewELU(o.ELUAlpha), nil
+	}
+	return NewActivation(kind)
+}
+
+// WeightInitFor returns the weight initialisation suited to a layer followed by
+// act: Kaiming uniform for the ReLU family and Xavier uniform for the others,
+// with the gain of 5/3 recommended for tanh.
+func WeightInitFor(act *Activation) initializer.Initializer {
+	switch act.kind {
+	case ReLU, GELU, SiLU, ELU:
+		return initializer.KaimingUniform(0, initializer.FanIn)
+	case LeakyReLU:
+		return initializer.KaimingUniform(act.param, initializer.FanIn)
+	case Tanh:
+		return initializer.XavierUniform(5.0 / 3)
+	}
+	return initializer.XavierUniform(1)
+}
+
+// MLP is a stack of linear layers, each followed by an activation.
 type MLP struct {
-	layers []*LinearLayer
+	layers      []*LinearLayer
+	activations []*Activation
+	net         *Sequential
 }
 
-func NewMLP(layerSizes []int) (*MLP, error) {
+func NewMLP(layerSizes []int, opts MLPOptions) (*MLP, error) {
 	if len(layerSizes) < 2 {
 		return nil, fmt.Errorf("invalid number of layer sizes")
 	}
 
-	// create linear layers
+	// create linear layers, each followed by its activation
 	layers := make([]*LinearLayer, len(layerSizes)-1)
+	activations := make([]*Activation, len(layers))
+	modules := make([]Module, 0, 2*len(layers))
 	for i := 0; i < len(layerSizes)-1; i++ {
-		ll, err := NewLinearLayer(layerSizes[i], layerSizes[i+1])
-		if err != nil {
-			return nil, fmt.Errorf("failed to create linear layer: %v", err)
+		kind := opts.Hidden
+		if i == len(layers)-1 {
+			kind = opts.Output
 		}
-		layers[i] = ll
-	}
-
-	return &MLP{layers}, nil
-}
-
-// Forward runs the forward pass through the MLP
-/*
-func (m *MLP) Forward(x *engine.Tensor) (*engine.Tensor, error) {
-	out := x
-	var err error
-	for _, l := range m.layers {
-		out, err = l.Forward(out)
+		act, err := opts.activation(kind)
 		if err != nil {
-			return nil, fmt.Errorf("failed to run forward pass through linear layer: %v", err)
+			return nil, fmt.Errorf("failed to create activation of layer %d: %v", i+1, err)
+		}
+		weightInit := opts.WeightInit
+		if weightInit == nil {
+			weightInit = WeightInitFor(act)
 		}
-		out, err = engine.Sigmoid(out)
+		ll, err := NewLinearLayerWithOptions(layerSizes[i], layerSizes[i+1], LinearOptions{weightInit, opts.BiasInit, opts.Generator})
 		if err != nil {
-			return nil, fmt.Errorf("failed to apply ReLU activation: %v", err)
+			return nil, fmt.Errorf("failed to create linear layer: %v", err)
 		}
+		layers[i] = ll
+		activations[i] = act
+		modules = append(modules, ll, act)
+	}
+	net, err := NewSequential(modules...)
+	if err != nil {
+		return nil, err
 	}
-	return out, nil
+
+	return &MLP{layers, activations, net}, nil
 }
-*/
 
 // Forward runs the forward pass through the MLP
 func (m *MLP) Forward(x *engine.Tensor) (*engine.Tensor, error) {
-	out := x
-	var err error
-	for _, l := range m.layers {
-		out, err = l.Forward(out)
-		if err != nil {
-			return nil, fmt.Errorf("failed to run forward pass through linear layer: %v", err)
-		}
-		out, err = engine.Relu(out)
-		if err != nil {
-			return nil, fmt.Errorf("failed to apply ReLU activation: %v", err)
-		}
-	}
-	return out, nil
+	return m.net.Forward(x)
 }
 
 func (m *MLP) GetLayers() []*LinearLayer {
 	return m.layers
 }
 
+// GetActivations returns the activation following each layer.
+func (m *MLP) GetActivations() []*Activation {
+	return m.activations
+}
+
 // Parameters returns a slice of all the parameters in the MLP
 func (m *MLP) GetParameters() []*engine.Tensor {
 	params := make([]*engine.Tensor, 0)
@@ -79,22 +125,32 @@ func (m *MLP) GetParameters() []*engine.Tensor {
 	return params
 }
 
-func (m *MLP) Backward(dout *engine.Tensor) error {
-	// Iterate through the layers in reverse order
-	for i := len(m.layers) - 1; i >= 0; i-- {
-		// layer := m.layers[i]
+// Backward backpropagates dout through the activations and layers in reverse
+// order and returns the gradient of the input.
+func (m *MLP) Backward(dout *engine.Tensor) (*engine.Tensor, error) {
+	return m.net.Backward(dout)
+}
 
-		// Backpropagate through the linear layer
-		dz, err := m.layers[i].Backward(dout)
-		if err != nil {
-			return fmt.Errorf("error in layer %d backward: %v", i+1, err)
-		}
+func (m *MLP) Parameters() []*engine.Tensor {
+	return m.net.Parameters()
+}
 
-		// Set the output gradient to the input gradient of the previous layer
-		dout = dz
-	}
+// NamedParameters names the parameters after the position of their module, the
+// layers being at even positions and the activations at odd ones.
+func (m *MLP) NamedParameters() []NamedParameter {
+	return m.net.NamedParameters()
+}
 
-	return nil
+func (m *MLP) NamedChildren() []NamedModule {
+	return m.net.NamedChildren()
+}
+
+func (m *MLP) Train(training bool) {
+	m.net.Train(training)
+}
+
+func (m *MLP) IsTraining() bool {
+	return m.net.IsTraining()
 }
 
 func (m *MLP) ZeroGrad() {
@@ -112,6 +168,7 @@ func (m *MLP) String() string {
 		fmt.Fprintf(&sb, "  Type: %T\n", layer)
 		fmt.Fprintf(&sb, "  Input shape: %v\n", layer.lin)
 		fmt.Fprintf(&sb, "  Output shape: %v\n", layer.lout)
+		fmt.Fprintf(&sb, "  Activation: %v\n", m.activations[i])
 		fmt.Fprintf(&sb, "  Parameters:\n")
 		for j, param := range layer.GetParameters() {
 			if j == 0 {
@@ -131,6 +188,7 @@ type LinearLayer struct {
 	lin      int
 	lout     int
 	intensor *engine.Tensor
+	training bool
 }
 
 func (l *LinearLayer) String() string {
@@ -145,23 +203,52 @@ func (l *LinearLayer) String() string {
 	return sb.String()
 }
 
+// LinearOptions selects how a linear layer initialises its parameters.
+type LinearOptions struct {
+	WeightInit initializer.Initializer // initializer.XavierUniform(1) if nil
+	BiasInit   initializer.Initializer // initializer.Zeros() if nil
+	Generator  *engine.Generator       // Source of the random values, the default generator if nil
+}
+
+// NewLinearLayer creates a layer with Xavier uniform weights and zero biases.
 func NewLinearLayer(lin, lout int) (*LinearLayer, error) {
-	w, err1 := engine.NewRandomTensor([]int{lin, lout})
+	return NewLinearLayerWithOptions(lin, lout, LinearOptions{})
+}
+
+// NewLinearLayerWithOptions creates a layer initialised as selected by opts.
+func NewLinearLayerWithOptions(lin, lout int, opts LinearOptions) (*LinearLayer, error) {
+	if lin <= 0 || lout <= 0 {
+		return nil, fmt.Errorf("invalid layer size %d -> %d", lin, lout)
+	}
+	weightInit, biasInit := opts.WeightInit, opts.BiasInit
+	if weightInit == nil {
+		weightInit = initializer.XavierUniform(1)
+	}
+	if biasInit == nil {
+		biasInit = initializer.Zeros()
+	}
+
+	w, err1 := weightInit(opts.Generator, []int{lin, lout})
 	if err1 != nil {
 		return nil, fmt.Errorf("failed to create weight tensor: %v", err1)
 	}
 
-	b, err2 := engine.NewRandomTensor([]int{1, lout})
+	b, err2 := biasInit(opts.Generator, []int{1, lout})
 	if err2 != nil {
 		return nil, fmt.Errorf("failed to create bias tensor: %v", err2)
 	}
 
+	// Track the parameters so that autograd can compute their gradients
+	w.SetRequiresGrad(true)
+	b.SetRequiresGrad(true)
+
 	return &LinearLayer{
 		w:        w,
 		b:        b,
 		lin:      lin,
 		lout:     lout,
 		intensor: nil,
+		training: true,
 	}, nil
 }
 
@@ -210,12 +297,8 @@ func (l *LinearLayer) Forward(x *engine.Tensor) (*engine.Tensor, error) {
 		return nil, err
 	}
 
-	// Add biases to the linear transformation
-	b, err := engine.NewTensor(l.b.GetData(), []int{x.GetShape()[0], l.lout})
-	if err != nil {
-		return nil, err
-	}
-	z, err = engine.Add(z, b)
+	// Add biases to the linear transformation, broadcasting them over the batch
+	z, err = engine.Add(z, l.b)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +306,19 @@ func (l *LinearLayer) Forward(x *engine.Tensor) (*engine.Tensor, error) {
 	return z, nil
 }
 
-func (l *LinearLayer) Backward(dout *engine.Tensor) (*engine.Tensor, error) {
+// Backward computes the gradients of the weights and biases from the input of
+// the last Forward, sets them as the weight and bias gradients, accumulates them
+// into the gradients of the parameters and returns the gradient of the input.
+func (l *LinearLayer) Backward(dout *engine.Tensor) (dx *engine.Tensor, err error) {
+	if l.intensor == nil {
+		return nil, fmt.Errorf("backward called before forward")
+	}
+	// The gradients are computed by hand, so keep their ops off the graph
+	engine.NoGrad(func() { dx, err = l.backward(dout) })
+	return dx, err
+}
+
+func (l *LinearLayer) backward(dout *engine.Tensor) (*engine.Tensor, error) {
 	// Check if the shape of input tensor is valid
 	if dout.GetShape()[1] != l.lout {
 		return nil, fmt.Errorf("invalid shape for input tensor, expected (%d, %d), got %v", dout.GetShape()[0], l.lout, dout.GetShape())
@@ -248,6 +343,9 @@ func (l *LinearLayer) Backward(dout *engine.Tensor) (*engine.Tensor, error) {
 		return nil, err
 	}
 	l.w.SetWeightGrads(weightGrads)
+	if err := accumulateGrad(l.w, weightGrads); err != nil {
+		return nil, err
+	}
 
 	// Compute gradient of biases
 	// Sum along axis 0 of dout to obtain the gradients for each example
@@ -256,11 +354,14 @@ func (l *LinearLayer) Backward(dout *engine.Tensor) (*engine.Tensor, error) {
 		return nil, err
 	}
 	// Reshape bias gradient tensor to match the shape of the bias tensor
-	biasGrads, err := engine.NewTensor(db.GetData(), []int{1, db.GetShape()[0]})
+	biasGrads, err := engine.NewTensor(db.GetData(), []int{1, l.lout})
 	if err != nil {
 		return nil, err
 	}
 	l.b.SetBiasGrads(biasGrads)
+	if err := accumulateGrad(l.b, biasGrads); err != nil {
+		return nil, err
+	}
 
 	// Propagate the gradient to the input
 	// Transpose weight tensor
@@ -284,6 +385,8 @@ func (l *LinearLayer) ZeroGrad() {
 
 	l.w.SetWeightGrads(w)
 	l.b.SetBiasGrads(b)
+	l.w.ZeroGrad()
+	l.b.ZeroGrad()
 }
 
 func (ll *LinearLayer) GetWeights() *engine.Tensor {
@@ -309,3 +412,26 @@ func (ll *LinearLayer) GetIntensor() *engine.Tensor {
 func (l *LinearLayer) GetParameters() []*engine.Tensor {
 	return []*engine.Tensor{l.w, l.b}
 }
+
+// Parameters returns the weights and biases of the layer.
+func (l *LinearLayer) Parameters() []*engine.Tensor {
+	return l.GetParameters()
+}
+
+func (l *LinearLayer) NamedParameters() []NamedParameter {
+	return []NamedParameter{{"weight", l.w}, {"bias", l.b}}
+}
+
+// NamedChildren returns nil, a linear layer has no submodules.
+func (l *LinearLayer) NamedChildren() []NamedModule {
+	return nil
+}
+
+// Train sets the mode of the layer, which behaves the same in both.
+func (l *LinearLayer) Train(training bool) {
+	l.training = training
+}
+
+func (l *LinearLayer) IsTraining() bool {
+	return l.training
+}
@@ -1,52 +1,512 @@
 package nn
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/conacts/goten/engine"
 )
 
-// Write a print function for the optimzer
+// Optimizer updates parameters from their gradients, which are read with
+// GetGrad after autograd or the Backward of a module has accumulated them.
+// Parameters without a gradient are left as they are.
+type Optimizer interface {
+	Step() error
+	// ZeroGrad resets the gradients of the parameters before the next backward pass.
+	ZeroGrad()
 
-type SGD struct {
+	GetLearningRate() float64
+	SetLearningRate(lr float64)
+
+	// State returns a copy of the learning rate, step count and per-parameter
+	// buffers, from which LoadState restores an optimizer over the same parameters.
+	State() State
+	LoadState(state State) error
+}
+
+// State is the serialisable state of an optimizer or a learning rate scheduler:
+// named scalars, and per-parameter tensors named "<parameter index>.<buffer>".
+type State struct {
+	Scalars map[string]float64
+	Tensors map[string]*engine.Tensor
+}
+
+// optimizer holds the parameters, learning rate, step count and per-parameter
+// buffers shared by every optimizer.
+type optimizer struct {
 	Parameters   []*engine.Tensor
 	LearningRate float64
+
+	steps   int
+	buffers []map[string][]float64 // Buffers of each parameter by name, allocated on first use
+}
+
+func newOptimizer(params []*engine.Tensor, lr float64) (optimizer, error) {
+	for i, p := range params {
+		if p == nil {
+			return optimizer{}, fmt.Errorf("parameter %d is nil", i)
+		}
+	}
+	if !(lr >= 0) {
+		return optimizer{}, fmt.Errorf("invalid learning rate %v", lr)
+	}
+	return optimizer{Parameters: params, LearningRate: lr}, nil
+}
+
+func (o *optimizer) GetLearningRate() float64 {
+	return o.LearningRate
+}
+
+func (o *optimizer) SetLearningRate(lr float64) {
+	o.LearningRate = lr
+}
+
+// Steps returns the number of steps taken.
+func (o *optimizer) Steps() int {
+	return o.steps
+}
+
+func (o *optimizer) ZeroGrad() {
+	for _, p := range o.Parameters {
+		p.ZeroGrad()
+	}
+}
+
+// buffer returns the named buffer of parameter i, filled with init when it is
+// first used.
+func (o *optimizer) buffer(i int, name string, init float64) []float64 {
+	if len(o.buffers) != len(o.Parameters) {
+		o.buffers = append(o.buffers, make([]map[string][]float64, len(o.Parameters)-len(o.buffers))...)
+	}
+	if o.buffers[i] == nil {
+		o.buffers[i] = make(map[string][]float64)
+	}
+	buf, ok := o.buffers[i][name]
+	if !ok {
+		buf = make([]float64, o.Parameters[i].GetSize())
+		for j := range buf {
+			buf[j] = init
+		}
+		o.buffers[i][name] = buf
+	}
+	return buf
+}
+
+// hasBuffer reports whether parameter i has the named buffer.
+func (o *optimizer) hasBuffer(i int, name string) bool {
+	if i >= len(o.buffers) || o.buffers[i] == nil {
+		return false
+	}
+	_, ok := o.buffers[i][name]
+	return ok
+}
+
+// update counts a step and calls f with the values and gradient of every
+// parameter that has a gradient, adding the L2 penalty weightDecay * p to the
+// gradient first. The values f leaves in p are written back into the parameter.
+// Every parameter is checked before any is updated, so a failed step changes
+// nothing.
+func (o *optimizer) update(weightDecay float64, f func(i int, p, g []float64)) error {
+	if !(o.LearningRate >= 0) {
+		return fmt.Errorf("invalid learning rate %v", o.LearningRate)
+	}
+	for i, param := range o.Parameters {
+		if param == nil {
+			return fmt.Errorf("parameter %d is nil", i)
+		}
+		if grad := param.GetGrad(); grad != nil && grad.GetSize() != param.GetSize() {
+			return fmt.Errorf("gradient of parameter %d has shape %v, parameter has shape %v", i, grad.GetShape(), param.GetShape())
+		}
+	}
+	o.steps++
+	for i, param := range o.Parameters {
+		grad := param.GetGrad()
+		if grad == nil {
+			continue
+		}
+		p := append([]float64(nil), param.GetData()...)
+		g := append([]float64(nil), grad.GetData()...)
+		if weightDecay != 0 {
+			for j := range g {
+				g[j] += weightDecay * p[j]
+			}
+		}
+		f(i, p, g)
+		if err := param.SetData(p); err != nil {
+			return fmt.Errorf("failed to update parameter %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (o *optimizer) State() State {
+	state := State{
+		Scalars: map[string]float64{"lr": o.LearningRate, "steps": float64(o.steps)},
+		Tensors: make(map[string]*engine.Tensor),
+	}
+	for i, buffers := range o.buffers {
+		for name, buf := range buffers {
+			t, _ := engine.NewTensor(append([]float64(nil), buf...), o.Parameters[i].GetShape())
+			state.Tensors[strconv.Itoa(i)+"."+name] = t
+		}
+	}
+	return state
+}
+
+func (o *optimizer) LoadState(state State) error {
+	buffers := make([]map[string][]float64, len(o.Parameters))
+	// Load in a fixed order so that the error reported for a bad state is deterministic
+	names := make([]string, 0, len(state.Tensors))
+	for name := range state.Tensors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := state.Tensors[name]
+		index, buffer, ok := strings.Cut(name, ".")
+		i, err := strconv.Atoi(index)
+		if !ok || err != nil || buffer == "" {
+			return fmt.Errorf("invalid optimizer state tensor name %q", name)
+		}
+		if i < 0 || i >= len(o.Parameters) {
+			return fmt.Errorf("optimizer state tensor %q refers to parameter %d of %d", name, i, len(o.Parameters))
+		}
+		if t == nil || t.GetSize() != o.Parameters[i].GetSize() {
+			return fmt.Errorf("optimizer state tensor %q does not match parameter of shape %v", name, o.Parameters[i].GetShape())
+		}
+		if buffers[i] == nil {
+			buffers[i] = make(map[string][]float64)
+		}
+		buffers[i][buffer] = append([]float64(nil), t.GetData()...)
+	}
+	if lr, ok := state.Scalars["lr"]; ok {
+		o.LearningRate = lr
+	}
+	if steps, ok := state.Scalars["steps"]; ok {
+		o.steps = int(steps)
+	}
+	o.buffers = buffers
+	return nil
+}
+
+// checkRange returns an error if v is not in [low, high), or [low, high] when
+// closed is true.
+func checkRange(name string, v, low, high float64, closed bool) error {
+	if v < low || v > high || (!closed && v == high) || math.IsNaN(v) {
+		bracket := ")"
+		if closed {
+			bracket = "]"
+		}
+		return fmt.Errorf("invalid %s %v, must be in [%v, %v%s", name, v, low, high, bracket)
+	}
+	return nil
+}
+
+// SGDOptions configures stochastic gradient descent.
+type SGDOptions struct {
+	LearningRate float64
+	Momentum     float64 // Factor of the velocity, 0 for plain SGD
+	Dampening    float64 // Fraction of the gradient left out of the velocity
+	Nesterov     bool    // Use Nesterov momentum, which needs momentum and no dampening
+	WeightDecay  float64 // L2 penalty added to the gradients
 }
 
+// SGD is stochastic gradient descent with optional momentum and weight decay.
+type SGD struct {
+	optimizer
+	opts SGDOptions
+}
+
+// NewSGD creates plain SGD with the given learning rate. It does not return an
+// error, so invalid parameters or learning rates are reported by Step instead;
+// use NewSGDWithOptions to check them up front.
 func NewSGD(params []*engine.Tensor, learningRate float64) *SGD {
-	return &SGD{
-		Parameters:   params,
-		LearningRate: learningRate,
+	base, err := newOptimizer(params, learningRate)
+	if err != nil {
+		base = optimizer{Parameters: params, LearningRate: learningRate}
 	}
+	return &SGD{optimizer: base, opts: SGDOptions{LearningRate: learningRate}}
 }
 
-func (s *SGD) Step() {
-	// Update the parameters of each layer
-	for i := 0; i < len(s.Parameters); i += 2 {
-		// Compute the gradient of the loss with respect to the parameter
-		gradW := s.Parameters[i].GetWeightGrads()
-		gradB := s.Parameters[i+1].GetBiasesGrads()
+// NewSGDWithOptions creates SGD configured by opts.
+func NewSGDWithOptions(params []*engine.Tensor, opts SGDOptions) (*SGD, error) {
+	base, err := newOptimizer(params, opts.LearningRate)
+	if err != nil {
+		return nil, err
+	}
+	if !(opts.Momentum >= 0) || !(opts.WeightDecay >= 0) {
+		return nil, fmt.Errorf("momentum and weight decay must be non-negative, got %v and %v", opts.Momentum, opts.WeightDecay)
+	}
+	if opts.Nesterov && (opts.Momentum == 0 || opts.Dampening != 0) {
+		return nil, errors.New("nesterov momentum needs a positive momentum and zero dampening")
+	}
+	return &SGD{optimizer: base, opts: opts}, nil
+}
 
-		// Scale the gradient by the learning rate
-		scaledGradW, err := engine.Scale(gradW, -s.LearningRate)
-		if err != nil {
-			log.Fatalf("failed to scale gradient: %v", err)
+func (s *SGD) Step() error {
+	momentum := s.opts.Momentum
+	return s.update(s.opts.WeightDecay, func(i int, p, g []float64) {
+		if momentum != 0 {
+			// The velocity starts at the first gradient, undamped
+			first := !s.hasBuffer(i, "momentum")
+			buf := s.buffer(i, "momentum", 0)
+			for j := range g {
+				if first {
+					buf[j] = g[j]
+				} else {
+					buf[j] = momentum*buf[j] + (1-s.opts.Dampening)*g[j]
+				}
+				if s.opts.Nesterov {
+					g[j] += momentum * buf[j]
+				} else {
+					g[j] = buf[j]
+				}
+			}
 		}
-		scaledGradB, err := engine.Scale(gradB, -s.LearningRate)
-		if err != nil {
-			log.Fatalf("failed to scale gradient: %v", err)
+		for j := range p {
+			p[j] -= s.LearningRate * g[j]
 		}
+	})
+}
+
+// AdamOptions configures Adam and AdamW.
+type AdamOptions struct {
+	LearningRate float64
+	Beta1        float64 // Decay of the average of the gradients
+	Beta2        float64 // Decay of the average of the squared gradients
+	Eps          float64 // Added to the denominator for numerical stability
+	WeightDecay  float64 // L2 penalty for Adam, decoupled decay for AdamW
+	AMSGrad      bool    // Use the running maximum of the squared gradient average
+}
+
+// DefaultAdamOptions returns the learning rate, betas and epsilon of the Adam paper.
+func DefaultAdamOptions() AdamOptions {
+	return AdamOptions{LearningRate: 1e-3, Beta1: 0.9, Beta2: 0.999, Eps: 1e-8}
+}
 
-		// Update the parameter using the scaled gradient
-		newParamW, err := engine.Add(s.Parameters[i], scaledGradW)
-		if err != nil {
-			log.Fatalf("failed to update parameter: %v", err)
+// Adam is the Adam optimizer, or AdamW when its weight decay is decoupled from
+// the gradient.
+type Adam struct {
+	optimizer
+	opts      AdamOptions
+	decoupled bool
+}
+
+// NewAdam creates Adam, which adds the weight decay to the gradient as an L2 penalty.
+func NewAdam(params []*engine.Tensor, opts AdamOptions) (*Adam, error) {
+	return newAdam(params, opts, false)
+}
+
+// NewAdamW creates AdamW, which shrinks the parameters by lr * WeightDecay at
+// every step instead of adding the decay to the gradient, so that it is not
+// rescaled by the adaptive learning rates. 0.01 is a common weight decay.
+func NewAdamW(params []*engine.Tensor, opts AdamOptions) (*Adam, error) {
+	return newAdam(params, opts, true)
+}
+
+func newAdam(params []*engine.Tensor, opts AdamOptions, decoupled bool) (*Adam, error) {
+	base, err := newOptimizer(params, opts.LearningRate)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRange("beta1", opts.Beta1, 0, 1, false); err != nil {
+		return nil, err
+	}
+	if err := checkRange("beta2", opts.Beta2, 0, 1, false); err != nil {
+		return nil, err
+	}
+	if !(opts.Eps >= 0) || !(opts.WeightDecay >= 0) {
+		return nil, fmt.Errorf("epsilon and weight decay must be non-negative, got %v and %v", opts.Eps, opts.WeightDecay)
+	}
+	return &Adam{optimizer: base, opts: opts, decoupled: decoupled}, nil
+}
+
+func (a *Adam) Step() error {
+	penalty := a.opts.WeightDecay
+	if a.decoupled {
+		penalty = 0
+	}
+	b1, b2 := a.opts.Beta1, a.opts.Beta2
+	return a.update(penalty, func(i int, p, g []float64) {
+		// update has already counted this step
+		correction1 := 1 - math.Pow(b1, float64(a.steps))
+		correction2 := 1 - math.Pow(b2, float64(a.steps))
+		m := a.buffer(i, "exp_avg", 0)
+		v := a.buffer(i, "exp_avg_sq", 0)
+		var vmax []float64
+		if a.opts.AMSGrad {
+			vmax = a.buffer(i, "max_exp_avg_sq", 0)
 		}
-		newParamB, err := engine.Add(s.Parameters[i+1], scaledGradB)
-		if err != nil {
-			log.Fatalf("failed to update parameter: %v", err)
+		for j := range p {
+			if a.decoupled {
+				p[j] -= a.LearningRate * a.opts.WeightDecay * p[j]
+			}
+			m[j] = b1*m[j] + (1-b1)*g[j]
+			v[j] = b2*v[j] + (1-b2)*g[j]*g[j]
+			second := v[j]
+			if vmax != nil {
+				vmax[j] = math.Max(vmax[j], v[j])
+				second = vmax[j]
+			}
+			p[j] -= a.LearningRate * (m[j] / correction1) / (math.Sqrt(second/correction2) + a.opts.Eps)
 		}
-		s.Parameters[i].SetData(newParamW.GetData())
-		s.Parameters[i].SetData(newParamB.GetData())
+	})
+}
+
+// RMSPropOptions configures RMSProp.
+type RMSPropOptions struct {
+	LearningRate float64
+	Alpha        float64 // Decay of the average of the squared gradients
+	Eps          float64 // Added to the denominator for numerical stability
+	Momentum     float64 // Factor of the velocity of the scaled gradients, 0 for none
+	Centered     bool    // Normalise by the variance rather than the second moment of the gradients
+	WeightDecay  float64 // L2 penalty added to the gradients
+}
+
+// DefaultRMSPropOptions returns a learning rate of 0.01, an alpha of 0.99 and an
+// epsilon of 1e-8.
+func DefaultRMSPropOptions() RMSPropOptions {
+	return RMSPropOptions{LearningRate: 1e-2, Alpha: 0.99, Eps: 1e-8}
+}
+
+// RMSProp divides the gradients by a running average of their magnitude.
+type RMSProp struct {
+	optimizer
+	opts RMSPropOptions
+}
+
+func NewRMSProp(params []*engine.Tensor, opts RMSPropOptions) (*RMSProp, error) {
+	base, err := newOptimizer(params, opts.LearningRate)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRange("alpha", opts.Alpha, 0, 1, false); err != nil {
+		return nil, err
+	}
+	if !(opts.Eps >= 0) || !(opts.Momentum >= 0) || !(opts.WeightDecay >= 0) {
+		return nil, fmt.Errorf("epsilon, momentum and weight decay must be non-negative, got %v, %v and %v", opts.Eps, opts.Momentum, opts.WeightDecay)
 	}
+	return &RMSProp{optimizer: base, opts: opts}, nil
+}
+
+func (r *RMSProp) Step() error {
+	alpha := r.opts.Alpha
+	return r.update(r.opts.WeightDecay, func(i int, p, g []float64) {
+		v := r.buffer(i, "square_avg", 0)
+		var avg, buf []float64
+		if r.opts.Centered {
+			avg = r.buffer(i, "grad_avg", 0)
+		}
+		if r.opts.Momentum > 0 {
+			buf = r.buffer(i, "momentum", 0)
+		}
+		for j := range p {
+			v[j] = alpha*v[j] + (1-alpha)*g[j]*g[j]
+			second := v[j]
+			if avg != nil {
+				avg[j] = alpha*avg[j] + (1-alpha)*g[j]
+				second -= avg[j] * avg[j]
+			}
+			step := g[j] / (math.Sqrt(second) + r.opts.Eps)
+			if buf != nil {
+				buf[j] = r.opts.Momentum*buf[j] + step
+				step = buf[j]
+			}
+			p[j] -= r.LearningRate * step
+		}
+	})
+}
+
+// AdagradOptions configures Adagrad.
+type AdagradOptions struct {
+	LearningRate            float64
+	InitialAccumulatorValue float64 // Starting value of the sums of squared gradients
+	Eps                     float64 // Added to the denominator for numerical stability
+	WeightDecay             float64 // L2 penalty added to the gradients
+}
+
+// DefaultAdagradOptions returns a learning rate of 0.01 and an epsilon of 1e-10.
+func DefaultAdagradOptions() AdagradOptions {
+	return AdagradOptions{LearningRate: 1e-2, Eps: 1e-10}
+}
+
+// Adagrad divides the gradients by the square root of the sum of all past
+// squared gradients, so that often updated parameters slow down.
+type Adagrad struct {
+	optimizer
+	opts AdagradOptions
+}
+
+func NewAdagrad(params []*engine.Tensor, opts AdagradOptions) (*Adagrad, error) {
+	base, err := newOptimizer(params, opts.LearningRate)
+	if err != nil {
+		return nil, err
+	}
+	if !(opts.InitialAccumulatorValue >= 0) || !(opts.Eps >= 0) || !(opts.WeightDecay >= 0) {
+		return nil, fmt.Errorf("initial accumulator value, epsilon and weight decay must be non-negative, got %v, %v and %v", opts.InitialAccumulatorValue, opts.Eps, opts.WeightDecay)
+	}
+	return &Adagrad{optimizer: base, opts: opts}, nil
+}
+
+func (a *Adagrad) Step() error {
+	return a.update(a.opts.WeightDecay, func(i int, p, g []float64) {
+		sum := a.buffer(i, "sum", a.opts.InitialAccumulatorValue)
+		for j := range p {
+			sum[j] += g[j] * g[j]
+			p[j] -= a.LearningRate * g[j] / (math.Sqrt(sum[j]) + a.opts.Eps)
+		}
+	})
+}
+
+// AdadeltaOptions configures Adadelta.
+type AdadeltaOptions struct {
+	LearningRate float64 // Scale of the updates, usually 1
+	Rho          float64 // Decay of the averages of the squared gradients and updates
+	Eps          float64 // Added inside the square roots for numerical stability
+	WeightDecay  float64 // L2 penalty added to the gradients
+}
+
+// DefaultAdadeltaOptions returns a learning rate of 1, a rho of 0.9 and an
+// epsilon of 1e-6.
+func DefaultAdadeltaOptions() AdadeltaOptions {
+	return AdadeltaOptions{LearningRate: 1, Rho: 0.9, Eps: 1e-6}
+}
+
+// Adadelta scales the gradients by the ratio of the running averages of past
+// updates and past gradients, so that it needs no tuned learning rate.
+type Adadelta struct {
+	optimizer
+	opts AdadeltaOptions
+}
+
+func NewAdadelta(params []*engine.Tensor, opts AdadeltaOptions) (*Adadelta, error) {
+	base, err := newOptimizer(params, opts.LearningRate)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRange("rho", opts.Rho, 0, 1, true); err != nil {
+		return nil, err
+	}
+	if !(opts.Eps >= 0) || !(opts.WeightDecay >= 0) {
+		return nil, fmt.Errorf("epsilon and weight decay must be non-negative, got %v and %v", opts.Eps, opts.WeightDecay)
+	}
+	return &Adadelta{optimizer: base, opts: opts}, nil
+}
+
+func (a *Adadelta) Step() error {
+	rho, eps := a.opts.Rho, a.opts.Eps
+	return a.update(a.opts.WeightDecay, func(i int, p, g []float64) {
+		v := a.buffer(i, "square_avg", 0)
+		u := a.buffer(i, "acc_delta", 0)
+		for j := range p {
+			v[j] = rho*v[j] + (1-rho)*g[j]*g[j]
+			delta := math.Sqrt(u[j]+eps) / math.Sqrt(v[j]+eps) * g[j]
+			u[j] = rho*u[j] + (1-rho)*delta*delta
+			p[j] -= a.LearningRate * delta
+		}
+	})
 }
@@ -0,0 +1,127 @@
+package nn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/conacts/goten/engine"
+)
+
+// Sequential runs its modules one after the other, feeding the output of each
+// into the next. Its children are named by their index.
+type Sequential struct {
+	modules  []Module
+	training bool
+}
+
+// NewSequential creates a Sequential in training mode running modules in order.
+func NewSequential(modules ...Module) (*Sequential, error) {
+	for i, m := range modules {
+		if m == nil {
+			return nil, fmt.Errorf("module %d is nil", i)
+		}
+	}
+	s := &Sequential{modules: append([]Module(nil), modules...)}
+	s.Train(true)
+	return s, nil
+}
+
+// Append adds m to the end of the sequence, in the mode of the sequence.
+func (s *Sequential) Append(m Module) error {
+	if m == nil {
+		return fmt.Errorf("cannot append nil module")
+	}
+	m.Train(s.training)
+	s.modules = append(s.modules, m)
+	return nil
+}
+
+// Len returns the number of modules in the sequence.
+func (s *Sequential) Len() int {
+	return len(s.modules)
+}
+
+// Get returns the i-th module of the sequence.
+func (s *Sequential) Get(i int) Module {
+	return s.modules[i]
+}
+
+func (s *Sequential) Forward(x *engine.Tensor) (*engine.Tensor, error) {
+	out := x
+	var err error
+	for i, m := range s.modules {
+		out, err = m.Forward(out)
+		if err != nil {
+			return nil, fmt.Errorf("forward pass of module %d (%T) failed: %v", i, m, err)
+		}
+	}
+	return out, nil
+}
+
+// Backward runs the hand-written backward passes of the modules in reverse
+// order. It fails with ErrNoBackward if one of the modules has none.
+func (s *Sequential) Backward(dout *engine.Tensor) (*engine.Tensor, error) {
+	var err error
+	for i := len(s.modules) - 1; i >= 0; i-- {
+		dout, err = s.modules[i].Backward(dout)
+		if err != nil {
+			return nil, fmt.Errorf("backward pass of module %d (%T) failed: %w", i, s.modules[i], err)
+		}
+	}
+	return dout, nil
+}
+
+func (s *Sequential) Parameters() []*engine.Tensor {
+	params := make([]*engine.Tensor, 0)
+	for _, m := range s.modules {
+		params = append(params, m.Parameters()...)
+	}
+	return params
+}
+
+func (s *Sequential) NamedParameters() []NamedParameter {
+	return childParameters(s.NamedChildren())
+}
+
+func (s *Sequential) NamedChildren() []NamedModule {
+	children := make([]NamedModule, len(s.modules))
+	for i, m := range s.modules {
+		children[i] = NamedModule{strconv.Itoa(i), m}
+	}
+	return children
+}
+
+func (s *Sequential) Train(training bool) {
+	s.training = training
+	for _, m := range s.modules {
+		m.Train(training)
+	}
+}
+
+func (s *Sequential) IsTraining() bool {
+	return s.training
+}
+
+func (s *Sequential) String() string {
+	var sb strings.Builder
+	sb.WriteString("Sequential(\n")
+	for i, m := range s.modules {
+		fmt.Fprintf(&sb, "  (%d): %s\n", i, describe(m))
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// describe returns a one-line summary of a module.
+func describe(m Module) string {
+	switch m := m.(type) {
+	case *LinearLayer:
+		return fmt.Sprintf("LinearLayer(%d -> %d)", m.lin, m.lout)
+	case *Activation:
+		return m.String()
+	case *Sequential:
+		return fmt.Sprintf("Sequential(%d modules)", m.Len())
+	}
+	return fmt.Sprintf("%T", m)
+}
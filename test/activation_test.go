@@ -0,0 +1,168 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+)
+
+func TestActivations(t *testing.T) {
+	data := []float64{-2.1, -0.7, 0.4, 1.3, 2.6, -0.2}
+	sigmoid := func(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+	tests := []struct {
+		act *nn.Activation
+		ref func(float64) float64
+	}{
+		{mustActivation(t, nn.Identity), func(x float64) float64 { return x }},
+		{mustActivation(t, nn.ReLU), func(x float64) float64 { return math.Max(x, 0) }},
+		{mustActivation(t, nn.LeakyReLU), func(x float64) float64 { return math.Max(x, 0.01*x) }},
+		{nn.NewLeakyReLU(0.2), func(x float64) float64 { return math.Max(x, 0.2*x) }},
+		{mustActivation(t, nn.GELU), func(x float64) float64 { return x * 0.5 * (1 + math.Erf(x/math.Sqrt2)) }},
+		{mustActivation(t, nn.Tanh), math.Tanh},
+		{mustActivation(t, nn.Sigmoid), sigmoid},
+		{mustActivation(t, nn.SiLU), func(x float64) float64 { return x * sigmoid(x) }},
+		{mustActivation(t, nn.ELU), func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return math.Exp(x) - 1
+		}},
+		{nn.NewELU(0.5), func(x float64) float64 {
+			if x > 0 {
+				return x
+			}
+			return 0.5 * (math.Exp(x) - 1)
+		}},
+	}
+	for _, tt := range tests {
+		x, _ := engine.NewTensor(data, []int{2, 3})
+		y, err := tt.act.Forward(x)
+		if err != nil {
+			t.Errorf("%v: Forward returned error: %v", tt.act, err)
+			continue
+		}
+		want := make([]float64, len(data))
+		for i, v := range data {
+			want[i] = tt.ref(v)
+		}
+		if !almostEqual(y.GetData(), want, 1e-12) {
+			t.Errorf("%v: Forward = %v, want %v", tt.act, y.GetData(), want)
+		}
+
+		dout, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+		dx, err := tt.act.Backward(dout)
+		if err != nil {
+			t.Errorf("%v: Backward returned error: %v", tt.act, err)
+			continue
+		}
+		numerical, err := engine.NumericGrad(func() (*engine.Tensor, error) { return tt.act.Forward(x) }, x, dout, 1e-6)
+		if err != nil {
+			t.Fatalf("NumericGrad returned error: %v", err)
+		}
+		if !almostEqual(dx.GetData(), numerical, 1e-6) {
+			t.Errorf("%v: Backward = %v, want %v", tt.act, dx.GetData(), numerical)
+		}
+	}
+
+	if _, err := nn.NewActivation(nn.ActivationType(100)); err == nil {
+		t.Error("Expected error for unknown activation")
+	}
+}
+
+func mustActivation(t *testing.T, kind nn.ActivationType) *nn.Activation {
+	act, err := nn.NewActivation(kind)
+	if err != nil {
+		t.Fatalf("NewActivation(%v) returned error: %v", kind, err)
+	}
+	return act
+}
+
+func TestMLPOptions(t *testing.T) {
+	net, err := nn.NewMLP([]int{3, 5, 2}, nn.MLPOptions{Hidden: nn.Tanh, Output: nn.Sigmoid})
+	if err != nil {
+		t.Fatalf("NewMLP returned error: %v", err)
+	}
+	acts := net.GetActivations()
+	if len(acts) != 2 || acts[0].Type() != nn.Tanh || acts[1].Type() != nn.Sigmoid {
+		t.Fatalf("activations = %v, want [Tanh Sigmoid]", acts)
+	}
+
+	// A sigmoid output covers the whole of (0, 1), unlike a sigmoid on top of a ReLU
+	x, _ := engine.Normal(engine.NewGenerator(6), []int{50, 3}, 0, 3)
+	out, err := net.Forward(x)
+	if err != nil {
+		t.Fatalf("Forward returned error: %v", err)
+	}
+	below := 0
+	for _, v := range out.GetData() {
+		if v <= 0 || v >= 1 {
+			t.Fatalf("sigmoid output %v outside (0, 1)", v)
+		}
+		if v < 0.5 {
+			below++
+		}
+	}
+	if below == 0 {
+		t.Error("no output below 0.5")
+	}
+
+	// Hand-written backward matches autograd through the activations
+	dout, _ := engine.Normal(engine.NewGenerator(7), []int{50, 2}, 0, 1)
+	if _, err := net.Backward(dout); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	w := net.GetLayers()[0].GetWeights()
+	analytic := w.GetGrad().GetData()
+	numerical, err := engine.NumericGrad(func() (*engine.Tensor, error) { return net.Forward(x) }, w, dout, 1e-6)
+	if err != nil {
+		t.Fatalf("NumericGrad returned error: %v", err)
+	}
+	if !almostEqual(analytic, numerical, 1e-5) {
+		t.Errorf("weight gradient = %v, want %v", analytic, numerical)
+	}
+}
+
+func TestMLPDefaultOptions(t *testing.T) {
+	net, err := nn.NewMLP([]int{2, 4, 1}, nn.DefaultMLPOptions())
+	if err != nil {
+		t.Fatalf("NewMLP returned error: %v", err)
+	}
+	acts := net.GetActivations()
+	if acts[0].Type() != nn.ReLU || acts[1].Type() != nn.Identity {
+		t.Errorf("default activations = %v, want [ReLU Identity]", acts)
+	}
+
+	// No output activation lets predictions go negative
+	x, _ := engine.Normal(engine.NewGenerator(8), []int{100, 2}, 0, 1)
+	last := net.GetLayers()[1]
+	negative, _ := engine.NewTensor([]float64{-1, -1, -1, -1}, []int{4, 1})
+	last.SetWeights(negative)
+	out, _ := net.Forward(x)
+	if min := minimum(out.GetData()); min >= 0 {
+		t.Errorf("output without activation never negative, min %v", min)
+	}
+
+	leaky, err := nn.NewMLP([]int{2, 2}, nn.MLPOptions{Output: nn.LeakyReLU, LeakySlope: 0.3})
+	if err != nil {
+		t.Fatalf("NewMLP returned error: %v", err)
+	}
+	if got := leaky.GetActivations()[0].String(); got != "LeakyReLU(slope=0.3)" {
+		t.Errorf("output activation = %s, want LeakyReLU(slope=0.3)", got)
+	}
+	if _, err := nn.NewMLP([]int{2, 2}, nn.MLPOptions{Hidden: nn.ActivationType(-1)}); err != nil {
+		t.Errorf("unused hidden activation should not be checked, got %v", err)
+	}
+	if _, err := nn.NewMLP([]int{2, 2, 2}, nn.MLPOptions{Hidden: nn.ActivationType(-1)}); err == nil {
+		t.Error("Expected error for unknown hidden activation")
+	}
+}
+
+func minimum(data []float64) float64 {
+	m := math.Inf(1)
+	for _, v := range data {
+		m = math.Min(m, v)
+	}
+	return m
+}
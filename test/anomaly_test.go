@@ -0,0 +1,103 @@
+package test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+)
+
+func TestAnomalyForward(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 0}, []int{2})
+
+	// Off by default: non-finite values propagate silently
+	if engine.IsAnomalyEnabled() {
+		t.Fatal("anomaly detection should be off by default")
+	}
+	out, err := engine.Log(x)
+	if err != nil || !math.IsInf(out.GetData()[1], -1) {
+		t.Fatalf("Log without anomaly detection = %v, %v", out, err)
+	}
+
+	engine.DetectAnomaly(func() {
+		if !engine.IsAnomalyEnabled() {
+			t.Error("DetectAnomaly did not turn anomaly detection on")
+		}
+		_, err = engine.Log(x)
+	})
+	if engine.IsAnomalyEnabled() {
+		t.Error("DetectAnomaly did not restore the previous setting")
+	}
+	if err == nil {
+		t.Fatal("Expected error for Log(0) in anomaly mode")
+	}
+	for _, want := range []string{"Log", "-Inf", "element 1", "[2]", "anomaly_test.go"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	// Finite results are returned as usual
+	engine.DetectAnomaly(func() {
+		out, err = engine.Exp(x)
+	})
+	if err != nil || out.GetData()[0] != math.E {
+		t.Errorf("Exp in anomaly mode = %v, %v", out, err)
+	}
+}
+
+func TestAnomalyNestedOp(t *testing.T) {
+	pred, _ := engine.NewTensor([]float64{0.5, 1}, []int{2, 1})
+	y, _ := engine.NewTensor([]float64{1, 1}, []int{2, 1})
+	var err error
+	engine.DetectAnomaly(func() {
+		_, err = nn.LogLoss(pred, y)
+	})
+	if err == nil {
+		t.Fatal("Expected error for LogLoss with a prediction of 1")
+	}
+	// The call site is the first frame outside the engine
+	if !strings.Contains(err.Error(), "Log") || !strings.Contains(err.Error(), "nn/loss.go") {
+		t.Errorf("error %q does not point at the Log call in nn/loss.go", err)
+	}
+}
+
+func TestAnomalyBackward(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{4, 0}, []int{2})
+	x.SetRequiresGrad(true)
+
+	var err error
+	engine.DetectAnomaly(func() {
+		var root, sum *engine.Tensor
+		root, err = engine.Sqrt(x)
+		if err != nil {
+			return
+		}
+		sum, err = engine.Sum(root, nil, false)
+		if err != nil {
+			return
+		}
+		err = sum.Backward()
+	})
+	if err == nil {
+		t.Fatal("Expected error for the infinite gradient of Sqrt at 0")
+	}
+	for _, want := range []string{"backward of Sqrt", "+Inf", "element 1", "anomaly_test.go"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	// Without anomaly detection the infinite gradient is accumulated
+	x.ZeroGrad()
+	root, _ := engine.Sqrt(x)
+	sum, _ := engine.Sum(root, nil, false)
+	if err := sum.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	if !math.IsInf(x.GetGrad().GetData()[1], 1) {
+		t.Errorf("gradient = %v, want +Inf at element 1", x.GetGrad().GetData())
+	}
+}
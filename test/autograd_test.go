@@ -0,0 +1,219 @@
+package test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func almostEqual(a, b []float64, tol float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBackwardMulAdd(t *testing.T) {
+	// loss = mean((a * b + a)) over a single row
+	a, _ := engine.NewTensor([]float64{1, 2, 3}, []int{1, 3})
+	b, _ := engine.NewTensor([]float64{4, 5, 6}, []int{1, 3})
+	a.SetRequiresGrad(true)
+	b.SetRequiresGrad(true)
+
+	ab, err := engine.Mul(a, b)
+	if err != nil {
+		t.Fatalf("Mul failed: %v", err)
+	}
+	sum, err := engine.Add(ab, a)
+	if err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	loss, err := engine.Mean(sum, nil, false)
+	if err != nil {
+		t.Fatalf("Mean failed: %v", err)
+	}
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+
+	// d/da = (b + 1) / 3, d/db = a / 3
+	expectedA := []float64{5. / 3, 6. / 3, 7. / 3}
+	expectedB := []float64{1. / 3, 2. / 3, 3. / 3}
+	if !almostEqual(a.GetGrad().GetData(), expectedA, 1e-12) {
+		t.Errorf("unexpected grad for a: got %v, want %v", a.GetGrad().GetData(), expectedA)
+	}
+	if !almostEqual(b.GetGrad().GetData(), expectedB, 1e-12) {
+		t.Errorf("unexpected grad for b: got %v, want %v", b.GetGrad().GetData(), expectedB)
+	}
+	if !reflect.DeepEqual(a.GetGrad().GetShape(), a.GetShape()) {
+		t.Errorf("grad shape %v does not match tensor shape %v", a.GetGrad().GetShape(), a.GetShape())
+	}
+}
+
+func TestBackwardDot(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2}, []int{1, 2})
+	w, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	w.SetRequiresGrad(true)
+	x.SetRequiresGrad(true)
+
+	z, _ := engine.Dot(x, w)
+	act, _ := engine.Sigmoid(z)
+	loss, _ := engine.Mean(act, nil, false)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+
+	// Compare against the hand-derived gradient
+	zd := z.GetData()
+	dz := make([]float64, 3)
+	for j := range dz {
+		s := 1 / (1 + math.Exp(-zd[j]))
+		dz[j] = s * (1 - s) / 3
+	}
+	expectedW := []float64{
+		1 * dz[0], 1 * dz[1], 1 * dz[2],
+		2 * dz[0], 2 * dz[1], 2 * dz[2],
+	}
+	expectedX := []float64{
+		1*dz[0] + 2*dz[1] + 3*dz[2],
+		4*dz[0] + 5*dz[1] + 6*dz[2],
+	}
+	if !almostEqual(w.GetGrad().GetData(), expectedW, 1e-12) {
+		t.Errorf("unexpected grad for w: got %v, want %v", w.GetGrad().GetData(), expectedW)
+	}
+	if !almostEqual(x.GetGrad().GetData(), expectedX, 1e-12) {
+		t.Errorf("unexpected grad for x: got %v, want %v", x.GetGrad().GetData(), expectedX)
+	}
+}
+
+func TestBackwardAccumulatesAndReusesInputs(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{3}, []int{1, 1})
+	a.SetRequiresGrad(true)
+
+	// a is used twice: d(a*a)/da = 2a
+	sq, _ := engine.Mul(a, a)
+	if err := sq.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	if a.GetGrad().GetData()[0] != 6 {
+		t.Errorf("expected grad 6, got %v", a.GetGrad().GetData()[0])
+	}
+
+	// A second backward pass accumulates into the existing gradient
+	sq, _ = engine.Mul(a, a)
+	sq.Backward()
+	if a.GetGrad().GetData()[0] != 12 {
+		t.Errorf("expected accumulated grad 12, got %v", a.GetGrad().GetData()[0])
+	}
+
+	a.ZeroGrad()
+	if a.GetGrad().GetData()[0] != 0 {
+		t.Errorf("expected ZeroGrad to reset grad, got %v", a.GetGrad().GetData()[0])
+	}
+}
+
+func TestBackwardSharedSubgraph(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	x.SetRequiresGrad(true)
+	h, _ := engine.Scale(x, 2)
+	h.RetainGrad()
+	l1, _ := engine.Sum(h, nil, false)
+	l2, _ := engine.Sum(h, nil, false)
+
+	// Each pass propagates only its own gradient through h
+	if err := l1.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	if err := l2.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{4, 4}) {
+		t.Errorf("expected grad [4 4] after two losses, got %v", x.GetGrad().GetData())
+	}
+	if !reflect.DeepEqual(h.GetGrad().GetData(), []float64{2, 2}) {
+		t.Errorf("expected retained grad [2 2], got %v", h.GetGrad().GetData())
+	}
+
+	// Running backward twice on one loss adds the same gradient again
+	if err := l1.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{6, 6}) {
+		t.Errorf("expected grad [6 6] after a third pass, got %v", x.GetGrad().GetData())
+	}
+	if l1.GetGrad() != nil {
+		t.Errorf("expected no grad on a non-leaf tensor that is not retained, got %v", l1.GetGrad().GetData())
+	}
+}
+
+func TestBackwardErrors(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 2}, []int{1, 2})
+	a.SetRequiresGrad(true)
+	out, _ := engine.Exp(a)
+	if err := out.Backward(); err == nil {
+		t.Error("expected Backward on a non-scalar tensor to fail")
+	}
+
+	b, _ := engine.NewTensor([]float64{1}, []int{1, 1})
+	if err := b.Backward(); err == nil {
+		t.Error("expected Backward on a tensor that does not require grad to fail")
+	}
+}
+
+func TestNoGrad(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 2}, []int{1, 2})
+	a.SetRequiresGrad(true)
+
+	var out *engine.Tensor
+	engine.NoGrad(func() {
+		out, _ = engine.Scale(a, 2)
+	})
+	if out.RequiresGrad() || !out.IsLeaf() {
+		t.Error("expected ops inside NoGrad not to be recorded")
+	}
+	if !engine.IsGradEnabled() {
+		t.Error("expected NoGrad to restore grad mode")
+	}
+
+	out, _ = engine.Scale(a, 2)
+	if !out.RequiresGrad() || out.GetOp() != "Scale" {
+		t.Errorf("expected Scale to be recorded, got op %q", out.GetOp())
+	}
+}
+
+func TestBackwardBroadcastReducesGrad(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	b, _ := engine.NewTensor([]float64{1, 2, 3}, []int{1, 3})
+	s, _ := engine.NewTensor([]float64{2}, []int{1})
+	b.SetRequiresGrad(true)
+	s.SetRequiresGrad(true)
+
+	// loss = mean((x + b) * s)
+	sum, _ := engine.Add(x, b)
+	prod, _ := engine.Mul(sum, s)
+	loss, _ := engine.Mean(prod, nil, false)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(b.GetGrad().GetShape(), []int{1, 3}) {
+		t.Errorf("expected bias grad shape [1 3], got %v", b.GetGrad().GetShape())
+	}
+	// Each bias element appears once per row: 2 rows * s / 6 elements
+	expectedB := []float64{2. / 3, 2. / 3, 2. / 3}
+	if !almostEqual(b.GetGrad().GetData(), expectedB, 1e-12) {
+		t.Errorf("unexpected grad for b: got %v, want %v", b.GetGrad().GetData(), expectedB)
+	}
+	// d/ds = mean(x + b) over every element
+	expectedS := []float64{(2 + 4 + 6 + 5 + 7 + 9) / 6.}
+	if !almostEqual(s.GetGrad().GetData(), expectedS, 1e-12) {
+		t.Errorf("unexpected grad for s: got %v, want %v", s.GetGrad().GetData(), expectedS)
+	}
+}
@@ -0,0 +1,113 @@
+package test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestDefaultBackend(t *testing.T) {
+	if engine.DefaultBackend().Name() != engine.CPU {
+		t.Errorf("Expected default backend %q, got %q", engine.CPU, engine.DefaultBackend().Name())
+	}
+	x, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	if x.GetBackend().Name() != engine.CPU {
+		t.Errorf("Expected new tensors on %q, got %q", engine.CPU, x.GetBackend().Name())
+	}
+	if _, err := engine.GetBackend("missing"); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+	if _, err := engine.SetDefaultBackend("missing"); err == nil {
+		t.Error("Expected error setting unknown default backend")
+	}
+	if err := engine.RegisterBackend(engine.DefaultBackend()); err == nil {
+		t.Error("Expected error registering a backend twice")
+	}
+	if err := engine.UnregisterBackend("missing"); err == nil {
+		t.Error("Expected error unregistering unknown backend")
+	}
+}
+
+func TestLoggingBackend(t *testing.T) {
+	var log strings.Builder
+	cpu, _ := engine.GetBackend(engine.CPU)
+	logging := engine.NewLoggingBackend(cpu, &log)
+	if err := engine.RegisterBackend(logging); err != nil {
+		t.Fatalf("RegisterBackend failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := engine.UnregisterBackend("log/cpu"); err != nil {
+			t.Errorf("UnregisterBackend failed: %v", err)
+		}
+	})
+	if _, err := engine.GetBackend("log/cpu"); err != nil {
+		t.Errorf("Expected registered backend to be found: %v", err)
+	}
+
+	// Tensors created while the logging backend is the default remember it
+	prev, err := engine.SetDefaultBackend("log/cpu")
+	if err != nil {
+		t.Fatalf("SetDefaultBackend failed: %v", err)
+	}
+	t.Cleanup(func() { engine.SetDefaultBackend(prev) })
+	if err := engine.UnregisterBackend("log/cpu"); err == nil {
+		t.Error("Expected error unregistering the default backend")
+	}
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	engine.SetDefaultBackend(prev)
+	y, _ := engine.NewTensor([]float64{1, 0, 0, 1}, []int{2, 2})
+	x.SetRequiresGrad(true)
+
+	z, _ := engine.Dot(x, y)
+	s, _ := engine.Add(z, y)
+	loss, _ := engine.Sum(s, nil, false)
+	if loss.GetBackend().Name() != "log/cpu" {
+		t.Errorf("Expected results to stay on the backend of their operands, got %q", loss.GetBackend().Name())
+	}
+	if !reflect.DeepEqual(loss.GetData(), []float64{12}) {
+		t.Errorf("Unexpected result %v", loss.GetData())
+	}
+	for _, op := range []string{"MatMul [2 2 2]", "Add [4]", "Sum [4 1]"} {
+		if !strings.Contains(log.String(), op) {
+			t.Errorf("Expected %q in log:\n%s", op, log.String())
+		}
+	}
+
+	// Backward kernels run on the backend too
+	log.Reset()
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	if !strings.Contains(log.String(), "MatMul") {
+		t.Errorf("Expected backward MatMul in log:\n%s", log.String())
+	}
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{1, 1, 1, 1}) {
+		t.Errorf("Unexpected gradient %v", x.GetGrad().GetData())
+	}
+
+	// Softmax and the backward passes of activations use the backend kernels
+	log.Reset()
+	x.ZeroGrad()
+	act, _ := engine.Relu(x)
+	sig, _ := engine.Sigmoid(act)
+	probs, _ := engine.Softmax(sig, 1)
+	picked, _ := engine.Mul(probs, y)
+	total, _ := engine.Sum(picked, nil, false)
+	if err := total.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	for _, op := range []string{"ShiftedExp [4]", "Softmax [4]", "SoftmaxBackward [4]", "SigmoidBackward [4]", "ReluBackward [4]"} {
+		if !strings.Contains(log.String(), op) {
+			t.Errorf("Expected %q in log:\n%s", op, log.String())
+		}
+	}
+
+	// Operands on the default backend are unaffected
+	log.Reset()
+	engine.Add(y, y)
+	if log.Len() != 0 {
+		t.Errorf("Expected no logging for default backend ops, got:\n%s", log.String())
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestComparisons(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 2, 3, math.NaN()}, []int{4})
+	b, _ := engine.NewTensor([]float64{2}, []int{1})
+
+	tests := []struct {
+		name string
+		op   func(t1, t2 *engine.Tensor) (*engine.Tensor, error)
+		want []bool
+	}{
+		{"Gt", engine.Gt, []bool{false, false, true, false}},
+		{"Ge", engine.Ge, []bool{false, true, true, false}},
+		{"Lt", engine.Lt, []bool{true, false, false, false}},
+		{"Le", engine.Le, []bool{true, true, false, false}},
+		{"Eq", engine.Eq, []bool{false, true, false, false}},
+		{"Ne", engine.Ne, []bool{true, false, true, true}},
+	}
+	for _, tt := range tests {
+		got, err := tt.op(a, b)
+		if err != nil {
+			t.Fatalf("%s returned error: %v", tt.name, err)
+		}
+		if got.GetDType() != engine.Bool || !reflect.DeepEqual(got.GetBoolData(), tt.want) {
+			t.Errorf("%s = %v %v, want %v", tt.name, got.GetDType(), got.GetBoolData(), tt.want)
+		}
+	}
+
+	c, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	if _, err := engine.Gt(a, c); err == nil {
+		t.Error("Expected error for shapes that cannot be broadcast")
+	}
+
+	// Masks do not take part in autograd
+	x, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	x.SetRequiresGrad(true)
+	m, _ := engine.Gt(x, b)
+	if m.RequiresGrad() {
+		t.Error("Expected comparison result not to require grad")
+	}
+}
+
+func TestLogicalOps(t *testing.T) {
+	p, _ := engine.NewBoolTensor([]bool{true, true, false, false}, []int{4})
+	q, _ := engine.NewBoolTensor([]bool{true, false, true, false}, []int{4})
+
+	and, _ := engine.And(p, q)
+	or, _ := engine.Or(p, q)
+	not, _ := engine.Not(p)
+	if !reflect.DeepEqual(and.GetBoolData(), []bool{true, false, false, false}) {
+		t.Errorf("And() = %v", and.GetBoolData())
+	}
+	if !reflect.DeepEqual(or.GetBoolData(), []bool{true, true, true, false}) {
+		t.Errorf("Or() = %v", or.GetBoolData())
+	}
+	if !reflect.DeepEqual(not.GetBoolData(), []bool{false, false, true, true}) {
+		t.Errorf("Not() = %v", not.GetBoolData())
+	}
+
+	x, _ := engine.NewTensor([]float64{0, math.NaN(), math.Inf(-1), 1}, []int{4})
+	nan, _ := engine.IsNaN(x)
+	inf, _ := engine.IsInf(x)
+	if !reflect.DeepEqual(nan.GetBoolData(), []bool{false, true, false, false}) {
+		t.Errorf("IsNaN() = %v", nan.GetBoolData())
+	}
+	if !reflect.DeepEqual(inf.GetBoolData(), []bool{false, false, true, false}) {
+		t.Errorf("IsInf() = %v", inf.GetBoolData())
+	}
+}
+
+func TestAllClose(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{0.1 + 0.2, 1e10, math.Inf(1)}, []int{3})
+	b, _ := engine.NewTensor([]float64{0.3, 1e10 + 1, math.Inf(1)}, []int{3})
+	if a.Equals(b) {
+		t.Error("Expected exact comparison to fail")
+	}
+	if !engine.AllClose(a, b, 1e-9, 1e-12) {
+		t.Error("Expected tensors to be close")
+	}
+
+	c, _ := engine.NewTensor([]float64{0.3, 1e10, math.Inf(-1)}, []int{3})
+	if engine.AllClose(a, c, 1e-9, 1e-12) {
+		t.Error("Expected opposite infinities not to be close")
+	}
+	nan, _ := engine.NewTensor([]float64{math.NaN()}, []int{1})
+	if engine.AllClose(nan, nan, 1, 1) {
+		t.Error("Expected NaN not to be close to itself")
+	}
+	d, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	if engine.AllClose(a, d, 1, 1) {
+		t.Error("Expected tensors of incompatible shapes not to be close")
+	}
+
+	mask, _ := engine.IsClose(a, c, 1e-9, 0)
+	if !reflect.DeepEqual(mask.GetBoolData(), []bool{true, true, false}) {
+		t.Errorf("IsClose() = %v", mask.GetBoolData())
+	}
+}
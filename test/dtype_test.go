@@ -0,0 +1,153 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestTypedConstructors(t *testing.T) {
+	f32, err := engine.NewFloat32Tensor([]float32{1.5, 2.5}, []int{1, 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f32.GetDType() != engine.Float32 {
+		t.Errorf("Expected float32 dtype, got %v", f32.GetDType())
+	}
+	if !reflect.DeepEqual(f32.GetData(), []float64{1.5, 2.5}) {
+		t.Errorf("Unexpected float64 view of float32 data %v", f32.GetData())
+	}
+
+	i64, _ := engine.NewInt64Tensor([]int64{1, 0, 3}, []int{3})
+	if v, _ := i64.GetValue([]int{2}); v != 3 {
+		t.Errorf("Expected value 3, got %v", v)
+	}
+	i64.SetValue(7.9, []int{0})
+	if !reflect.DeepEqual(i64.GetInt64Data(), []int64{7, 0, 3}) {
+		t.Errorf("Expected SetValue to truncate into int64 storage, got %v", i64.GetInt64Data())
+	}
+
+	b, _ := engine.NewBoolTensor([]bool{true, false}, []int{2})
+	if !reflect.DeepEqual(b.GetData(), []float64{1, 0}) {
+		t.Errorf("Unexpected float64 view of bool data %v", b.GetData())
+	}
+
+	if _, err := engine.NewInt64Tensor([]int64{1, 2}, []int{3}); err == nil {
+		t.Error("Expected error for mismatched data size")
+	}
+
+	x, _ := engine.NewTensorOf(engine.Bool, []float64{0, 2, -1}, []int{3})
+	if !reflect.DeepEqual(x.GetBoolData(), []bool{false, true, true}) {
+		t.Errorf("Unexpected bool conversion %v", x.GetBoolData())
+	}
+}
+
+func TestAsType(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1.7, -2.2, 0}, []int{3})
+
+	i := x.AsType(engine.Int64)
+	if !reflect.DeepEqual(i.GetInt64Data(), []int64{1, -2, 0}) {
+		t.Errorf("Unexpected int64 conversion %v", i.GetInt64Data())
+	}
+	b := i.AsType(engine.Bool)
+	if !reflect.DeepEqual(b.GetBoolData(), []bool{true, true, false}) {
+		t.Errorf("Unexpected bool conversion %v", b.GetBoolData())
+	}
+	f := x.AsType(engine.Float32)
+	if !reflect.DeepEqual(f.GetFloat32Data(), []float32{1.7, -2.2, 0}) {
+		t.Errorf("Unexpected float32 conversion %v", f.GetFloat32Data())
+	}
+	if x.AsType(engine.Float64) != x {
+		t.Error("Expected AsType to the same dtype to return the tensor itself")
+	}
+
+	// Large int64 values are copied exactly out of strided views
+	big, _ := engine.NewInt64Tensor([]int64{1<<62 + 1, 0, 1<<62 + 3}, []int{3})
+	view, _ := engine.Slice(big, 0, 0, 3, 2)
+	if !reflect.DeepEqual(view.GetInt64Data(), []int64{1<<62 + 1, 1<<62 + 3}) {
+		t.Errorf("Expected exact int64 data, got %v", view.GetInt64Data())
+	}
+}
+
+func TestDTypePromotion(t *testing.T) {
+	tests := []struct {
+		a, b, expected engine.DType
+	}{
+		{engine.Bool, engine.Bool, engine.Bool},
+		{engine.Bool, engine.Int64, engine.Int64},
+		{engine.Int64, engine.Float32, engine.Float32},
+		{engine.Float32, engine.Float64, engine.Float64},
+	}
+	for _, tt := range tests {
+		if got := engine.PromoteTypes(tt.a, tt.b); got != tt.expected {
+			t.Errorf("PromoteTypes(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+
+	i, _ := engine.NewInt64Tensor([]int64{1, 2}, []int{2})
+	f, _ := engine.NewFloat32Tensor([]float32{0.5, 0.5}, []int{2})
+	b, _ := engine.NewBoolTensor([]bool{true, true}, []int{2})
+
+	sum, _ := engine.Add(i, f)
+	if sum.GetDType() != engine.Float32 || !reflect.DeepEqual(sum.GetData(), []float64{1.5, 2.5}) {
+		t.Errorf("Add(int64, float32) = %v %v", sum.GetDType(), sum.GetData())
+	}
+	count, _ := engine.Sum(b, nil, false)
+	if count.GetDType() != engine.Int64 || count.GetData()[0] != 2 {
+		t.Errorf("Sum(bool) = %v %v", count.GetDType(), count.GetData())
+	}
+	prod, _ := engine.Mul(i, i)
+	if prod.GetDType() != engine.Int64 {
+		t.Errorf("Mul(int64, int64) has dtype %v", prod.GetDType())
+	}
+	mean, _ := engine.Mean(i, nil, false)
+	if mean.GetDType() != engine.Float64 || mean.GetData()[0] != 1.5 {
+		t.Errorf("Mean(int64) = %v %v", mean.GetDType(), mean.GetData())
+	}
+	exp, _ := engine.Exp(f)
+	if exp.GetDType() != engine.Float32 {
+		t.Errorf("Exp(float32) has dtype %v", exp.GetDType())
+	}
+
+	x, _ := engine.NewTensor([]float64{1, 3, 2}, []int{1, 3})
+	arg, _ := engine.ArgMax(x, 1, false)
+	if arg.GetDType() != engine.Int64 || arg.GetInt64Data()[0] != 1 {
+		t.Errorf("ArgMax() = %v %v", arg.GetDType(), arg.GetInt64Data())
+	}
+}
+
+func TestEqualsComparesDType(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	b, _ := engine.NewInt64Tensor([]int64{1, 2}, []int{2})
+	if a.Equals(b) {
+		t.Error("Expected tensors of different dtypes not to be equal")
+	}
+	if !a.AsType(engine.Int64).Equals(b) {
+		t.Error("Expected converted tensor to equal int64 tensor")
+	}
+}
+
+func TestFloat32Autograd(t *testing.T) {
+	w, _ := engine.NewFloat32Tensor([]float32{1, 2, 3}, []int{1, 3})
+	w.SetRequiresGrad(true)
+	sq, _ := engine.Square(w)
+	loss, _ := engine.Sum(sq, nil, false)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	if w.GetGrad().GetDType() != engine.Float32 {
+		t.Errorf("Expected float32 gradient, got %v", w.GetGrad().GetDType())
+	}
+	if !reflect.DeepEqual(w.GetGrad().GetFloat32Data(), []float32{2, 4, 6}) {
+		t.Errorf("Unexpected gradient %v", w.GetGrad().GetFloat32Data())
+	}
+
+	// Integer tensors never take part in autograd
+	i, _ := engine.NewInt64Tensor([]int64{1, 2}, []int{2})
+	i.SetRequiresGrad(true)
+	out, _ := engine.Sum(i, nil, false)
+	if out.RequiresGrad() {
+		t.Error("Expected integer result not to require grad")
+	}
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestEinsum(t *testing.T) {
+	m, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	sq, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	v, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	u, _ := engine.NewTensor([]float64{3, 4, 5}, []int{3})
+
+	tests := []struct {
+		name     string
+		spec     string
+		operands []*engine.Tensor
+		shape    []int
+		data     []float64
+	}{
+		{"transpose", "ij->ji", []*engine.Tensor{m}, []int{3, 2}, []float64{1, 4, 2, 5, 3, 6}},
+		{"sum", "ij->", []*engine.Tensor{m}, []int{}, []float64{21}},
+		{"column sums", "ij->j", []*engine.Tensor{m}, []int{3}, []float64{5, 7, 9}},
+		{"trace", "ii", []*engine.Tensor{sq}, []int{}, []float64{5}},
+		{"diagonal", "ii->i", []*engine.Tensor{sq}, []int{2}, []float64{1, 4}},
+		{"matrix vector", "ij,j->i", []*engine.Tensor{m, u}, []int{2}, []float64{26, 62}},
+		{"outer", "i,j", []*engine.Tensor{v, u}, []int{2, 3}, []float64{3, 4, 5, 6, 8, 10}},
+		{"implicit matmul", "ij,jk", []*engine.Tensor{sq, m}, []int{2, 3}, []float64{9, 12, 15, 19, 26, 33}},
+		{"bilinear", "i,ij,j->", []*engine.Tensor{v, m, u}, []int{}, []float64{150}},
+	}
+	for _, tt := range tests {
+		got, err := engine.Einsum(tt.spec, tt.operands...)
+		if err != nil {
+			t.Errorf("Einsum(%q) returned error: %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got.GetShape(), tt.shape) || !reflect.DeepEqual(got.GetData(), tt.data) {
+			t.Errorf("%s: Einsum(%q) = %v %v, want %v %v", tt.name, tt.spec, got.GetShape(), got.GetData(), tt.shape, tt.data)
+		}
+	}
+}
+
+func TestEinsumMatchesBatchMatMul(t *testing.T) {
+	a, _ := engine.NewRandomTensor([]int{2, 3, 4})
+	b, _ := engine.NewRandomTensor([]int{2, 4, 5})
+	got, err := engine.Einsum("bij,bjk->bik", a, b)
+	if err != nil {
+		t.Fatalf("Einsum() returned error: %v", err)
+	}
+	want, _ := engine.BatchMatMul(a, b)
+	if !reflect.DeepEqual(got.GetShape(), want.GetShape()) || !almostEqual(got.GetData(), want.GetData(), 1e-12) {
+		t.Errorf("Einsum() = %v, want %v", got.GetData(), want.GetData())
+	}
+}
+
+func TestEinsumErrors(t *testing.T) {
+	m, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	specs := []string{"ij,jk->ik", "ijk->i", "ij->k", "ij->ii", "i1->i", "ii->i"}
+	for _, spec := range specs {
+		if _, err := engine.Einsum(spec, m); err == nil {
+			t.Errorf("Expected error for subscripts %q", spec)
+		}
+	}
+}
+
+func TestEinsumGradient(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	y, _ := engine.NewTensor([]float64{5, 6}, []int{2})
+	x.SetRequiresGrad(true)
+	y.SetRequiresGrad(true)
+
+	// loss = sum_i x_ii * y_i
+	z, _ := engine.Einsum("ii,i->", x, y)
+	if err := z.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	if !reflect.DeepEqual(z.GetData(), []float64{29}) {
+		t.Errorf("Unexpected result %v", z.GetData())
+	}
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{5, 0, 0, 6}) {
+		t.Errorf("Unexpected grad for x %v", x.GetGrad().GetData())
+	}
+	if !reflect.DeepEqual(y.GetGrad().GetData(), []float64{1, 4}) {
+		t.Errorf("Unexpected grad for y %v", y.GetGrad().GetData())
+	}
+}
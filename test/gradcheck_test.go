@@ -0,0 +1,114 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+)
+
+func TestGradCheck(t *testing.T) {
+	g := engine.NewGenerator(9)
+	a, _ := engine.Normal(g, []int{3, 4}, 0, 1)
+	b, _ := engine.Normal(g, []int{4, 2}, 0, 1)
+	data := append([]float64(nil), a.GetData()...)
+
+	results, err := engine.GradCheck(func(in ...*engine.Tensor) (*engine.Tensor, error) {
+		z, err := engine.Dot(in[0], in[1])
+		if err != nil {
+			return nil, err
+		}
+		return engine.Tanh(z)
+	}, []*engine.Tensor{a, b}, engine.DefaultGradCheckOptions())
+	if err != nil {
+		t.Fatalf("GradCheck returned error: %v", err)
+	}
+	if len(results) != 2 || !results[0].Passed || !results[1].Passed {
+		t.Errorf("GradCheck of Tanh(Dot) failed:\n%s", engine.GradCheckReport(results, "a", "b"))
+	}
+	if !reflect.DeepEqual(a.GetData(), data) {
+		t.Errorf("GradCheck changed its input to %v", a.GetData())
+	}
+	if a.RequiresGrad() || a.GetGrad() != nil {
+		t.Error("GradCheck left gradient state on its input")
+	}
+
+	// Detaching one factor of x*x halves the analytic gradient
+	results, err = engine.GradCheck(func(in ...*engine.Tensor) (*engine.Tensor, error) {
+		return engine.Mul(in[0], in[0].Detach())
+	}, []*engine.Tensor{a}, engine.DefaultGradCheckOptions())
+	if err != nil {
+		t.Fatalf("GradCheck returned error: %v", err)
+	}
+	if results[0].Passed || results[0].MaxRelError < 0.4 {
+		t.Errorf("GradCheck did not catch a wrong gradient: %v", results[0])
+	}
+
+	// Inputs whose gradient autograd never reaches are checked against zero
+	results, err = engine.GradCheck(func(in ...*engine.Tensor) (*engine.Tensor, error) {
+		return engine.Scale(in[0].Detach(), 2)
+	}, []*engine.Tensor{a}, engine.DefaultGradCheckOptions())
+	if err != nil {
+		t.Fatalf("GradCheck returned error: %v", err)
+	}
+	if results[0].Passed {
+		t.Error("GradCheck did not catch a missing gradient")
+	}
+}
+
+func TestNumericGradLinearLayer(t *testing.T) {
+	opts := engine.DefaultGradCheckOptions()
+	layer, _ := nn.NewLinearLayer(3, 2)
+	x, _ := engine.Normal(engine.NewGenerator(1), []int{4, 3}, 0, 1)
+	dout, _ := engine.Normal(engine.NewGenerator(2), []int{4, 2}, 0, 1)
+	forward := func() (*engine.Tensor, error) { return layer.Forward(x) }
+	forward()
+	dx, err := layer.Backward(dout)
+	if err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+
+	checks := []struct {
+		name     string
+		param    *engine.Tensor
+		analytic []float64
+	}{
+		{"x", x, dx.GetData()},
+		{"w", layer.GetWeights(), layer.GetWeights().GetWeightGrads().GetData()},
+		{"b", layer.GetBiases(), layer.GetBiases().GetBiasesGrads().GetData()},
+	}
+	for _, c := range checks {
+		numerical, err := engine.NumericGrad(forward, c.param, dout, opts.Eps)
+		if err != nil {
+			t.Fatalf("NumericGrad returned error: %v", err)
+		}
+		r, err := engine.CompareGrads(c.analytic, numerical, opts)
+		if err != nil {
+			t.Fatalf("CompareGrads returned error: %v", err)
+		}
+		if !r.Passed {
+			t.Errorf("LinearLayer.Backward gradient of %s: %v", c.name, r)
+		}
+	}
+
+	wrong, _ := engine.NewTensor([]float64{1, 2, 3}, []int{3})
+	if _, err := engine.NumericGrad(forward, x, wrong, opts.Eps); err == nil {
+		t.Error("Expected error for output gradient of the wrong shape")
+	}
+}
+
+func TestCompareGrads(t *testing.T) {
+	opts := engine.GradCheckOptions{Atol: 1e-6, Rtol: 1e-3}
+	r, _ := engine.CompareGrads([]float64{1, 2, 0}, []float64{1, 2.0001, 0}, opts)
+	if !r.Passed || r.Worst != 1 {
+		t.Errorf("CompareGrads = %+v, want a pass with worst element 1", r)
+	}
+	r, _ = engine.CompareGrads([]float64{1, 3}, []float64{1, 2}, opts)
+	if r.Passed || r.MaxAbsError != 1 || r.MaxRelError != 1.0/3 {
+		t.Errorf("CompareGrads = %+v, want a failure with errors 1 and 1/3", r)
+	}
+	if _, err := engine.CompareGrads([]float64{1}, []float64{1, 2}, opts); err == nil {
+		t.Error("Expected error for gradients of different sizes")
+	}
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestGatherScatterAdd(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	x.SetRequiresGrad(true)
+	idx, _ := engine.NewInt64Tensor([]int64{2, 0, 1, 1}, []int{2, 2})
+
+	g, err := engine.Gather(x, 1, idx)
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(g.GetShape(), []int{2, 2}) || !reflect.DeepEqual(g.GetData(), []float64{3, 1, 5, 5}) {
+		t.Errorf("Gather() = %v %v", g.GetShape(), g.GetData())
+	}
+	loss, _ := engine.Sum(g, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{1, 0, 1, 0, 2, 0}) {
+		t.Errorf("Gather() grad = %v", x.GetGrad().GetData())
+	}
+
+	rows, _ := engine.NewInt64Tensor([]int64{1, 0, 1}, []int{1, 3})
+	g0, _ := engine.Gather(x, 0, rows)
+	if !reflect.DeepEqual(g0.GetData(), []float64{4, 2, 6}) {
+		t.Errorf("Gather() along axis 0 = %v", g0.GetData())
+	}
+
+	base, _ := engine.NewZeroTensor([]int{2, 3})
+	src, _ := engine.NewTensor([]float64{10, 20, 30, 40}, []int{2, 2})
+	src.SetRequiresGrad(true)
+	s, err := engine.ScatterAdd(base, 1, idx, src)
+	if err != nil {
+		t.Fatalf("ScatterAdd() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(s.GetData(), []float64{20, 0, 10, 0, 70, 0}) {
+		t.Errorf("ScatterAdd() = %v", s.GetData())
+	}
+	w, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	weighted, _ := engine.Mul(s, w)
+	loss, _ = engine.Sum(weighted, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(src.GetGrad().GetData(), []float64{3, 1, 5, 5}) {
+		t.Errorf("ScatterAdd() grad for src = %v", src.GetGrad().GetData())
+	}
+
+	bad, _ := engine.NewInt64Tensor([]int64{3, 0}, []int{2, 1})
+	if _, err := engine.Gather(x, 1, bad); err == nil {
+		t.Error("Expected error for out of range index")
+	}
+	floats, _ := engine.NewTensor([]float64{0, 1}, []int{2, 1})
+	if _, err := engine.Gather(x, 1, floats); err == nil {
+		t.Error("Expected error for float index")
+	}
+}
+
+func TestIndexSelect(t *testing.T) {
+	// Embedding lookup of rows 2, 0, 2
+	emb, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{3, 2})
+	emb.SetRequiresGrad(true)
+	idx, _ := engine.NewInt64Tensor([]int64{2, 0, 2}, []int{3})
+	out, err := engine.IndexSelect(emb, 0, idx)
+	if err != nil {
+		t.Fatalf("IndexSelect() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(out.GetShape(), []int{3, 2}) || !reflect.DeepEqual(out.GetData(), []float64{5, 6, 1, 2, 5, 6}) {
+		t.Errorf("IndexSelect() = %v %v", out.GetShape(), out.GetData())
+	}
+	loss, _ := engine.Sum(out, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(emb.GetGrad().GetData(), []float64{1, 1, 0, 0, 2, 2}) {
+		t.Errorf("IndexSelect() grad = %v", emb.GetGrad().GetData())
+	}
+
+	cols, _ := engine.NewInt64Tensor([]int64{1}, []int{1})
+	col, _ := engine.IndexSelect(emb, 1, cols)
+	if !reflect.DeepEqual(col.GetData(), []float64{2, 4, 6}) {
+		t.Errorf("IndexSelect() along axis 1 = %v", col.GetData())
+	}
+}
+
+func TestMaskedOps(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	x.SetRequiresGrad(true)
+	// One mask per column, broadcast over rows
+	mask, _ := engine.NewBoolTensor([]bool{true, false, true}, []int{3})
+
+	sel, err := engine.MaskedSelect(x, mask)
+	if err != nil {
+		t.Fatalf("MaskedSelect() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(sel.GetData(), []float64{1, 3, 4, 6}) {
+		t.Errorf("MaskedSelect() = %v", sel.GetData())
+	}
+	loss, _ := engine.Sum(sel, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{1, 0, 1, 1, 0, 1}) {
+		t.Errorf("MaskedSelect() grad = %v", x.GetGrad().GetData())
+	}
+
+	x.ZeroGrad()
+	filled, err := engine.MaskedFill(x, mask, -1)
+	if err != nil {
+		t.Fatalf("MaskedFill() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(filled.GetData(), []float64{-1, 2, -1, -1, 5, -1}) {
+		t.Errorf("MaskedFill() = %v", filled.GetData())
+	}
+	loss, _ = engine.Sum(filled, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{0, 1, 0, 0, 1, 0}) {
+		t.Errorf("MaskedFill() grad = %v", x.GetGrad().GetData())
+	}
+
+	notBool, _ := engine.NewTensor([]float64{1, 0, 1}, []int{3})
+	if _, err := engine.MaskedFill(x, notBool, 0); err == nil {
+		t.Error("Expected error for non-bool mask")
+	}
+	none, _ := engine.NewBoolTensor([]bool{false, false, false}, []int{3})
+	if _, err := engine.MaskedSelect(x, none); err == nil {
+		t.Error("Expected error when nothing is selected")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	cond, _ := engine.NewBoolTensor([]bool{true, false}, []int{2, 1})
+	a, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	b, _ := engine.NewTensor([]float64{0}, []int{1})
+	a.SetRequiresGrad(true)
+	b.SetRequiresGrad(true)
+
+	out, err := engine.Where(cond, a, b)
+	if err != nil {
+		t.Fatalf("Where() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(out.GetData(), []float64{1, 2, 0, 0}) {
+		t.Errorf("Where() = %v", out.GetData())
+	}
+	loss, _ := engine.Sum(out, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(a.GetGrad().GetData(), []float64{1, 1, 0, 0}) || !reflect.DeepEqual(b.GetGrad().GetData(), []float64{2}) {
+		t.Errorf("Where() grads = %v %v", a.GetGrad().GetData(), b.GetGrad().GetData())
+	}
+}
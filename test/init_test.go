@@ -0,0 +1,207 @@
+package test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+	"github.com/conacts/goten/nn/init"
+)
+
+func TestFans(t *testing.T) {
+	tests := []struct {
+		shape         []int
+		fanIn, fanOut int
+		wantErr       bool
+	}{
+		{[]int{3, 4}, 3, 4, false},
+		{[]int{2, 3, 5}, 10, 15, false},
+		{[]int{3}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		fanIn, fanOut, err := initializer.Fans(tt.shape)
+		if (err != nil) != tt.wantErr || fanIn != tt.fanIn || fanOut != tt.fanOut {
+			t.Errorf("Fans(%v) = %d, %d, %v, want %d, %d", tt.shape, fanIn, fanOut, err, tt.fanIn, tt.fanOut)
+		}
+	}
+}
+
+func TestConstantInitializers(t *testing.T) {
+	tests := []struct {
+		name string
+		init initializer.Initializer
+		want float64
+	}{
+		{"Zeros", initializer.Zeros(), 0},
+		{"Ones", initializer.Ones(), 1},
+		{"Constant", initializer.Constant(0.25), 0.25},
+	}
+	for _, tt := range tests {
+		out, err := tt.init(nil, []int{2, 3})
+		if err != nil {
+			t.Errorf("%s returned error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(out.GetShape(), []int{2, 3}) {
+			t.Errorf("%s shape = %v, want [2 3]", tt.name, out.GetShape())
+		}
+		for _, v := range out.GetData() {
+			if v != tt.want {
+				t.Errorf("%s = %v, want all %v", tt.name, out.GetData(), tt.want)
+				break
+			}
+		}
+	}
+}
+
+// std returns the standard deviation of data around zero.
+func std(data []float64) float64 {
+	sum := 0.0
+	for _, v := range data {
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(data)))
+}
+
+func TestScaledInitializers(t *testing.T) {
+	shape := []int{200, 300}
+	fanIn, fanOut := 200.0, 300.0
+	tests := []struct {
+		name  string
+		init  initializer.Initializer
+		std   float64
+		bound float64 // Largest magnitude of uniform samples, 0 for normal ones
+	}{
+		{"XavierUniform", initializer.XavierUniform(2), 2 * math.Sqrt(2/(fanIn+fanOut)), 2 * math.Sqrt(6/(fanIn+fanOut))},
+		{"XavierNormal", initializer.XavierNormal(1), math.Sqrt(2 / (fanIn + fanOut)), 0},
+		{"KaimingUniform", initializer.KaimingUniform(0, initializer.FanIn), math.Sqrt(2 / fanIn), math.Sqrt(6 / fanIn)},
+		{"KaimingNormal fan out", initializer.KaimingNormal(0, initializer.FanOut), math.Sqrt(2 / fanOut), 0},
+		{"KaimingNormal leaky", initializer.KaimingNormal(1, initializer.FanIn), math.Sqrt(1 / fanIn), 0},
+		{"LeCunUniform", initializer.LeCunUniform(), math.Sqrt(1 / fanIn), math.Sqrt(3 / fanIn)},
+		{"LeCunNormal", initializer.LeCunNormal(), math.Sqrt(1 / fanIn), 0},
+	}
+	for _, tt := range tests {
+		out, err := tt.init(engine.NewGenerator(9), shape)
+		if err != nil {
+			t.Errorf("%s returned error: %v", tt.name, err)
+			continue
+		}
+		data := out.GetData()
+		if got := std(data); math.Abs(got-tt.std) > 0.02*tt.std {
+			t.Errorf("%s std = %v, want %v", tt.name, got, tt.std)
+		}
+		if tt.bound > 0 {
+			for _, v := range data {
+				if math.Abs(v) > tt.bound {
+					t.Errorf("%s sample %v outside [-%v, %v]", tt.name, v, tt.bound, tt.bound)
+					break
+				}
+			}
+		}
+
+		again, _ := tt.init(engine.NewGenerator(9), shape)
+		if !reflect.DeepEqual(again.GetData(), data) {
+			t.Errorf("%s is not reproducible with the same generator", tt.name)
+		}
+	}
+
+	if _, err := initializer.XavierUniform(1)(nil, []int{5}); err == nil {
+		t.Error("Expected error for 1-D shape")
+	}
+}
+
+func TestOrthogonal(t *testing.T) {
+	for _, shape := range [][]int{{3, 5}, {5, 3}, {4, 4}, {2, 2, 3}} {
+		out, err := initializer.Orthogonal(2)(engine.NewGenerator(10), shape)
+		if err != nil {
+			t.Fatalf("Orthogonal(%v) returned error: %v", shape, err)
+		}
+		if !reflect.DeepEqual(out.GetShape(), shape) {
+			t.Errorf("Orthogonal(%v) shape = %v", shape, out.GetShape())
+		}
+		rows := shape[0]
+		m, _ := engine.NewTensor(out.GetData(), []int{rows, out.GetSize() / rows})
+		mt := mustTranspose(t, m)
+		// The product along the smaller dimension is 4 times the identity
+		gram := mustDot(t, m, mt)
+		if rows > m.GetShape()[1] {
+			gram = mustDot(t, mt, m)
+		}
+		n := gram.GetShape()[0]
+		want := make([]float64, n*n)
+		for i := 0; i < n; i++ {
+			want[i*n+i] = 4
+		}
+		if !almostEqual(gram.GetData(), want, 1e-10) {
+			t.Errorf("Orthogonal(%v) Gram matrix = %v, want %v", shape, gram.GetData(), want)
+		}
+	}
+}
+
+func TestLinearLayerInit(t *testing.T) {
+	ll, err := nn.NewLinearLayer(30, 20)
+	if err != nil {
+		t.Fatalf("NewLinearLayer returned error: %v", err)
+	}
+	for _, v := range ll.GetBiases().GetData() {
+		if v != 0 {
+			t.Fatalf("default biases = %v, want zeros", ll.GetBiases().GetData())
+		}
+	}
+	bound := math.Sqrt(6.0 / 50)
+	for _, v := range ll.GetWeights().GetData() {
+		if math.Abs(v) > bound {
+			t.Fatalf("default weight %v outside the Xavier bound %v", v, bound)
+		}
+	}
+
+	custom, err := nn.NewLinearLayerWithOptions(3, 2, nn.LinearOptions{
+		WeightInit: initializer.Constant(0.5),
+		BiasInit:   initializer.Ones(),
+	})
+	if err != nil {
+		t.Fatalf("NewLinearLayerWithOptions returned error: %v", err)
+	}
+	if custom.GetWeights().GetData()[0] != 0.5 || custom.GetBiases().GetData()[1] != 1 {
+		t.Errorf("custom initialisation = %v, %v", custom.GetWeights(), custom.GetBiases())
+	}
+	if !custom.GetWeights().RequiresGrad() || !custom.GetBiases().RequiresGrad() {
+		t.Error("parameters should require gradients")
+	}
+}
+
+func TestMLPInit(t *testing.T) {
+	opts := nn.MLPOptions{Hidden: nn.ReLU, Output: nn.Sigmoid, Generator: engine.NewGenerator(11)}
+	net, err := nn.NewMLP([]int{100, 50, 1}, opts)
+	if err != nil {
+		t.Fatalf("NewMLP returned error: %v", err)
+	}
+	layers := net.GetLayers()
+	// Kaiming before the ReLU, Xavier before the sigmoid
+	if got, want := std(layers[0].GetWeights().GetData()), math.Sqrt(2.0/100); math.Abs(got-want) > 0.05*want {
+		t.Errorf("hidden weight std = %v, want %v", got, want)
+	}
+	bound := math.Sqrt(6.0 / 51)
+	for _, v := range layers[1].GetWeights().GetData() {
+		if math.Abs(v) > bound {
+			t.Fatalf("output weight %v outside the Xavier bound %v", v, bound)
+		}
+	}
+
+	again, _ := nn.NewMLP([]int{100, 50, 1}, nn.MLPOptions{Hidden: nn.ReLU, Output: nn.Sigmoid, Generator: engine.NewGenerator(11)})
+	if !reflect.DeepEqual(again.GetLayers()[0].GetWeights().GetData(), layers[0].GetWeights().GetData()) {
+		t.Error("MLP initialisation is not reproducible with the same generator")
+	}
+
+	opts.WeightInit = initializer.Zeros()
+	zeros, _ := nn.NewMLP([]int{4, 3, 1}, opts)
+	for _, l := range zeros.GetLayers() {
+		for _, v := range l.GetWeights().GetData() {
+			if v != 0 {
+				t.Fatalf("WeightInit not applied to every layer: %v", l.GetWeights())
+			}
+		}
+	}
+}
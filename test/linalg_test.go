@@ -0,0 +1,326 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/engine/linalg"
+)
+
+func mustTensor(t *testing.T, data []float64, shape []int) *engine.Tensor {
+	t.Helper()
+	tensor, err := engine.NewTensor(data, shape)
+	if err != nil {
+		t.Fatalf("NewTensor returned error: %v", err)
+	}
+	return tensor
+}
+
+func mustDot(t *testing.T, tensors ...*engine.Tensor) *engine.Tensor {
+	t.Helper()
+	out := tensors[0]
+	for _, next := range tensors[1:] {
+		var err error
+		if out, err = engine.Dot(out, next); err != nil {
+			t.Fatalf("Dot returned error: %v", err)
+		}
+	}
+	return out
+}
+
+func mustTranspose(t *testing.T, tensor *engine.Tensor) *engine.Tensor {
+	t.Helper()
+	out, err := engine.Transpose(tensor)
+	if err != nil {
+		t.Fatalf("Transpose returned error: %v", err)
+	}
+	return out
+}
+
+func diag(t *testing.T, s *engine.Tensor) *engine.Tensor {
+	t.Helper()
+	n := s.GetShape()[0]
+	data := make([]float64, n*n)
+	for i, v := range s.GetData() {
+		data[i*n+i] = v
+	}
+	return mustTensor(t, data, []int{n, n})
+}
+
+func eye(t *testing.T, n int) *engine.Tensor {
+	t.Helper()
+	data := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		data[i*n+i] = 1
+	}
+	return mustTensor(t, data, []int{n, n})
+}
+
+func TestSolveInvDet(t *testing.T) {
+	a := mustTensor(t, []float64{0, 2, 1, 1, 1, 0, 3, 0, 1}, []int{3, 3})
+	b := mustTensor(t, []float64{5, 3, 4}, []int{3})
+
+	x, err := linalg.Solve(a, b)
+	if err != nil {
+		t.Fatalf("Solve returned error: %v", err)
+	}
+	want := mustTensor(t, []float64{1, 2, 1}, []int{3})
+	if !engine.AllClose(x, want, 1e-9, 1e-12) {
+		t.Errorf("Solve = %v, want %v", x.GetData(), want.GetData())
+	}
+
+	bm := mustTensor(t, []float64{5, 1, 3, 0, 5, 2}, []int{3, 2})
+	xm, err := linalg.Solve(a, bm)
+	if err != nil {
+		t.Fatalf("Solve returned error: %v", err)
+	}
+	if !engine.AllClose(mustDot(t, a, xm), bm, 1e-9, 1e-12) {
+		t.Errorf("a . Solve(a, b) = %v, want %v", mustDot(t, a, xm).GetData(), bm.GetData())
+	}
+
+	inv, err := linalg.Inv(a)
+	if err != nil {
+		t.Fatalf("Inv returned error: %v", err)
+	}
+	if !engine.AllClose(mustDot(t, a, inv), eye(t, 3), 1e-9, 1e-12) {
+		t.Errorf("a . Inv(a) = %v, want identity", mustDot(t, a, inv).GetData())
+	}
+
+	det, err := linalg.Det(a)
+	if err != nil {
+		t.Fatalf("Det returned error: %v", err)
+	}
+	if math.Abs(det+5) > 1e-12 {
+		t.Errorf("Det = %v, want -5", det)
+	}
+
+	singular := mustTensor(t, []float64{1, 2, 2, 4}, []int{2, 2})
+	if _, err := linalg.Solve(singular, mustTensor(t, []float64{1, 2}, []int{2})); err == nil {
+		t.Error("Expected error for singular matrix in Solve")
+	}
+	if _, err := linalg.Inv(singular); err == nil {
+		t.Error("Expected error for singular matrix in Inv")
+	}
+	if det, _ := linalg.Det(singular); det != 0 {
+		t.Errorf("Det of singular matrix = %v, want 0", det)
+	}
+	if _, err := linalg.Solve(a, mustTensor(t, []float64{1, 2}, []int{2})); err == nil {
+		t.Error("Expected error for mismatched right-hand side")
+	}
+	if _, err := linalg.Inv(mustTensor(t, []float64{1, 2, 3, 4, 5, 6}, []int{2, 3})); err == nil {
+		t.Error("Expected error for non-square matrix")
+	}
+}
+
+func TestLU(t *testing.T) {
+	a := mustTensor(t, []float64{0, 2, 1, 1, 1, 0, 3, 0, 1}, []int{3, 3})
+	p, l, u, err := linalg.LU(a)
+	if err != nil {
+		t.Fatalf("LU returned error: %v", err)
+	}
+	if !engine.AllClose(mustDot(t, p, l, u), a, 1e-9, 1e-12) {
+		t.Errorf("P . L . U = %v, want %v", mustDot(t, p, l, u).GetData(), a.GetData())
+	}
+	ld, ud := l.GetData(), u.GetData()
+	for i := 0; i < 3; i++ {
+		if ld[i*3+i] != 1 {
+			t.Errorf("L[%d][%d] = %v, want 1", i, i, ld[i*3+i])
+		}
+		for j := i + 1; j < 3; j++ {
+			if ld[i*3+j] != 0 || ud[j*3+i] != 0 {
+				t.Errorf("L or U is not triangular: %v, %v", ld, ud)
+			}
+		}
+	}
+}
+
+func TestQR(t *testing.T) {
+	for _, shape := range [][]int{{4, 3}, {3, 3}, {2, 4}, {200, 3}} {
+		data := make([]float64, shape[0]*shape[1])
+		for i := range data {
+			data[i] = math.Sin(float64(3*i + 1))
+		}
+		a := mustTensor(t, data, shape)
+		q, r, err := linalg.QR(a)
+		if err != nil {
+			t.Fatalf("QR returned error: %v", err)
+		}
+		k := shape[0]
+		if shape[1] < k {
+			k = shape[1]
+		}
+		if !engine.AllClose(mustDot(t, q, r), a, 1e-9, 1e-12) {
+			t.Errorf("%v: Q . R = %v, want %v", shape, mustDot(t, q, r).GetData(), data)
+		}
+		if !engine.AllClose(mustDot(t, mustTranspose(t, q), q), eye(t, k), 1e-9, 1e-12) {
+			t.Errorf("%v: Q is not orthonormal", shape)
+		}
+		rd := r.GetData()
+		for i := 0; i < k; i++ {
+			if rd[i*shape[1]+i] < 0 {
+				t.Errorf("%v: R has negative diagonal %v", shape, rd)
+			}
+			for j := 0; j < i; j++ {
+				if rd[i*shape[1]+j] != 0 {
+					t.Errorf("%v: R is not upper triangular: %v", shape, rd)
+				}
+			}
+		}
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	a := mustTensor(t, []float64{4, 12, -16, 12, 37, -43, -16, -43, 98}, []int{3, 3})
+	l, err := linalg.Cholesky(a)
+	if err != nil {
+		t.Fatalf("Cholesky returned error: %v", err)
+	}
+	want := mustTensor(t, []float64{2, 0, 0, 6, 1, 0, -8, 5, 3}, []int{3, 3})
+	if !engine.AllClose(l, want, 1e-9, 1e-12) {
+		t.Errorf("Cholesky = %v, want %v", l.GetData(), want.GetData())
+	}
+
+	notPD := mustTensor(t, []float64{1, 2, 2, 1}, []int{2, 2})
+	if _, err := linalg.Cholesky(notPD); err == nil {
+		t.Error("Expected error for matrix that is not positive definite")
+	}
+}
+
+func TestSVD(t *testing.T) {
+	for _, shape := range [][]int{{4, 3}, {3, 3}, {2, 4}, {200, 3}} {
+		data := make([]float64, shape[0]*shape[1])
+		for i := range data {
+			data[i] = math.Cos(float64(2*i + 1))
+		}
+		a := mustTensor(t, data, shape)
+		u, s, vt, err := linalg.SVD(a)
+		if err != nil {
+			t.Fatalf("SVD returned error: %v", err)
+		}
+		if !engine.AllClose(mustDot(t, u, diag(t, s), vt), a, 1e-9, 1e-12) {
+			t.Errorf("%v: U . S . Vt = %v, want %v", shape, mustDot(t, u, diag(t, s), vt).GetData(), data)
+		}
+		k := s.GetShape()[0]
+		if !engine.AllClose(mustDot(t, mustTranspose(t, u), u), eye(t, k), 1e-9, 1e-12) {
+			t.Errorf("%v: U is not orthonormal", shape)
+		}
+		if !engine.AllClose(mustDot(t, vt, mustTranspose(t, vt)), eye(t, k), 1e-9, 1e-12) {
+			t.Errorf("%v: V is not orthonormal", shape)
+		}
+		sd := s.GetData()
+		for i := 1; i < len(sd); i++ {
+			if sd[i] > sd[i-1] || sd[i] < 0 {
+				t.Errorf("%v: singular values %v are not non-negative and decreasing", shape, sd)
+			}
+		}
+	}
+
+	// Rank deficient: U must still have orthonormal columns
+	a := mustTensor(t, []float64{1, 2, 2, 4, 3, 6}, []int{3, 2})
+	u, s, vt, err := linalg.SVD(a)
+	if err != nil {
+		t.Fatalf("SVD returned error: %v", err)
+	}
+	if !engine.AllClose(mustDot(t, u, diag(t, s), vt), a, 1e-9, 1e-12) {
+		t.Errorf("U . S . Vt = %v, want %v", mustDot(t, u, diag(t, s), vt).GetData(), a.GetData())
+	}
+	if !engine.AllClose(mustDot(t, mustTranspose(t, u), u), eye(t, 2), 1e-9, 1e-12) {
+		t.Errorf("U of rank deficient matrix is not orthonormal: %v", u.GetData())
+	}
+	if math.Abs(s.GetData()[0]-math.Sqrt(70)) > 1e-9 || math.Abs(s.GetData()[1]) > 1e-9 {
+		t.Errorf("singular values = %v, want [sqrt(70) 0]", s.GetData())
+	}
+}
+
+func TestEigSym(t *testing.T) {
+	a := mustTensor(t, []float64{2, -1, 0, -1, 2, -1, 0, -1, 2}, []int{3, 3})
+	values, vectors, err := linalg.EigSym(a)
+	if err != nil {
+		t.Fatalf("EigSym returned error: %v", err)
+	}
+	want := mustTensor(t, []float64{2 - math.Sqrt2, 2, 2 + math.Sqrt2}, []int{3})
+	if !engine.AllClose(values, want, 1e-9, 1e-12) {
+		t.Errorf("eigenvalues = %v, want %v", values.GetData(), want.GetData())
+	}
+	// a . V = V . diag(values)
+	if !engine.AllClose(mustDot(t, a, vectors), mustDot(t, vectors, diag(t, values)), 1e-9, 1e-12) {
+		t.Errorf("eigenvectors %v do not match eigenvalues %v", vectors.GetData(), values.GetData())
+	}
+	if !engine.AllClose(mustDot(t, mustTranspose(t, vectors), vectors), eye(t, 3), 1e-9, 1e-12) {
+		t.Errorf("eigenvectors are not orthonormal: %v", vectors.GetData())
+	}
+}
+
+func TestPinvLstSq(t *testing.T) {
+	a := mustTensor(t, []float64{1, 1, 1, 2, 1, 3}, []int{3, 2})
+	pinv, err := linalg.Pinv(a, -1)
+	if err != nil {
+		t.Fatalf("Pinv returned error: %v", err)
+	}
+	if !engine.AllClose(mustDot(t, a, pinv, a), a, 1e-9, 1e-12) {
+		t.Errorf("A . Pinv(A) . A = %v, want %v", mustDot(t, a, pinv, a).GetData(), a.GetData())
+	}
+	if !engine.AllClose(mustDot(t, pinv, a), eye(t, 2), 1e-9, 1e-12) {
+		t.Errorf("Pinv(A) . A = %v, want identity", mustDot(t, pinv, a).GetData())
+	}
+
+	// Fit a line through (1, 2), (2, 3), (3, 4.5): intercept 2/3, slope 5/4
+	b := mustTensor(t, []float64{2, 3, 4.5}, []int{3})
+	x, err := linalg.LstSq(a, b)
+	if err != nil {
+		t.Fatalf("LstSq returned error: %v", err)
+	}
+	want := mustTensor(t, []float64{2.0 / 3, 1.25}, []int{2})
+	if !engine.AllClose(x, want, 1e-9, 1e-12) {
+		t.Errorf("LstSq = %v, want %v", x.GetData(), want.GetData())
+	}
+
+	// Underdetermined: minimum norm solution
+	w := mustTensor(t, []float64{1, 1}, []int{1, 2})
+	x, err = linalg.LstSq(w, mustTensor(t, []float64{2}, []int{1}))
+	if err != nil {
+		t.Fatalf("LstSq returned error: %v", err)
+	}
+	if !engine.AllClose(x, mustTensor(t, []float64{1, 1}, []int{2}), 1e-9, 1e-12) {
+		t.Errorf("LstSq = %v, want [1 1]", x.GetData())
+	}
+}
+
+func TestNorm(t *testing.T) {
+	m := mustTensor(t, []float64{1, -2, 3, -4}, []int{2, 2})
+	v := mustTensor(t, []float64{3, -4}, []int{2})
+	tests := []struct {
+		tensor *engine.Tensor
+		ord    string
+		want   float64
+	}{
+		{m, "", math.Sqrt(30)},
+		{m, "fro", math.Sqrt(30)},
+		{m, "1", 6},
+		{m, "inf", 7},
+		{m, "2", math.Sqrt(15 + math.Sqrt(221))},
+		{m, "nuc", math.Sqrt(15+math.Sqrt(221)) + math.Sqrt(15-math.Sqrt(221))},
+		{v, "", 5},
+		{v, "1", 7},
+		{v, "inf", 4},
+		{v, "2", 5},
+	}
+	for _, tt := range tests {
+		got, err := linalg.Norm(tt.tensor, tt.ord)
+		if err != nil {
+			t.Fatalf("Norm(%v, %q) returned error: %v", tt.tensor.GetShape(), tt.ord, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Norm(%v, %q) = %v, want %v", tt.tensor.GetShape(), tt.ord, got, tt.want)
+		}
+	}
+
+	if _, err := linalg.Norm(m, "3"); err == nil {
+		t.Error("Expected error for unsupported norm")
+	}
+	cube := mustTensor(t, make([]float64, 8), []int{2, 2, 2})
+	if _, err := linalg.Norm(cube, "1"); err == nil {
+		t.Error("Expected error for matrix norm of 3-D tensor")
+	}
+}
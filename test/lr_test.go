@@ -0,0 +1,263 @@
+package test
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+	"github.com/conacts/goten/nn/lr"
+)
+
+// learningRates returns the learning rates a scheduler sets on an SGD optimizer
+// with base learning rate base at steps 0 to n-1.
+func learningRates(t *testing.T, schedule lr.Schedule, err error, base float64, n int) []float64 {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("schedule returned error: %v", err)
+	}
+	opt := nn.NewSGD(nil, base)
+	s, err := lr.NewScheduler(opt, schedule)
+	if err != nil {
+		t.Fatalf("NewScheduler returned error: %v", err)
+	}
+	rates := []float64{opt.GetLearningRate()}
+	for i := 1; i < n; i++ {
+		if err := s.Step(); err != nil {
+			t.Fatalf("Step returned error: %v", err)
+		}
+		rates = append(rates, opt.GetLearningRate())
+	}
+	return rates
+}
+
+func TestDecaySchedules(t *testing.T) {
+	s, err := lr.StepLR(2, 0.5)
+	if got, want := learningRates(t, s, err, 1, 6), []float64{1, 1, 0.5, 0.5, 0.25, 0.25}; !almostEqual(got, want, 1e-12) {
+		t.Errorf("StepLR = %v, want %v", got, want)
+	}
+	s, err = lr.MultiStepLR([]int{1, 4}, 0.1)
+	if got, want := learningRates(t, s, err, 2, 6), []float64{2, 0.2, 0.2, 0.2, 0.02, 0.02}; !almostEqual(got, want, 1e-12) {
+		t.Errorf("MultiStepLR = %v, want %v", got, want)
+	}
+	s, err = lr.ExponentialLR(0.5)
+	if got, want := learningRates(t, s, err, 1, 4), []float64{1, 0.5, 0.25, 0.125}; !almostEqual(got, want, 1e-12) {
+		t.Errorf("ExponentialLR = %v, want %v", got, want)
+	}
+}
+
+func TestCosineSchedules(t *testing.T) {
+	s, err := lr.CosineAnnealingLR(4, 0.2)
+	mid := 0.2 + 0.8*(1+math.Cos(math.Pi/4))/2
+	if got, want := learningRates(t, s, err, 1, 7), []float64{1, mid, 0.6, 0.2 + 0.8*(1+math.Cos(3*math.Pi/4))/2, 0.2, 0.2, 0.2}; !almostEqual(got, want, 1e-12) {
+		t.Errorf("CosineAnnealingLR = %v, want %v", got, want)
+	}
+
+	// Periods of 2, 4 and 8 steps restarting at steps 2 and 6
+	s, err = lr.CosineAnnealingWarmRestarts(2, 2, 0)
+	got := learningRates(t, s, err, 1, 10)
+	want := []float64{1, 0.5, 1, (1 + math.Cos(math.Pi/4)) / 2, 0.5, (1 + math.Cos(3*math.Pi/4)) / 2, 1, (1 + math.Cos(math.Pi/8)) / 2, (1 + math.Cos(math.Pi/4)) / 2, (1 + math.Cos(3*math.Pi/8)) / 2}
+	if !almostEqual(got, want, 1e-12) {
+		t.Errorf("CosineAnnealingWarmRestarts = %v, want %v", got, want)
+	}
+	s, err = lr.CosineAnnealingWarmRestarts(3, 1, 0)
+	if got := learningRates(t, s, err, 1, 7); got[3] != 1 || got[6] != 1 || got[4] != got[1] {
+		t.Errorf("CosineAnnealingWarmRestarts with fixed periods = %v", got)
+	}
+}
+
+func TestLinearWarmup(t *testing.T) {
+	decay, _ := lr.ExponentialLR(0.5)
+	s, err := lr.LinearWarmup(4, 0.2, decay)
+	if got, want := learningRates(t, s, err, 1, 7), []float64{0.2, 0.4, 0.6, 0.8, 1, 0.5, 0.25}; !almostEqual(got, want, 1e-12) {
+		t.Errorf("LinearWarmup = %v, want %v", got, want)
+	}
+	s, err = lr.LinearWarmup(2, 0, nil)
+	if got, want := learningRates(t, s, err, 3, 4), []float64{0, 1.5, 3, 3}; !almostEqual(got, want, 1e-12) {
+		t.Errorf("LinearWarmup without schedule after = %v, want %v", got, want)
+	}
+}
+
+func TestOneCycleLR(t *testing.T) {
+	s, err := lr.OneCycleLR(lr.DefaultOneCycleOptions(1, 11))
+	rates := learningRates(t, s, err, 123, 13)
+	// Peak at step 0.3 * 11 - 1 = 2.3, so the learning rate rises until step 3
+	if math.Abs(rates[0]-1.0/25) > 1e-12 {
+		t.Errorf("initial learning rate = %v, want 0.04", rates[0])
+	}
+	for i := 1; i < len(rates); i++ {
+		rising := i <= 3
+		if rising && !(rates[i] > rates[i-1]) || !rising && i <= 10 && !(rates[i] < rates[i-1]) {
+			t.Errorf("learning rates %v not rising then falling at step %d", rates, i)
+			break
+		}
+	}
+	if peak := rates[3]; peak < 0.95 || peak > 1 {
+		t.Errorf("peak learning rate = %v, want close to 1", peak)
+	}
+	if final := 1.0 / 25 / 1e4; rates[10] != final || rates[12] != final {
+		t.Errorf("final learning rates = %v, want %v", rates[10:], final)
+	}
+}
+
+func TestReduceLROnPlateau(t *testing.T) {
+	opt := nn.NewSGD(nil, 1)
+	opts := lr.DefaultPlateauOptions()
+	opts.Patience = 1
+	opts.Cooldown = 1
+	opts.MinLR = 0.005
+	p, err := lr.NewReduceLROnPlateau(opt, opts)
+	if err != nil {
+		t.Fatalf("NewReduceLROnPlateau returned error: %v", err)
+	}
+	losses := []float64{1, 0.5, 0.5, 0.49999, 0.6, 0.4, 0.4, 0.4, 0.4, 0.4, 0.4}
+	want := []float64{1, 1, 1, 0.1, 0.1, 0.1, 0.1, 0.01, 0.01, 0.01, 0.005}
+	for i, loss := range losses {
+		if err := p.Step(loss); err != nil {
+			t.Fatalf("Step returned error: %v", err)
+		}
+		if got := opt.GetLearningRate(); math.Abs(got-want[i]) > 1e-12 {
+			t.Errorf("learning rate after loss %d = %v, want %v", i, got, want[i])
+		}
+	}
+	if p.Best() != 0.4 {
+		t.Errorf("Best() = %v, want 0.4", p.Best())
+	}
+	if err := p.Step(math.NaN()); err == nil {
+		t.Error("Expected error for NaN metric")
+	}
+
+	opts = lr.DefaultPlateauOptions()
+	opts.Mode = lr.Max
+	opts.Patience = 0
+	acc, _ := lr.NewReduceLROnPlateau(opt, opts)
+	acc.Step(0.5)
+	acc.Step(0.7)
+	if opt.GetLearningRate() != 0.005 {
+		t.Errorf("improving accuracy changed the learning rate to %v", opt.GetLearningRate())
+	}
+	acc.Step(0.6)
+	if math.Abs(opt.GetLearningRate()-0.0005) > 1e-15 {
+		t.Errorf("worse accuracy left the learning rate at %v", opt.GetLearningRate())
+	}
+}
+
+func TestScheduleInvalidArguments(t *testing.T) {
+	errs := map[string]error{}
+	_, errs["StepLR step size"] = lr.StepLR(0, 0.5)
+	_, errs["MultiStepLR milestones"] = lr.MultiStepLR([]int{3, 2}, 0.5)
+	_, errs["ExponentialLR gamma"] = lr.ExponentialLR(0)
+	_, errs["CosineAnnealingLR tMax"] = lr.CosineAnnealingLR(0, 0)
+	_, errs["CosineAnnealingWarmRestarts tMult"] = lr.CosineAnnealingWarmRestarts(2, 0, 0)
+	_, errs["LinearWarmup start factor"] = lr.LinearWarmup(2, 1.5, nil)
+	_, errs["OneCycleLR steps"] = lr.OneCycleLR(lr.DefaultOneCycleOptions(1, 1))
+	_, errs["NewScheduler schedule"] = lr.NewScheduler(nn.NewSGD(nil, 1), nil)
+	plateau := lr.DefaultPlateauOptions()
+	plateau.Factor = 1
+	_, errs["ReduceLROnPlateau factor"] = lr.NewReduceLROnPlateau(nn.NewSGD(nil, 1), plateau)
+	for name, err := range errs {
+		if err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+// trainingRun is a model trained by Adam under a cosine schedule with warmup and
+// a plateau scheduler.
+type trainingRun struct {
+	net       *nn.MLP
+	opt       *nn.Adam
+	scheduler *lr.Scheduler
+	plateau   *lr.ReduceLROnPlateau
+}
+
+func newTrainingRun(t *testing.T, seed int64) *trainingRun {
+	net, err := nn.NewMLP([]int{2, 4, 1}, nn.MLPOptions{Hidden: nn.Tanh, Generator: engine.NewGenerator(seed)})
+	if err != nil {
+		t.Fatalf("NewMLP returned error: %v", err)
+	}
+	opts := nn.DefaultAdamOptions()
+	opts.LearningRate = 0.05
+	opt, err := nn.NewAdam(net.Parameters(), opts)
+	if err != nil {
+		t.Fatalf("NewAdam returned error: %v", err)
+	}
+	cosine, _ := lr.CosineAnnealingLR(20, 0.001)
+	schedule, _ := lr.LinearWarmup(3, 0.1, cosine)
+	scheduler, err := lr.NewScheduler(opt, schedule)
+	if err != nil {
+		t.Fatalf("NewScheduler returned error: %v", err)
+	}
+	plateau, _ := lr.NewReduceLROnPlateau(opt, lr.DefaultPlateauOptions())
+	return &trainingRun{net, opt, scheduler, plateau}
+}
+
+func (r *trainingRun) components() map[string]nn.Stateful {
+	return map[string]nn.Stateful{"optimizer": r.opt, "scheduler": r.scheduler, "plateau": r.plateau}
+}
+
+func (r *trainingRun) train(t *testing.T, x, y *engine.Tensor, steps int) {
+	for i := 0; i < steps; i++ {
+		r.opt.ZeroGrad()
+		out, _ := r.net.Forward(x)
+		rows, _ := nn.MSE(out, y)
+		loss, _ := engine.Mean(rows, nil, false)
+		if err := loss.Backward(); err != nil {
+			t.Fatalf("Backward returned error: %v", err)
+		}
+		if err := r.opt.Step(); err != nil {
+			t.Fatalf("Step returned error: %v", err)
+		}
+		if err := r.scheduler.Step(); err != nil {
+			t.Fatalf("scheduler Step returned error: %v", err)
+		}
+		if err := r.plateau.Step(loss.GetData()[0]); err != nil {
+			t.Fatalf("plateau Step returned error: %v", err)
+		}
+	}
+}
+
+func TestCheckpointResumesTraining(t *testing.T) {
+	x, _ := engine.Normal(engine.NewGenerator(14), []int{6, 2}, 0, 1)
+	y, _ := engine.Normal(engine.NewGenerator(15), []int{6, 1}, 0, 1)
+
+	run := newTrainingRun(t, 16)
+	run.train(t, x, y, 5)
+	var buf bytes.Buffer
+	if err := nn.SaveCheckpoint(&buf, run.net, run.components()); err != nil {
+		t.Fatalf("SaveCheckpoint returned error: %v", err)
+	}
+
+	// A run started from other weights picks up exactly where the first one stopped
+	resumed := newTrainingRun(t, 17)
+	if err := nn.LoadCheckpoint(bytes.NewReader(buf.Bytes()), resumed.net, resumed.components()); err != nil {
+		t.Fatalf("LoadCheckpoint returned error: %v", err)
+	}
+	if resumed.scheduler.Steps() != 5 || resumed.opt.GetLearningRate() != run.opt.GetLearningRate() {
+		t.Errorf("resumed at step %d with learning rate %v, want step 5 with %v", resumed.scheduler.Steps(), resumed.opt.GetLearningRate(), run.opt.GetLearningRate())
+	}
+	run.train(t, x, y, 5)
+	resumed.train(t, x, y, 5)
+	for i, p := range run.net.Parameters() {
+		if !reflect.DeepEqual(p.GetData(), resumed.net.Parameters()[i].GetData()) {
+			t.Errorf("parameter %d diverged after resuming: %v, want %v", i, resumed.net.Parameters()[i].GetData(), p.GetData())
+		}
+	}
+	if resumed.plateau.Best() != run.plateau.Best() {
+		t.Errorf("plateau best = %v, want %v", resumed.plateau.Best(), run.plateau.Best())
+	}
+
+	// The model must match the saved one
+	other, _ := nn.NewMLP([]int{2, 3, 1}, nn.DefaultMLPOptions())
+	if err := nn.LoadCheckpoint(bytes.NewReader(buf.Bytes()), other, nil); err == nil {
+		t.Error("Expected error loading a checkpoint into a model of other shape")
+	}
+	if err := nn.LoadCheckpoint(bytes.NewReader(buf.Bytes()), nil, map[string]nn.Stateful{"missing": run.opt}); err == nil {
+		t.Error("Expected error for missing component")
+	}
+	if err := nn.SaveCheckpoint(&buf, nil, map[string]nn.Stateful{"a.b": run.opt}); err == nil {
+		t.Error("Expected error for component name with a dot")
+	}
+}
@@ -0,0 +1,171 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+// numericGrad estimates the gradient of sum(f(x)) with central differences.
+func numericGrad(f func(*engine.Tensor) (*engine.Tensor, error), data []float64, shape []int) []float64 {
+	const h = 1e-6
+	grad := make([]float64, len(data))
+	for i := range data {
+		eval := func(delta float64) float64 {
+			shifted := append([]float64(nil), data...)
+			shifted[i] += delta
+			x, _ := engine.NewTensor(shifted, shape)
+			y, _ := f(x)
+			total := 0.0
+			for _, v := range y.GetData() {
+				total += v
+			}
+			return total
+		}
+		grad[i] = (eval(h) - eval(-h)) / (2 * h)
+	}
+	return grad
+}
+
+func TestUnaryMathOps(t *testing.T) {
+	data := []float64{0.3, 0.9, 1.7, 2.4}
+	tests := []struct {
+		name string
+		op   func(*engine.Tensor) (*engine.Tensor, error)
+		ref  func(float64) float64
+	}{
+		{"Log", engine.Log, math.Log},
+		{"Log1p", engine.Log1p, math.Log1p},
+		{"Sqrt", engine.Sqrt, math.Sqrt},
+		{"Rsqrt", engine.Rsqrt, func(x float64) float64 { return 1 / math.Sqrt(x) }},
+		{"Reciprocal", engine.Reciprocal, func(x float64) float64 { return 1 / x }},
+		{"Tanh", engine.Tanh, math.Tanh},
+		{"Sin", engine.Sin, math.Sin},
+		{"Cos", engine.Cos, math.Cos},
+		{"Softplus", engine.Softplus, func(x float64) float64 { return math.Log(1 + math.Exp(x)) }},
+		{"Abs", engine.Abs, math.Abs},
+		{"PowScalar", func(x *engine.Tensor) (*engine.Tensor, error) { return engine.PowScalar(x, 2.5) },
+			func(x float64) float64 { return math.Pow(x, 2.5) }},
+		{"Clamp", func(x *engine.Tensor) (*engine.Tensor, error) { return engine.Clamp(x, 0.5, 2) },
+			func(x float64) float64 { return math.Min(math.Max(x, 0.5), 2) }},
+	}
+	for _, tt := range tests {
+		x, _ := engine.NewTensor(append([]float64(nil), data...), []int{2, 2})
+		x.SetRequiresGrad(true)
+		y, err := tt.op(x)
+		if err != nil {
+			t.Errorf("%s returned error: %v", tt.name, err)
+			continue
+		}
+		want := make([]float64, len(data))
+		for i, v := range data {
+			want[i] = tt.ref(v)
+		}
+		if !almostEqual(y.GetData(), want, 1e-12) {
+			t.Errorf("%s = %v, want %v", tt.name, y.GetData(), want)
+		}
+
+		loss, _ := engine.Sum(y, nil, false)
+		if err := loss.Backward(); err != nil {
+			t.Errorf("%s backward failed: %v", tt.name, err)
+			continue
+		}
+		expected := numericGrad(tt.op, data, []int{2, 2})
+		if !almostEqual(x.GetGrad().GetData(), expected, 1e-5) {
+			t.Errorf("%s gradient = %v, want %v", tt.name, x.GetGrad().GetData(), expected)
+		}
+	}
+}
+
+func TestSignAndSoftplusStability(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{-2, 0, 3}, []int{3})
+	s, _ := engine.Sign(x)
+	if !almostEqual(s.GetData(), []float64{-1, 0, 1}, 0) {
+		t.Errorf("Sign() = %v", s.GetData())
+	}
+	big, _ := engine.NewTensor([]float64{1000, -1000}, []int{2})
+	sp, _ := engine.Softplus(big)
+	if !almostEqual(sp.GetData(), []float64{1000, 0}, 1e-12) {
+		t.Errorf("Softplus() overflowed: %v", sp.GetData())
+	}
+	if _, err := engine.Clamp(x, 1, 0); err == nil {
+		t.Error("Expected error for empty clamp range")
+	}
+	if _, err := engine.Log(nil); err == nil {
+		t.Error("Expected error for nil tensor")
+	}
+}
+
+func TestBinaryMathOps(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 4, 2, 3}, []int{2, 2})
+	b, _ := engine.NewTensor([]float64{2, 3}, []int{2})
+	a.SetRequiresGrad(true)
+	b.SetRequiresGrad(true)
+
+	q, err := engine.Div(a, b)
+	if err != nil {
+		t.Fatalf("Div returned error: %v", err)
+	}
+	if !almostEqual(q.GetData(), []float64{0.5, 4.0 / 3, 1, 1}, 1e-12) {
+		t.Errorf("Div() = %v", q.GetData())
+	}
+	loss, _ := engine.Sum(q, nil, false)
+	loss.Backward()
+	if !almostEqual(a.GetGrad().GetData(), []float64{0.5, 1.0 / 3, 0.5, 1.0 / 3}, 1e-12) {
+		t.Errorf("Div() grad for a = %v", a.GetGrad().GetData())
+	}
+	// d/db sum(a / b) = -sum over rows of a / b^2
+	if !almostEqual(b.GetGrad().GetData(), []float64{-3.0 / 4, -7.0 / 9}, 1e-12) {
+		t.Errorf("Div() grad for b = %v", b.GetGrad().GetData())
+	}
+
+	a.ZeroGrad()
+	b.ZeroGrad()
+	p, _ := engine.Pow(a, b)
+	if !almostEqual(p.GetData(), []float64{1, 64, 4, 27}, 1e-12) {
+		t.Errorf("Pow() = %v", p.GetData())
+	}
+	loss, _ = engine.Sum(p, nil, false)
+	loss.Backward()
+	if !almostEqual(a.GetGrad().GetData(), []float64{2, 48, 4, 27}, 1e-12) {
+		t.Errorf("Pow() grad for base = %v", a.GetGrad().GetData())
+	}
+	wantB := []float64{4 * math.Log(2), 64*math.Log(4) + 27*math.Log(3)}
+	if !almostEqual(b.GetGrad().GetData(), wantB, 1e-12) {
+		t.Errorf("Pow() grad for exponent = %v, want %v", b.GetGrad().GetData(), wantB)
+	}
+}
+
+func TestMaximumMinimum(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 5, 3, math.NaN()}, []int{4})
+	b, _ := engine.NewTensor([]float64{2, 4, 3, 0}, []int{4})
+	a.SetRequiresGrad(true)
+	b.SetRequiresGrad(true)
+
+	mx, _ := engine.Maximum(a, b)
+	got := mx.GetData()
+	if got[0] != 2 || got[1] != 5 || got[2] != 3 || !math.IsNaN(got[3]) {
+		t.Errorf("Maximum() = %v", got)
+	}
+	loss, _ := engine.Sum(mx, nil, false)
+	loss.Backward()
+	if !almostEqual(a.GetGrad().GetData(), []float64{0, 1, 0.5, 1}, 0) {
+		t.Errorf("Maximum() grad for a = %v", a.GetGrad().GetData())
+	}
+	if !almostEqual(b.GetGrad().GetData(), []float64{1, 0, 0.5, 0}, 0) {
+		t.Errorf("Maximum() grad for b = %v", b.GetGrad().GetData())
+	}
+
+	mn, _ := engine.Minimum(a, b)
+	if got := mn.GetData(); got[0] != 1 || got[1] != 4 || got[2] != 3 || !math.IsNaN(got[3]) {
+		t.Errorf("Minimum() = %v", got)
+	}
+
+	i, _ := engine.NewInt64Tensor([]int64{1, 7}, []int{2})
+	j, _ := engine.NewInt64Tensor([]int64{3, 2}, []int{2})
+	im, _ := engine.Maximum(i, j)
+	if im.GetDType() != engine.Int64 || !almostEqual(im.GetData(), []float64{3, 7}, 0) {
+		t.Errorf("Maximum(int64) = %v %v", im.GetDType(), im.GetData())
+	}
+}
@@ -0,0 +1,233 @@
+package test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+// naiveDot is the original element-by-element implementation of engine.Dot,
+// kept as a reference for correctness tests and benchmarks.
+func naiveDot(t1, t2 *engine.Tensor) (*engine.Tensor, error) {
+	shape := []int{t1.GetShape()[0], t2.GetShape()[1]}
+	result, err := engine.NewZeroTensor(shape)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < shape[0]; i++ {
+		for j := 0; j < shape[1]; j++ {
+			sum := 0.0
+			for k := 0; k < t1.GetShape()[1]; k++ {
+				v1, err1 := t1.GetValue([]int{i, k})
+				if err1 != nil {
+					return nil, err1
+				}
+				v2, err2 := t2.GetValue([]int{k, j})
+				if err2 != nil {
+					return nil, err2
+				}
+				sum += v1 * v2
+			}
+			result.SetValue(sum, []int{i, j})
+		}
+	}
+	return result, nil
+}
+
+func randomMatrix(rng *rand.Rand, rows, cols int) *engine.Tensor {
+	data := make([]float64, rows*cols)
+	for i := range data {
+		data[i] = rng.Float64()*2 - 1
+	}
+	t, _ := engine.NewTensor(data, []int{rows, cols})
+	return t
+}
+
+func TestDotMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// Sizes straddle the tile sizes and the parallel threshold
+	sizes := [][3]int{{1, 1, 1}, {3, 5, 2}, {17, 65, 9}, {70, 130, 300}, {129, 64, 257}}
+	for _, threads := range []int{1, 4} {
+		prev := engine.SetNumThreads(threads)
+		for _, s := range sizes {
+			m, k, n := s[0], s[1], s[2]
+			a := randomMatrix(rng, m, k)
+			b := randomMatrix(rng, k, n)
+			bT := randomMatrix(rng, n, k)
+			aT := randomMatrix(rng, k, m)
+
+			// Plain operands and transposed views exercise every kernel path
+			bView, _ := engine.Transpose(bT)
+			aView, _ := engine.Transpose(aT)
+			cases := []struct {
+				name   string
+				t1, t2 *engine.Tensor
+			}{
+				{"plain", a, b},
+				{"transposed rhs", a, bView},
+				{"transposed lhs", aView, b},
+				{"both transposed", aView, bView},
+			}
+			for _, c := range cases {
+				got, err := engine.Dot(c.t1, c.t2)
+				if err != nil {
+					t.Fatalf("Dot() returned error: %v", err)
+				}
+				want, _ := naiveDot(c.t1, c.t2)
+				if !almostEqual(got.GetData(), want.GetData(), 1e-9) {
+					t.Errorf("Dot() %s %v with %d threads does not match reference", c.name, s, threads)
+				}
+			}
+		}
+		engine.SetNumThreads(prev)
+	}
+}
+
+func TestDotGradientTransposedView(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	w, _ := engine.NewTensor([]float64{1, 0, 2, 1, 0, 3}, []int{2, 3})
+	x.SetRequiresGrad(true)
+	w.SetRequiresGrad(true)
+
+	// x . w^T with w^T as a view
+	wT, _ := engine.Transpose(w)
+	z, _ := engine.Dot(x, wT)
+	loss, _ := engine.Sum(z, nil, false)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	// d/dx = ones . w, d/dw = ones^T . x
+	expectedX := []float64{2, 0, 5, 2, 0, 5}
+	expectedW := []float64{5, 7, 9, 5, 7, 9}
+	if !almostEqual(x.GetGrad().GetData(), expectedX, 1e-12) {
+		t.Errorf("unexpected grad for x: got %v, want %v", x.GetGrad().GetData(), expectedX)
+	}
+	if !almostEqual(w.GetGrad().GetData(), expectedW, 1e-12) {
+		t.Errorf("unexpected grad for w: got %v, want %v", w.GetGrad().GetData(), expectedW)
+	}
+}
+
+func benchmarkSizes() []int {
+	return []int{32, 128, 256}
+}
+
+func BenchmarkDotNaive(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range benchmarkSizes() {
+		x, y := randomMatrix(rng, n, n), randomMatrix(rng, n, n)
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naiveDot(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkDot(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range benchmarkSizes() {
+		x, y := randomMatrix(rng, n, n), randomMatrix(rng, n, n)
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				engine.Dot(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkDotSingleThread(b *testing.B) {
+	prev := engine.SetNumThreads(1)
+	defer engine.SetNumThreads(prev)
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range benchmarkSizes() {
+		x, y := randomMatrix(rng, n, n), randomMatrix(rng, n, n)
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				engine.Dot(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkDotTransposedRHS(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range benchmarkSizes() {
+		x, y := randomMatrix(rng, n, n), randomMatrix(rng, n, n)
+		yT, _ := engine.Transpose(y)
+		b.Run(fmt.Sprintf("%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				engine.Dot(x, yT)
+			}
+		})
+	}
+}
+
+func TestBatchMatMul(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	a, _ := engine.NewRandomTensor([]int{3, 4, 5})
+	b, _ := engine.NewRandomTensor([]int{3, 5, 2})
+	out, err := engine.BatchMatMul(a, b)
+	if err != nil {
+		t.Fatalf("BatchMatMul() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(out.GetShape(), []int{3, 4, 2}) {
+		t.Fatalf("Expected shape [3 4 2], got %v", out.GetShape())
+	}
+	for i := 0; i < 3; i++ {
+		ai, _ := engine.Narrow(a, 0, i, 1)
+		bi, _ := engine.Narrow(b, 0, i, 1)
+		oi, _ := engine.Narrow(out, 0, i, 1)
+		ai, _ = engine.Squeeze(ai, 0)
+		bi, _ = engine.Squeeze(bi, 0)
+		want, _ := naiveDot(ai, bi)
+		if !almostEqual(oi.GetData(), want.GetData(), 1e-12) {
+			t.Errorf("BatchMatMul() batch %d does not match reference", i)
+		}
+	}
+
+	// A 2-D operand is broadcast against every matrix of the batch
+	w := randomMatrix(rng, 5, 2)
+	shared, err := engine.BatchMatMul(a, w)
+	if err != nil {
+		t.Fatalf("BatchMatMul() returned error: %v", err)
+	}
+	a1, _ := engine.Narrow(a, 0, 1, 1)
+	a1, _ = engine.Squeeze(a1, 0)
+	want, _ := naiveDot(a1, w)
+	got, _ := engine.Narrow(shared, 0, 1, 1)
+	if !almostEqual(got.GetData(), want.GetData(), 1e-12) {
+		t.Error("BatchMatMul() with broadcast rhs does not match reference")
+	}
+
+	if _, err := engine.BatchMatMul(a, a); err == nil {
+		t.Error("Expected error for mismatched inner dimensions")
+	}
+	c, _ := engine.NewRandomTensor([]int{2, 5, 2})
+	if _, err := engine.BatchMatMul(a, c); err == nil {
+		t.Error("Expected error for incompatible batch dimensions")
+	}
+}
+
+func TestBatchMatMulGradientBroadcast(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8}, []int{2, 2, 2})
+	w, _ := engine.NewTensor([]float64{1, 0, 2, 1}, []int{1, 2, 2})
+	x.SetRequiresGrad(true)
+	w.SetRequiresGrad(true)
+	z, _ := engine.BatchMatMul(x, w)
+	loss, _ := engine.Sum(z, nil, false)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	// d/dx = ones . w^T for each batch, d/dw sums x^T . ones over the batch
+	expectedX := []float64{1, 3, 1, 3, 1, 3, 1, 3}
+	expectedW := []float64{16, 16, 20, 20}
+	if !almostEqual(x.GetGrad().GetData(), expectedX, 1e-12) {
+		t.Errorf("unexpected grad for x: got %v, want %v", x.GetGrad().GetData(), expectedX)
+	}
+	if !reflect.DeepEqual(w.GetGrad().GetShape(), []int{1, 2, 2}) || !almostEqual(w.GetGrad().GetData(), expectedW, 1e-12) {
+		t.Errorf("unexpected grad for w: got %v %v, want %v", w.GetGrad().GetShape(), w.GetGrad().GetData(), expectedW)
+	}
+}
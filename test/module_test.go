@@ -0,0 +1,188 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+)
+
+// double is a module without a hand-written backward pass.
+type double struct {
+	training bool
+}
+
+func (d *double) Forward(x *engine.Tensor) (*engine.Tensor, error) {
+	return engine.Scale(x, 2)
+}
+
+func (d *double) Backward(dout *engine.Tensor) (*engine.Tensor, error) {
+	return nil, nn.ErrNoBackward
+}
+
+func (d *double) Parameters() []*engine.Tensor         { return nil }
+func (d *double) NamedParameters() []nn.NamedParameter { return nil }
+func (d *double) NamedChildren() []nn.NamedModule      { return nil }
+func (d *double) Train(training bool)                  { d.training = training }
+func (d *double) IsTraining() bool                     { return d.training }
+
+func newTestSequential(t *testing.T) *nn.Sequential {
+	l1, err := nn.NewLinearLayer(3, 4)
+	if err != nil {
+		t.Fatalf("failed to create linear layer: %v", err)
+	}
+	l2, err := nn.NewLinearLayer(4, 2)
+	if err != nil {
+		t.Fatalf("failed to create linear layer: %v", err)
+	}
+	seq, err := nn.NewSequential(l1, l2)
+	if err != nil {
+		t.Fatalf("NewSequential returned error: %v", err)
+	}
+	return seq
+}
+
+func TestSequentialForward(t *testing.T) {
+	seq := newTestSequential(t)
+	x, _ := engine.Normal(engine.NewGenerator(3), []int{5, 3}, 0, 1)
+
+	out, err := seq.Forward(x)
+	if err != nil {
+		t.Fatalf("Forward returned error: %v", err)
+	}
+	hidden, _ := seq.Get(0).Forward(x)
+	want, _ := seq.Get(1).Forward(hidden)
+	if !engine.AllClose(out, want, 0, 0) {
+		t.Errorf("Forward = %v, want %v", out, want)
+	}
+
+	var names []string
+	for _, p := range seq.NamedParameters() {
+		names = append(names, p.Name)
+	}
+	if want := []string{"0.weight", "0.bias", "1.weight", "1.bias"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("NamedParameters names = %v, want %v", names, want)
+	}
+	if got := len(seq.Parameters()); got != 4 {
+		t.Errorf("len(Parameters()) = %d, want 4", got)
+	}
+	if children := seq.NamedChildren(); len(children) != 2 || children[1].Name != "1" || children[1].Module != seq.Get(1) {
+		t.Errorf("NamedChildren = %v", children)
+	}
+
+	if _, err := nn.NewSequential(nil); err == nil {
+		t.Error("Expected error for nil module")
+	}
+}
+
+func TestSequentialBackwardMatchesAutograd(t *testing.T) {
+	seq := newTestSequential(t)
+	x, _ := engine.Normal(engine.NewGenerator(4), []int{5, 3}, 0, 1)
+	dout, _ := engine.Normal(engine.NewGenerator(5), []int{5, 2}, 0, 1)
+
+	// Autograd through the recorded graph
+	x.SetRequiresGrad(true)
+	out, err := seq.Forward(x)
+	if err != nil {
+		t.Fatalf("Forward returned error: %v", err)
+	}
+	weighted, _ := engine.Mul(out, dout)
+	loss, _ := engine.Sum(weighted, nil, false)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	params := seq.NamedParameters()
+	want := make([][]float64, len(params))
+	for i, p := range params {
+		want[i] = p.Tensor.GetGrad().GetData()
+	}
+	wantDx := x.GetGrad().GetData()
+
+	// Hand-written backward passes
+	nn.ZeroGrad(seq)
+	if _, err := seq.Forward(x); err != nil {
+		t.Fatalf("Forward returned error: %v", err)
+	}
+	dx, err := seq.Backward(dout)
+	if err != nil {
+		t.Fatalf("Sequential.Backward returned error: %v", err)
+	}
+	if !almostEqual(dx.GetData(), wantDx, 1e-12) {
+		t.Errorf("input gradient = %v, want %v", dx.GetData(), wantDx)
+	}
+	for i, p := range params {
+		if !almostEqual(p.Tensor.GetGrad().GetData(), want[i], 1e-12) {
+			t.Errorf("gradient of %s = %v, want %v", p.Name, p.Tensor.GetGrad().GetData(), want[i])
+		}
+	}
+
+	// The first gradient accumulated owns its memory
+	fresh := newTestSequential(t)
+	if _, err := fresh.Forward(x); err != nil {
+		t.Fatalf("Forward returned error: %v", err)
+	}
+	if _, err := fresh.Backward(dout); err != nil {
+		t.Fatalf("Sequential.Backward returned error: %v", err)
+	}
+	weight := fresh.Parameters()[0]
+	if &weight.GetGrad().GetData()[0] == &weight.GetWeightGrads().GetData()[0] {
+		t.Error("gradient shares memory with the weight gradients of the layer")
+	}
+	if &weight.GetGrad().GetShape()[0] == &weight.GetShape()[0] {
+		t.Error("gradient shares its shape with the parameter")
+	}
+}
+
+func TestSequentialNoBackward(t *testing.T) {
+	l, _ := nn.NewLinearLayer(2, 2)
+	seq, _ := nn.NewSequential(l, &double{})
+	x, _ := engine.NewTensor([]float64{1, 2}, []int{1, 2})
+	out, err := seq.Forward(x)
+	if err != nil {
+		t.Fatalf("Forward returned error: %v", err)
+	}
+	if _, err := seq.Backward(out); !errors.Is(err, nn.ErrNoBackward) {
+		t.Errorf("Backward error = %v, want ErrNoBackward", err)
+	}
+
+	// Autograd still reaches the parameters through the module
+	loss, _ := engine.Sum(out, nil, false)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward returned error: %v", err)
+	}
+	if g := l.GetBiases().GetGrad(); g == nil || !reflect.DeepEqual(g.GetData(), []float64{2, 2}) {
+		t.Errorf("bias gradient = %v, want [2 2]", g)
+	}
+}
+
+func TestSequentialTrainEval(t *testing.T) {
+	inner := &double{}
+	nested, _ := nn.NewSequential(inner)
+	seq := newTestSequential(t)
+	if err := seq.Append(nested); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	if !seq.IsTraining() || !inner.IsTraining() {
+		t.Error("modules should start in training mode")
+	}
+
+	nn.Eval(seq)
+	for _, c := range seq.NamedChildren() {
+		if c.Module.IsTraining() {
+			t.Errorf("child %s is still training after Eval", c.Name)
+		}
+	}
+	if inner.IsTraining() {
+		t.Error("Eval did not reach nested modules")
+	}
+
+	seq.Train(true)
+	if !inner.IsTraining() {
+		t.Error("Train did not reach nested modules")
+	}
+	if err := seq.Append(nil); err == nil {
+		t.Error("Expected error appending nil module")
+	}
+}
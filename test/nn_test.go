@@ -1,6 +1,7 @@
 package test
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -111,6 +112,27 @@ func TestLinearLayer_GetSetBiases(t *testing.T) {
 	}
 }
 
+func TestLinearLayer_ForwardBatch(t *testing.T) {
+	ll, err := nn.NewLinearLayer(2, 3)
+	if err != nil {
+		t.Fatalf("failed to create linear layer: %v", err)
+	}
+	w, _ := engine.NewTensor([]float64{1, 0, 1, 0, 1, 1}, []int{2, 3})
+	b, _ := engine.NewTensor([]float64{1, 2, 3}, []int{1, 3})
+	ll.SetWeights(w)
+	ll.SetBiases(b)
+
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	out, err := ll.Forward(x)
+	if err != nil {
+		t.Fatalf("failed to compute forward pass: %v", err)
+	}
+	expected, _ := engine.NewTensor([]float64{2, 4, 6, 4, 6, 10}, []int{2, 3})
+	if !out.Equals(expected) {
+		t.Errorf("unexpected output: got %v, want %v", out, expected)
+	}
+}
+
 // WRITE FOR REAL PREDICTED OUTPUT
 /*
 func TestLinearLayer_Forward(t *testing.T) {
@@ -152,3 +174,40 @@ func TestLinearLayer_Forward(t *testing.T) {
 	}
 }
 */
+
+func TestLogLoss(t *testing.T) {
+	pred, _ := engine.NewTensor([]float64{0.9, 0.2, 0.6, 0.4}, []int{4, 1})
+	y, _ := engine.NewTensor([]float64{1, 0, 1, 0}, []int{4, 1})
+	pred.SetRequiresGrad(true)
+
+	loss, err := nn.LogLoss(pred, y)
+	if err != nil {
+		t.Fatalf("LogLoss() returned error: %v", err)
+	}
+	want := -(math.Log(0.9) + math.Log(0.8) + math.Log(0.6) + math.Log(0.6)) / 4
+	if !reflect.DeepEqual(loss.GetShape(), []int{1, 1}) || math.Abs(loss.GetData()[0]-want) > 1e-12 {
+		t.Errorf("LogLoss() = %v %v, want [1 1] %v", loss.GetShape(), loss.GetData(), want)
+	}
+
+	// d/dp = (p - y) / (p (1 - p)) / n
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+	expected := []float64{-1 / 0.9 / 4, 1 / 0.8 / 4, -1 / 0.6 / 4, 1 / 0.6 / 4}
+	if !almostEqual(pred.GetGrad().GetData(), expected, 1e-12) {
+		t.Errorf("Unexpected LogLoss gradient %v, want %v", pred.GetGrad().GetData(), expected)
+	}
+}
+
+func TestMSE(t *testing.T) {
+	pred, _ := engine.NewTensor([]float64{1, 2, 3, 5}, []int{2, 2})
+	y, _ := engine.NewTensor([]float64{0, 0, 1, 1}, []int{2, 2})
+	loss, err := nn.MSE(pred, y)
+	if err != nil {
+		t.Fatalf("MSE() returned error: %v", err)
+	}
+	// One mean per row: (1 + 4) / 2 and (4 + 16) / 2
+	if !reflect.DeepEqual(loss.GetShape(), []int{2, 1}) || !reflect.DeepEqual(loss.GetData(), []float64{2.5, 10}) {
+		t.Errorf("MSE() = %v %v, want [2 1] [2.5 10]", loss.GetShape(), loss.GetData())
+	}
+}
@@ -175,18 +175,16 @@ func TestTranspose2D(t *testing.T) {
 		t.Errorf("Expected %v, but got %v", expected1, res1)
 	}
 
-	/*
-		// Test transpose of 3x3 matrix
-		t2, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9}, []int{3, 3})
-		expected2, _ := engine.NewTensor([]float64{1, 4, 7, 2, 5, 8, 3, 6, 9}, []int{3, 3})
-		res2, err := engine.Transpose(t2)
-		if err != nil {
-			t.Errorf("Unexpected error: %v", err)
-		}
-		if !res2.Equals(expected2) {
-			t.Errorf("Expected %v, but got %v", expected2, res2)
-		}
-	*/
+	// Test transpose of 3x3 matrix
+	t2, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9}, []int{3, 3})
+	expected2, _ := engine.NewTensor([]float64{1, 4, 7, 2, 5, 8, 3, 6, 9}, []int{3, 3})
+	res2, err := engine.Transpose(t2)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !res2.Equals(expected2) {
+		t.Errorf("Expected %v, but got %v", expected2, res2)
+	}
 
 	// Test transpose of non-square matrix
 	t3, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
@@ -218,5 +216,85 @@ func TestSquare(t *testing.T) {
 	}
 }
 
+func TestBroadcastShapes(t *testing.T) {
+	tests := []struct {
+		s1, s2   []int
+		expected []int
+		wantErr  bool
+	}{
+		{[]int{2, 3}, []int{2, 3}, []int{2, 3}, false},
+		{[]int{2, 3}, []int{1, 3}, []int{2, 3}, false},
+		{[]int{2, 3}, []int{3}, []int{2, 3}, false},
+		{[]int{4, 1, 3}, []int{2, 1}, []int{4, 2, 3}, false},
+		{[]int{1}, []int{5, 4}, []int{5, 4}, false},
+		{[]int{2, 2}, []int{1, 3}, nil, true},
+		{[]int{3, 2}, []int{3}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := engine.BroadcastShapes(tt.s1, tt.s2)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("BroadcastShapes(%v, %v) error = %v, wantErr %v", tt.s1, tt.s2, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("BroadcastShapes(%v, %v) = %v, want %v", tt.s1, tt.s2, got, tt.expected)
+		}
+	}
+}
+
+func TestAddBroadcast(t *testing.T) {
+	// Bias row added to every row of a batch
+	t1, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	t2, _ := engine.NewTensor([]float64{10, 20, 30}, []int{1, 3})
+	expected, _ := engine.NewTensor([]float64{11, 22, 33, 14, 25, 36}, []int{2, 3})
+	result, err := engine.Add(t1, t2)
+	if err != nil {
+		t.Fatalf("Error adding broadcast tensors: %v", err)
+	}
+	if !result.Equals(expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+
+	// Column vector against row vector produces an outer sum
+	col, _ := engine.NewTensor([]float64{1, 2}, []int{2, 1})
+	row, _ := engine.NewTensor([]float64{10, 20, 30}, []int{3})
+	expected, _ = engine.NewTensor([]float64{11, 21, 31, 12, 22, 32}, []int{2, 3})
+	result, err = engine.Add(col, row)
+	if err != nil {
+		t.Fatalf("Error adding broadcast tensors: %v", err)
+	}
+	if !result.Equals(expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+}
+
+func TestMulSubBroadcast(t *testing.T) {
+	t1, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	scalar, _ := engine.NewTensor([]float64{2}, []int{1})
+	expected, _ := engine.NewTensor([]float64{2, 4, 6, 8, 10, 12}, []int{2, 3})
+	result, err := engine.Mul(t1, scalar)
+	if err != nil {
+		t.Fatalf("Error multiplying broadcast tensors: %v", err)
+	}
+	if !result.Equals(expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+
+	col, _ := engine.NewTensor([]float64{1, 4}, []int{2, 1})
+	expected, _ = engine.NewTensor([]float64{0, 1, 2, 0, 1, 2}, []int{2, 3})
+	result, err = engine.Sub(t1, col)
+	if err != nil {
+		t.Fatalf("Error subtracting broadcast tensors: %v", err)
+	}
+	if !result.Equals(expected) {
+		t.Errorf("Expected %v but got %v", expected, result)
+	}
+
+	bad, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	if _, err := engine.Sub(t1, bad); err == nil {
+		t.Error("Expected error subtracting tensors with incompatible shapes but got none")
+	}
+}
+
 // Write tests for
 // mean, min, max, neg, sub
@@ -0,0 +1,280 @@
+package test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+)
+
+// param returns a parameter holding data with its gradient set to grad.
+func param(data, grad []float64) *engine.Tensor {
+	p, _ := engine.NewTensor(append([]float64(nil), data...), []int{len(data)})
+	p.SetRequiresGrad(true)
+	if grad != nil {
+		g, _ := engine.NewTensor(append([]float64(nil), grad...), []int{len(grad)})
+		p.SetGrad(g)
+	}
+	return p
+}
+
+func TestOptimizerFirstSteps(t *testing.T) {
+	sqrtEps := math.Sqrt(1e-6)
+	tests := []struct {
+		name string
+		make func(p *engine.Tensor) (nn.Optimizer, error)
+		want []float64 // Values after each step with a constant gradient of 0.5 from 1
+	}{
+		{"SGD", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewSGD([]*engine.Tensor{p}, 0.1), nil
+		}, []float64{0.95, 0.9}},
+		{"SGD momentum", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewSGDWithOptions([]*engine.Tensor{p}, nn.SGDOptions{LearningRate: 0.1, Momentum: 0.9})
+		}, []float64{0.95, 0.95 - 0.1*0.95}},
+		{"SGD Nesterov", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewSGDWithOptions([]*engine.Tensor{p}, nn.SGDOptions{LearningRate: 0.1, Momentum: 0.9, Nesterov: true})
+		}, []float64{1 - 0.1*0.95, 1 - 0.1*0.95 - 0.1*(0.5+0.9*0.95)}},
+		{"SGD weight decay", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewSGDWithOptions([]*engine.Tensor{p}, nn.SGDOptions{LearningRate: 0.1, WeightDecay: 0.2})
+		}, []float64{1 - 0.1*0.7}},
+		{"Adam", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewAdam([]*engine.Tensor{p}, nn.AdamOptions{LearningRate: 0.1, Beta1: 0.9, Beta2: 0.999})
+		}, []float64{0.9, 0.8}},
+		{"AdamW", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewAdamW([]*engine.Tensor{p}, nn.AdamOptions{LearningRate: 0.1, Beta1: 0.9, Beta2: 0.999, WeightDecay: 0.5})
+		}, []float64{0.95 - 0.1, (0.95-0.1)*0.95 - 0.1}},
+		{"RMSProp", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewRMSProp([]*engine.Tensor{p}, nn.RMSPropOptions{LearningRate: 0.01, Alpha: 0.99})
+		}, []float64{1 - 0.1, 0.9 - 0.01/math.Sqrt(0.0199)}},
+		{"Adagrad", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewAdagrad([]*engine.Tensor{p}, nn.AdagradOptions{LearningRate: 0.1})
+		}, []float64{0.9, 0.9 - 0.1/math.Sqrt(2)}},
+		{"Adadelta", func(p *engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewAdadelta([]*engine.Tensor{p}, nn.AdadeltaOptions{LearningRate: 1, Rho: 0.9, Eps: 1e-6})
+		}, []float64{1 - sqrtEps/math.Sqrt(0.025+1e-6)*0.5}},
+	}
+	for _, tt := range tests {
+		p := param([]float64{1}, []float64{0.5})
+		opt, err := tt.make(p)
+		if err != nil {
+			t.Errorf("%s: constructor returned error: %v", tt.name, err)
+			continue
+		}
+		for step, want := range tt.want {
+			if err := opt.Step(); err != nil {
+				t.Fatalf("%s: Step returned error: %v", tt.name, err)
+			}
+			if got := p.GetData()[0]; math.Abs(got-want) > 1e-9 {
+				t.Errorf("%s: value after step %d = %v, want %v", tt.name, step+1, got, want)
+			}
+		}
+	}
+}
+
+func TestOptimizersMinimize(t *testing.T) {
+	params := func() []*engine.Tensor { return []*engine.Tensor{param([]float64{3, -2, 0.5}, nil)} }
+	target, _ := engine.NewTensor([]float64{1, 2, -1}, []int{3})
+	adam := nn.DefaultAdamOptions()
+	adam.LearningRate = 0.05
+	rmsprop := nn.DefaultRMSPropOptions()
+	rmsprop.LearningRate = 0.02
+	adagrad := nn.DefaultAdagradOptions()
+	adagrad.LearningRate = 0.5
+	makers := map[string]func(p []*engine.Tensor) (nn.Optimizer, error){
+		"SGD": func(p []*engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewSGDWithOptions(p, nn.SGDOptions{LearningRate: 0.05, Momentum: 0.9, Nesterov: true})
+		},
+		"Adam":    func(p []*engine.Tensor) (nn.Optimizer, error) { return nn.NewAdam(p, adam) },
+		"AdamW":   func(p []*engine.Tensor) (nn.Optimizer, error) { return nn.NewAdamW(p, adam) },
+		"RMSProp": func(p []*engine.Tensor) (nn.Optimizer, error) { return nn.NewRMSProp(p, rmsprop) },
+		"Adagrad": func(p []*engine.Tensor) (nn.Optimizer, error) { return nn.NewAdagrad(p, adagrad) },
+		"Adadelta": func(p []*engine.Tensor) (nn.Optimizer, error) {
+			return nn.NewAdadelta(p, nn.AdadeltaOptions{LearningRate: 10, Rho: 0.9, Eps: 1e-6})
+		},
+	}
+	for name, newOptimizer := range makers {
+		p := params()
+		opt, err := newOptimizer(p)
+		if err != nil {
+			t.Fatalf("%s: constructor returned error: %v", name, err)
+		}
+		for i := 0; i < 500; i++ {
+			opt.ZeroGrad()
+			diff, _ := engine.Sub(p[0], target)
+			sq, _ := engine.Square(diff)
+			loss, _ := engine.Sum(sq, nil, false)
+			if err := loss.Backward(); err != nil {
+				t.Fatalf("%s: Backward returned error: %v", name, err)
+			}
+			if err := opt.Step(); err != nil {
+				t.Fatalf("%s: Step returned error: %v", name, err)
+			}
+		}
+		if !almostEqual(p[0].GetData(), target.GetData(), 0.05) {
+			t.Errorf("%s converged to %v, want %v", name, p[0].GetData(), target.GetData())
+		}
+	}
+}
+
+func TestOptimizerSkipsParametersWithoutGrad(t *testing.T) {
+	withGrad := param([]float64{1, 1}, []float64{1, 2})
+	without := param([]float64{5}, nil)
+	opt, _ := nn.NewAdam([]*engine.Tensor{without, withGrad}, nn.DefaultAdamOptions())
+	if err := opt.Step(); err != nil {
+		t.Fatalf("Step returned error: %v", err)
+	}
+	if without.GetData()[0] != 5 {
+		t.Errorf("parameter without gradient changed to %v", without.GetData())
+	}
+	if withGrad.GetData()[0] == 1 {
+		t.Error("parameter with gradient was not updated")
+	}
+	if _, ok := opt.State().Tensors["0.exp_avg"]; ok {
+		t.Error("state allocated for parameter without gradient")
+	}
+
+	opt.ZeroGrad()
+	if !reflect.DeepEqual(withGrad.GetGrad().GetData(), []float64{0, 0}) {
+		t.Errorf("ZeroGrad left gradient %v", withGrad.GetGrad().GetData())
+	}
+}
+
+func TestOptimizerState(t *testing.T) {
+	run := func(opt nn.Optimizer, p *engine.Tensor, steps int) {
+		for i := 0; i < steps; i++ {
+			g, _ := engine.NewTensor([]float64{0.3 * float64(i+1), -0.2}, []int{2})
+			p.SetGrad(g)
+			if err := opt.Step(); err != nil {
+				t.Fatalf("Step returned error: %v", err)
+			}
+		}
+	}
+	opts := nn.RMSPropOptions{LearningRate: 0.01, Alpha: 0.9, Momentum: 0.5, Centered: true}
+	p1 := param([]float64{1, 2}, nil)
+	opt1, _ := nn.NewRMSProp([]*engine.Tensor{p1}, opts)
+	run(opt1, p1, 3)
+	opt1.SetLearningRate(0.02)
+	state := opt1.State()
+	if state.Scalars["lr"] != 0.02 || state.Scalars["steps"] != 3 || len(state.Tensors) != 3 {
+		t.Fatalf("State() = %v", state)
+	}
+
+	// A fresh optimizer over a copy of the parameters continues identically
+	p2 := param(p1.GetData(), nil)
+	opt2, _ := nn.NewRMSProp([]*engine.Tensor{p2}, opts)
+	if err := opt2.LoadState(state); err != nil {
+		t.Fatalf("LoadState returned error: %v", err)
+	}
+	if opt2.GetLearningRate() != 0.02 {
+		t.Errorf("loaded learning rate = %v, want 0.02", opt2.GetLearningRate())
+	}
+	run(opt1, p1, 2)
+	run(opt2, p2, 2)
+	if !reflect.DeepEqual(p1.GetData(), p2.GetData()) {
+		t.Errorf("restored optimizer diverged: %v, want %v", p2.GetData(), p1.GetData())
+	}
+
+	// State is a copy
+	state.Tensors["0.square_avg"].SetData([]float64{100, 100})
+	if reflect.DeepEqual(opt1.State().Tensors["0.square_avg"].GetData(), []float64{100, 100}) {
+		t.Error("State() shares buffers with the optimizer")
+	}
+
+	bad := []nn.State{
+		{Tensors: map[string]*engine.Tensor{"square_avg": state.Tensors["0.square_avg"]}},
+		{Tensors: map[string]*engine.Tensor{"1.square_avg": state.Tensors["0.square_avg"]}},
+		{Tensors: map[string]*engine.Tensor{"0.square_avg": param([]float64{1, 2, 3}, nil)}},
+	}
+	for _, s := range bad {
+		if err := opt2.LoadState(s); err == nil {
+			t.Errorf("Expected error loading %v", s.Tensors)
+		}
+	}
+}
+
+func TestOptimizerStepIsAtomic(t *testing.T) {
+	first := param([]float64{1, 2}, []float64{1, 1})
+	view, _ := engine.Slice(first, 0, 1, 2, 1)
+	bad := param([]float64{3, 4}, []float64{1})
+	opt := nn.NewSGD([]*engine.Tensor{first, bad}, 0.5)
+	if err := opt.Step(); err == nil {
+		t.Fatal("Expected error for gradient of the wrong size")
+	}
+	if !reflect.DeepEqual(first.GetData(), []float64{1, 2}) || opt.Steps() != 0 {
+		t.Errorf("failed step left parameter %v after %d steps", first.GetData(), opt.Steps())
+	}
+
+	// The update is written into the buffer the view shares
+	opt = nn.NewSGD([]*engine.Tensor{first}, 0.5)
+	if err := opt.Step(); err != nil {
+		t.Fatalf("Step returned error: %v", err)
+	}
+	if !reflect.DeepEqual(view.GetData(), []float64{1.5}) {
+		t.Errorf("view of the parameter = %v, want [1.5]", view.GetData())
+	}
+
+	// NewSGD cannot fail, so Step reports invalid arguments
+	if err := nn.NewSGD([]*engine.Tensor{first}, math.NaN()).Step(); err == nil {
+		t.Error("Expected error for NaN learning rate")
+	}
+	if err := nn.NewSGD([]*engine.Tensor{nil}, 0.1).Step(); err == nil {
+		t.Error("Expected error for nil parameter")
+	}
+}
+
+func TestOptimizerInvalidOptions(t *testing.T) {
+	params := []*engine.Tensor{param([]float64{1}, nil)}
+	adam := nn.DefaultAdamOptions()
+	adam.Beta1 = 1
+	rmsprop := nn.DefaultRMSPropOptions()
+	rmsprop.Alpha = -0.1
+	adadelta := nn.DefaultAdadeltaOptions()
+	adadelta.Rho = 1.5
+	errs := map[string]error{}
+	_, errs["negative learning rate"] = nn.NewSGDWithOptions(params, nn.SGDOptions{LearningRate: -1})
+	_, errs["Nesterov without momentum"] = nn.NewSGDWithOptions(params, nn.SGDOptions{LearningRate: 0.1, Nesterov: true})
+	_, errs["beta1 of 1"] = nn.NewAdam(params, adam)
+	_, errs["negative alpha"] = nn.NewRMSProp(params, rmsprop)
+	_, errs["negative epsilon"] = nn.NewAdagrad(params, nn.AdagradOptions{LearningRate: 0.1, Eps: -1})
+	_, errs["rho above 1"] = nn.NewAdadelta(params, adadelta)
+	_, errs["nil parameter"] = nn.NewAdam([]*engine.Tensor{nil}, nn.DefaultAdamOptions())
+	for name, err := range errs {
+		if err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+func TestSGDWithHandWrittenBackward(t *testing.T) {
+	net, err := nn.NewMLP([]int{2, 3, 1}, nn.MLPOptions{Hidden: nn.Tanh})
+	if err != nil {
+		t.Fatalf("NewMLP returned error: %v", err)
+	}
+	x, _ := engine.Normal(engine.NewGenerator(12), []int{8, 2}, 0, 1)
+	y, _ := engine.Normal(engine.NewGenerator(13), []int{8, 1}, 0, 1)
+	opt := nn.NewSGD(net.Parameters(), 0.1)
+
+	mse := func() float64 {
+		out, _ := net.Forward(x)
+		rows, _ := nn.MSE(out, y)
+		loss, _ := engine.Mean(rows, nil, false)
+		return loss.GetData()[0]
+	}
+	before := mse()
+	for i := 0; i < 50; i++ {
+		opt.ZeroGrad()
+		out, _ := net.Forward(x)
+		dout, _ := nn.Backward(out, y)
+		if _, err := net.Backward(dout); err != nil {
+			t.Fatalf("Backward returned error: %v", err)
+		}
+		if err := opt.Step(); err != nil {
+			t.Fatalf("Step returned error: %v", err)
+		}
+	}
+	if after := mse(); !(after < before) {
+		t.Errorf("MSE went from %v to %v", before, after)
+	}
+}
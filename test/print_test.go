@@ -0,0 +1,101 @@
+package test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestTensorString(t *testing.T) {
+	vector, _ := engine.NewTensor([]float64{1, -2.5, 3}, []int{3})
+	matrix, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	cube, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8}, []int{2, 2, 2})
+	transposed, _ := engine.Transpose(matrix)
+	ints, _ := engine.NewInt64Tensor([]int64{1, -20, 300}, []int{3})
+	bools, _ := engine.NewBoolTensor([]bool{true, false}, []int{2})
+	special, _ := engine.NewTensor([]float64{math.NaN(), math.Inf(1), math.Inf(-1), 1}, []int{4})
+	scalar, _ := engine.NewTensor([]float64{7}, []int{})
+
+	tests := []struct {
+		name   string
+		tensor *engine.Tensor
+		want   string
+	}{
+		{"vector", vector, "[ 1.0000, -2.5000,  3.0000]"},
+		{"matrix", matrix, "[[1.0000, 2.0000, 3.0000],\n [4.0000, 5.0000, 6.0000]]"},
+		{"cube", cube, "[[[1.0000, 2.0000],\n  [3.0000, 4.0000]],\n\n [[5.0000, 6.0000],\n  [7.0000, 8.0000]]]"},
+		{"transposed view", transposed, "[[1.0000, 4.0000],\n [2.0000, 5.0000],\n [3.0000, 6.0000]]"},
+		{"int64", ints, "[  1, -20, 300]"},
+		{"bool", bools, "[ true, false]"},
+		{"nan and inf", special, "[   nan,    inf,   -inf, 1.0000]"},
+		{"scalar", scalar, "7.0000"},
+	}
+	for _, tt := range tests {
+		if got := tt.tensor.String(); got != tt.want {
+			t.Errorf("%s: String() =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+
+	var nilTensor *engine.Tensor
+	if got := nilTensor.String(); got != "<nil>" {
+		t.Errorf("nil String() = %q, want <nil>", got)
+	}
+}
+
+func TestPrintOptions(t *testing.T) {
+	prev := engine.SetPrintOptions(engine.PrintOptions{Precision: 2, Threshold: 10, EdgeItems: 2, LineWidth: 75})
+	defer engine.SetPrintOptions(prev)
+	if got := engine.GetPrintOptions().Precision; got != 2 {
+		t.Errorf("GetPrintOptions().Precision = %d, want 2", got)
+	}
+
+	data := make([]float64, 20)
+	for i := range data {
+		data[i] = float64(i)
+	}
+	long, _ := engine.NewTensor(data, []int{20})
+	if got, want := long.String(), "[ 0.00,  1.00, ..., 18.00, 19.00]"; got != want {
+		t.Errorf("summarised vector = %q, want %q", got, want)
+	}
+	grid, _ := engine.NewTensor(data, []int{5, 4})
+	if got, want := grid.String(), "[[ 0.00,  1.00,  2.00,  3.00],\n [ 4.00,  5.00,  6.00,  7.00],\n ...,\n [12.00, 13.00, 14.00, 15.00],\n [16.00, 17.00, 18.00, 19.00]]"; got != want {
+		t.Errorf("summarised matrix =\n%s\nwant\n%s", got, want)
+	}
+
+	wide, _ := engine.NewTensor(data[:8], []int{8})
+	got := wide.Format(engine.PrintOptions{Precision: 1, Threshold: 1000, EdgeItems: 3, LineWidth: 20})
+	if want := "[0.0, 1.0, 2.0, 3.0,\n 4.0, 5.0, 6.0, 7.0]"; got != want {
+		t.Errorf("wrapped vector =\n%s\nwant\n%s", got, want)
+	}
+	for _, line := range strings.Split(got, "\n") {
+		if len(line) > 20 {
+			t.Errorf("line %q is longer than the line width", line)
+		}
+	}
+}
+
+func TestPrintScientific(t *testing.T) {
+	opts := engine.DefaultPrintOptions()
+	large, _ := engine.NewTensor([]float64{1e9, 2}, []int{2})
+	if got, want := large.Format(opts), "[1.0000e+09, 2.0000e+00]"; got != want {
+		t.Errorf("large values = %q, want %q", got, want)
+	}
+	small, _ := engine.NewTensor([]float64{1e-5, 0}, []int{2})
+	if got, want := small.Format(opts), "[1.0000e-05, 0.0000e+00]"; got != want {
+		t.Errorf("small values = %q, want %q", got, want)
+	}
+	regular, _ := engine.NewTensor([]float64{0.5, 20}, []int{2})
+	if got, want := regular.Format(opts), "[ 0.5000, 20.0000]"; got != want {
+		t.Errorf("regular values = %q, want %q", got, want)
+	}
+	opts.Sci = engine.SciNever
+	if got, want := large.Format(opts), "[1000000000.0000,          2.0000]"; got != want {
+		t.Errorf("SciNever = %q, want %q", got, want)
+	}
+	opts.Sci = engine.SciAlways
+	if got, want := regular.Format(opts), "[5.0000e-01, 2.0000e+01]"; got != want {
+		t.Errorf("SciAlways = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestGeneratorReproducible(t *testing.T) {
+	a, _ := engine.Normal(engine.NewGenerator(7), []int{2, 3}, 0, 1)
+	b, _ := engine.Normal(engine.NewGenerator(7), []int{2, 3}, 0, 1)
+	if !reflect.DeepEqual(a.GetData(), b.GetData()) {
+		t.Errorf("Generators with the same seed gave %v and %v", a.GetData(), b.GetData())
+	}
+	c, _ := engine.Normal(engine.NewGenerator(8), []int{2, 3}, 0, 1)
+	if reflect.DeepEqual(a.GetData(), c.GetData()) {
+		t.Error("Generators with different seeds gave the same tensor")
+	}
+
+	g := engine.NewGenerator(7)
+	first, _ := engine.Uniform(g, []int{4}, 0, 1)
+	g.Seed(7)
+	second, _ := engine.Uniform(g, []int{4}, 0, 1)
+	if !reflect.DeepEqual(first.GetData(), second.GetData()) {
+		t.Errorf("Reseeding gave %v and %v", first.GetData(), second.GetData())
+	}
+
+	engine.ManualSeed(3)
+	r1, _ := engine.NewRandomTensor([]int{3})
+	engine.ManualSeed(3)
+	r2, _ := engine.NewRandomTensor([]int{3})
+	if !reflect.DeepEqual(r1.GetData(), r2.GetData()) {
+		t.Errorf("ManualSeed did not make NewRandomTensor reproducible: %v and %v", r1.GetData(), r2.GetData())
+	}
+}
+
+func TestUniformNormal(t *testing.T) {
+	g := engine.NewGenerator(1)
+	u, err := engine.Uniform(g, []int{100, 100}, -2, 3)
+	if err != nil {
+		t.Fatalf("Uniform returned error: %v", err)
+	}
+	mean := 0.0
+	for _, v := range u.GetData() {
+		if v < -2 || v >= 3 {
+			t.Fatalf("Uniform sample %v out of [-2, 3)", v)
+		}
+		mean += v / 10000
+	}
+	if math.Abs(mean-0.5) > 0.05 {
+		t.Errorf("Uniform mean = %v, want about 0.5", mean)
+	}
+
+	n, err := engine.Normal(g, []int{10000}, 1, 2)
+	if err != nil {
+		t.Fatalf("Normal returned error: %v", err)
+	}
+	mean, variance := 0.0, 0.0
+	for _, v := range n.GetData() {
+		mean += v / 10000
+	}
+	for _, v := range n.GetData() {
+		variance += (v - mean) * (v - mean) / 10000
+	}
+	if math.Abs(mean-1) > 0.1 || math.Abs(variance-4) > 0.3 {
+		t.Errorf("Normal mean and variance = %v, %v, want about 1, 4", mean, variance)
+	}
+
+	if _, err := engine.Uniform(g, []int{2}, 1, 1); err == nil {
+		t.Error("Expected error for empty uniform range")
+	}
+	if _, err := engine.Normal(g, []int{2}, 0, -1); err == nil {
+		t.Error("Expected error for negative standard deviation")
+	}
+	if _, err := engine.Normal(g, []int{0, 2}, 0, 1); err == nil {
+		t.Error("Expected error for invalid shape")
+	}
+}
+
+func TestTruncatedNormal(t *testing.T) {
+	g := engine.NewGenerator(2)
+	tn, err := engine.TruncatedNormal(g, []int{1000}, 0, 1, -0.5, 2)
+	if err != nil {
+		t.Fatalf("TruncatedNormal returned error: %v", err)
+	}
+	for _, v := range tn.GetData() {
+		if v < -0.5 || v > 2 {
+			t.Fatalf("TruncatedNormal sample %v out of [-0.5, 2]", v)
+		}
+	}
+
+	// Bounds far in the tail must not hang
+	tail, err := engine.TruncatedNormal(g, []int{10}, 0, 1, 6, 7)
+	if err != nil {
+		t.Fatalf("TruncatedNormal returned error: %v", err)
+	}
+	for _, v := range tail.GetData() {
+		if v < 6 || v > 7 {
+			t.Errorf("TruncatedNormal sample %v out of [6, 7]", v)
+		}
+	}
+
+	if _, err := engine.TruncatedNormal(g, []int{2}, 0, 1, 1, -1); err == nil {
+		t.Error("Expected error for empty truncation range")
+	}
+}
+
+func TestBernoulli(t *testing.T) {
+	g := engine.NewGenerator(3)
+	p, _ := engine.NewTensor([]float64{0, 1, 0.5}, []int{1, 3})
+	counts := make([]float64, 3)
+	for i := 0; i < 1000; i++ {
+		b, err := engine.Bernoulli(g, p)
+		if err != nil {
+			t.Fatalf("Bernoulli returned error: %v", err)
+		}
+		if !reflect.DeepEqual(b.GetShape(), []int{1, 3}) {
+			t.Fatalf("Bernoulli shape = %v, want [1 3]", b.GetShape())
+		}
+		for j, v := range b.GetData() {
+			counts[j] += v
+		}
+	}
+	if counts[0] != 0 || counts[1] != 1000 || math.Abs(counts[2]-500) > 60 {
+		t.Errorf("Bernoulli counts = %v, want [0 1000 ~500]", counts)
+	}
+
+	bad, _ := engine.NewTensor([]float64{1.5}, []int{1})
+	if _, err := engine.Bernoulli(g, bad); err == nil {
+		t.Error("Expected error for probability above 1")
+	}
+}
+
+func TestMultinomial(t *testing.T) {
+	g := engine.NewGenerator(4)
+	probs, _ := engine.NewTensor([]float64{1, 0, 3}, []int{3})
+	m, err := engine.Multinomial(g, probs, 4000, true)
+	if err != nil {
+		t.Fatalf("Multinomial returned error: %v", err)
+	}
+	if m.GetDType() != engine.Int64 || !reflect.DeepEqual(m.GetShape(), []int{4000}) {
+		t.Fatalf("Multinomial = %v %v, want int64 [4000]", m.GetDType(), m.GetShape())
+	}
+	counts := make([]int, 3)
+	for _, v := range m.GetInt64Data() {
+		counts[v]++
+	}
+	if counts[1] != 0 || math.Abs(float64(counts[2])/4000-0.75) > 0.03 {
+		t.Errorf("Multinomial counts = %v, want about [1000 0 3000]", counts)
+	}
+
+	rows, _ := engine.NewTensor([]float64{1, 1, 1, 1, 0, 0, 5, 1}, []int{2, 4})
+	w, err := engine.Multinomial(g, rows, 2, false)
+	if err != nil {
+		t.Fatalf("Multinomial returned error: %v", err)
+	}
+	if !reflect.DeepEqual(w.GetShape(), []int{2, 2}) {
+		t.Fatalf("Multinomial shape = %v, want [2 2]", w.GetShape())
+	}
+	d := w.GetInt64Data()
+	if d[0] == d[1] {
+		t.Errorf("Multinomial without replacement drew %d twice", d[0])
+	}
+	second := []int64{d[2], d[3]}
+	sort.Slice(second, func(i, j int) bool { return second[i] < second[j] })
+	if !reflect.DeepEqual(second, []int64{2, 3}) {
+		t.Errorf("Multinomial drew %v from row with weights [0 0 5 1]", second)
+	}
+
+	if _, err := engine.Multinomial(g, rows, 3, false); err == nil {
+		t.Error("Expected error for too many samples without replacement")
+	}
+	zero, _ := engine.NewTensor([]float64{0, 0}, []int{2})
+	if _, err := engine.Multinomial(g, zero, 1, true); err == nil {
+		t.Error("Expected error for weights summing to zero")
+	}
+}
+
+func TestRandPerm(t *testing.T) {
+	p, err := engine.RandPerm(engine.NewGenerator(5), 10)
+	if err != nil {
+		t.Fatalf("RandPerm returned error: %v", err)
+	}
+	values := append([]int64(nil), p.GetInt64Data()...)
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for i, v := range values {
+		if v != int64(i) {
+			t.Fatalf("RandPerm = %v is not a permutation", p.GetInt64Data())
+		}
+	}
+	if _, err := engine.RandPerm(nil, 0); err == nil {
+		t.Error("Expected error for empty permutation")
+	}
+}
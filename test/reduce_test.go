@@ -0,0 +1,152 @@
+package test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestSumAxes(t *testing.T) {
+	// shape [2, 2, 3]
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, []int{2, 2, 3})
+
+	tests := []struct {
+		name     string
+		axes     []int
+		keepdims bool
+		data     []float64
+		shape    []int
+	}{
+		{"all", nil, false, []float64{78}, []int{}},
+		{"all keepdims", nil, true, []float64{78}, []int{1, 1, 1}},
+		{"axis 0", []int{0}, false, []float64{8, 10, 12, 14, 16, 18}, []int{2, 3}},
+		{"axis 1 keepdims", []int{1}, true, []float64{5, 7, 9, 17, 19, 21}, []int{2, 1, 3}},
+		{"last axis", []int{-1}, false, []float64{6, 15, 24, 33}, []int{2, 2}},
+		{"axes 0 and 2", []int{0, 2}, false, []float64{30, 48}, []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := engine.Sum(x, tt.axes, tt.keepdims)
+			if err != nil {
+				t.Fatalf("Sum() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got.GetData(), tt.data) {
+				t.Errorf("Sum() data = %v, want %v", got.GetData(), tt.data)
+			}
+			if !reflect.DeepEqual(got.GetShape(), tt.shape) {
+				t.Errorf("Sum() shape = %v, want %v", got.GetShape(), tt.shape)
+			}
+		})
+	}
+
+	if _, err := engine.Sum(x, []int{3}, false); err == nil {
+		t.Error("expected error for out of range axis")
+	}
+	if _, err := engine.Sum(x, []int{1, -2}, false); err == nil {
+		t.Error("expected error for duplicate axes")
+	}
+}
+
+func TestSumCols(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, []int{2, 2, 3})
+	got, err := engine.SumCols(x)
+	if err != nil {
+		t.Fatalf("SumCols() returned error: %v", err)
+	}
+	expected, _ := engine.NewTensor([]float64{22, 26, 30}, []int{1, 3})
+	if !got.Equals(expected) {
+		t.Errorf("SumCols() = %v, want %v", got.GetData(), expected.GetData())
+	}
+}
+
+func TestMeanMaxMin(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 5, 3, 4, 2, 6}, []int{2, 3})
+
+	mean, _ := engine.Mean(x, []int{1}, true)
+	if !reflect.DeepEqual(mean.GetData(), []float64{3, 4}) || !reflect.DeepEqual(mean.GetShape(), []int{2, 1}) {
+		t.Errorf("Mean() = %v with shape %v", mean.GetData(), mean.GetShape())
+	}
+	mean, _ = engine.Mean(x, []int{0}, false)
+	if !reflect.DeepEqual(mean.GetData(), []float64{2.5, 3.5, 4.5}) {
+		t.Errorf("Mean() over axis 0 = %v", mean.GetData())
+	}
+
+	max, _ := engine.Max(x, []int{1}, false)
+	if !reflect.DeepEqual(max.GetData(), []float64{5, 6}) {
+		t.Errorf("Max() = %v", max.GetData())
+	}
+	min, _ := engine.Min(x, nil, false)
+	if !reflect.DeepEqual(min.GetData(), []float64{1}) {
+		t.Errorf("Min() = %v", min.GetData())
+	}
+}
+
+func TestArgMaxArgMin(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 5, 3, 4, 2, 6, 9, 0, 9}, []int{3, 3})
+
+	argmax, err := engine.ArgMax(x, 1, false)
+	if err != nil {
+		t.Fatalf("ArgMax() returned error: %v", err)
+	}
+	// Ties resolve to the first occurrence
+	if !reflect.DeepEqual(argmax.GetData(), []float64{1, 2, 0}) {
+		t.Errorf("ArgMax() = %v", argmax.GetData())
+	}
+	argmin, _ := engine.ArgMin(x, 0, true)
+	if !reflect.DeepEqual(argmin.GetData(), []float64{0, 2, 0}) || !reflect.DeepEqual(argmin.GetShape(), []int{1, 3}) {
+		t.Errorf("ArgMin() = %v with shape %v", argmin.GetData(), argmin.GetShape())
+	}
+	if _, err := engine.ArgMax(x, 2, false); err == nil {
+		t.Error("expected error for out of range axis")
+	}
+}
+
+func TestVarStd(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{2, 4, 4, 4, 5, 5, 7, 9}, []int{2, 4})
+
+	v, _ := engine.Var(x, nil, 0, false)
+	if v.GetData()[0] != 4 {
+		t.Errorf("Var() = %v, want 4", v.GetData())
+	}
+	s, _ := engine.Std(x, nil, 0, false)
+	if s.GetData()[0] != 2 {
+		t.Errorf("Std() = %v, want 2", s.GetData())
+	}
+	v, _ = engine.Var(x, []int{1}, 1, false)
+	if !almostEqual(v.GetData(), []float64{1, 11.0 / 3}, 1e-12) {
+		t.Errorf("Var() with ddof 1 = %v", v.GetData())
+	}
+	if _, err := engine.Var(x, []int{0, 1}, 8, false); err == nil {
+		t.Error("expected error when ddof leaves no degrees of freedom")
+	}
+}
+
+func TestReductionGradients(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 5, 3, 4, 2, 6}, []int{2, 3})
+	x.SetRequiresGrad(true)
+
+	max, _ := engine.Max(x, []int{1}, false)
+	loss, _ := engine.Sum(max, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{0, 1, 0, 0, 0, 1}) {
+		t.Errorf("unexpected Max grad: %v", x.GetGrad().GetData())
+	}
+
+	x.ZeroGrad()
+	std, _ := engine.Std(x, nil, 0, false)
+	std.Backward()
+	data := x.GetData()
+	mean, sd := 3.5, std.GetData()[0]
+	expected := make([]float64, len(data))
+	for i, v := range data {
+		expected[i] = (v - mean) / (float64(len(data)) * sd)
+	}
+	if !almostEqual(x.GetGrad().GetData(), expected, 1e-12) {
+		t.Errorf("unexpected Std grad: got %v, want %v", x.GetGrad().GetData(), expected)
+	}
+	if math.Abs(sd-math.Sqrt(17.5/6)) > 1e-12 {
+		t.Errorf("unexpected Std value %v", sd)
+	}
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+	"github.com/conacts/goten/nn"
+)
+
+func safetensorsFile(header string, data interface{}) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint64(len(header)))
+	buf.WriteString(header)
+	binary.Write(&buf, binary.LittleEndian, data)
+	return buf.Bytes()
+}
+
+func TestSafetensorsRoundTrip(t *testing.T) {
+	w, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	wt, _ := engine.Transpose(w)
+	b, _ := engine.NewFloat32Tensor([]float32{0.5, -1}, []int{2})
+	steps, _ := engine.NewInt64Tensor([]int64{100}, []int{1})
+	mask, _ := engine.NewBoolTensor([]bool{true, false, true}, []int{3})
+	tensors := map[string]*engine.Tensor{"layer.w": w, "layer.wt": wt, "layer.b": b, "steps": steps, "mask": mask}
+
+	var buf bytes.Buffer
+	if err := engine.WriteSafetensors(&buf, tensors, map[string]string{"format": "pt"}); err != nil {
+		t.Fatalf("WriteSafetensors returned error: %v", err)
+	}
+	if n := binary.LittleEndian.Uint64(buf.Bytes()); n%8 != 0 {
+		t.Errorf("header size %d is not a multiple of 8", n)
+	}
+	got, metadata, err := engine.ReadSafetensors(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadSafetensors returned error: %v", err)
+	}
+	if !reflect.DeepEqual(metadata, map[string]string{"format": "pt"}) {
+		t.Errorf("metadata = %v, want map[format:pt]", metadata)
+	}
+	if len(got) != len(tensors) {
+		t.Fatalf("ReadSafetensors returned %d tensors, want %d", len(got), len(tensors))
+	}
+	for name, want := range tensors {
+		g := got[name]
+		if g == nil || g.GetDType() != want.GetDType() || !reflect.DeepEqual(g.GetShape(), want.GetShape()) || !g.Equals(want) {
+			t.Errorf("tensor %s = %v, want %v %v %v", name, g, want.GetDType(), want.GetShape(), want.GetData())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "model.safetensors")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := engine.OpenSafetensors(path)
+	if err != nil {
+		t.Fatalf("OpenSafetensors returned error: %v", err)
+	}
+	if !f.Tensors["layer.w"].Equals(w) || !f.Tensors["mask"].Equals(mask) || f.Metadata["format"] != "pt" {
+		t.Errorf("OpenSafetensors = %v, %v", f.Tensors, f.Metadata)
+	}
+	// Writes to opened tensors must not reach the file
+	f.Tensors["layer.w"].SetData([]float64{0, 0, 0, 0, 0, 0})
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	f, err = engine.OpenSafetensors(path)
+	if err != nil {
+		t.Fatalf("OpenSafetensors returned error: %v", err)
+	}
+	defer f.Close()
+	if !f.Tensors["layer.w"].Equals(w) {
+		t.Errorf("file changed after writing to a loaded tensor: %v", f.Tensors["layer.w"].GetData())
+	}
+}
+
+func TestReadSafetensors(t *testing.T) {
+	// Header as written by the reference implementation, without padding
+	file := safetensorsFile(`{"__metadata__":{"format":"pt"},"weight":{"dtype":"F32","shape":[2,1],"data_offsets":[0,8]}}`, []float32{1.5, 2})
+	got, _, err := engine.ReadSafetensors(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadSafetensors returned error: %v", err)
+	}
+	want, _ := engine.NewFloat32Tensor([]float32{1.5, 2}, []int{2, 1})
+	if !got["weight"].Equals(want) || !reflect.DeepEqual(got["weight"].GetShape(), []int{2, 1}) {
+		t.Errorf("weight = %v %v, want %v", got["weight"].GetShape(), got["weight"].GetData(), want.GetData())
+	}
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"unsupported dtype", `{"x":{"dtype":"BF16","shape":[2],"data_offsets":[0,4]}}`},
+		{"offsets out of range", `{"x":{"dtype":"F32","shape":[4],"data_offsets":[0,16]}}`},
+		{"offsets not matching shape", `{"x":{"dtype":"F32","shape":[1],"data_offsets":[0,8]}}`},
+		{"invalid json", `{"x":`},
+		{"overflowing shape", `{"x":{"dtype":"F32","shape":[4611686018427387904,4],"data_offsets":[0,0]}}`},
+		{"empty shape", `{"x":{"dtype":"F32","shape":[0],"data_offsets":[0,0]}}`},
+	}
+	for _, tt := range tests {
+		if _, _, err := engine.ReadSafetensors(bytes.NewReader(safetensorsFile(tt.header, []float32{1, 2}))); err == nil {
+			t.Errorf("Expected error for %s", tt.name)
+		}
+	}
+	if _, _, err := engine.ReadSafetensors(bytes.NewReader([]byte{1, 2, 3})); err == nil {
+		t.Error("Expected error for truncated file")
+	}
+	if _, err := engine.OpenSafetensors(filepath.Join(t.TempDir(), "missing.safetensors")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestLoadParametersFromSafetensors(t *testing.T) {
+	layer, _ := nn.NewLinearLayer(2, 1)
+	seq, _ := nn.NewSequential(layer)
+
+	// Float32 weights as another framework would write them, in a different order
+	header := `{"0.bias":{"dtype":"F32","shape":[1,1],"data_offsets":[0,4]},"0.weight":{"dtype":"F32","shape":[2,1],"data_offsets":[4,12]}}`
+	tensors, _, err := engine.ReadSafetensors(bytes.NewReader(safetensorsFile(header, []float32{0.5, 2, -3})))
+	if err != nil {
+		t.Fatalf("ReadSafetensors returned error: %v", err)
+	}
+	if err := nn.LoadParameters(seq, tensors); err != nil {
+		t.Fatalf("LoadParameters returned error: %v", err)
+	}
+	if !reflect.DeepEqual(layer.GetWeights().GetData(), []float64{2, -3}) || !reflect.DeepEqual(layer.GetBiases().GetData(), []float64{0.5}) {
+		t.Errorf("loaded weights %v and biases %v, want [2 -3] and [0.5]", layer.GetWeights().GetData(), layer.GetBiases().GetData())
+	}
+	x, _ := engine.NewTensor([]float64{1, 1}, []int{1, 2})
+	if out, _ := seq.Forward(x); out.GetData()[0] != -0.5 {
+		t.Errorf("Forward after loading = %v, want [-0.5]", out.GetData())
+	}
+
+	bad := map[string]map[string]*engine.Tensor{
+		"missing parameter": {"0.weight": tensors["0.weight"]},
+		"unexpected tensor": {"0.weight": tensors["0.weight"], "0.bias": tensors["0.bias"], "1.weight": tensors["0.weight"]},
+		"mismatched shape":  {"0.weight": tensors["0.bias"], "0.bias": tensors["0.bias"]},
+	}
+	for name, tensors := range bad {
+		if err := nn.LoadParameters(seq, tensors); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
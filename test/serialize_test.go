@@ -0,0 +1,171 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestSaveLoad(t *testing.T) {
+	f64, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	view, _ := engine.Transpose(f64)
+	f32, _ := engine.NewFloat32Tensor([]float32{1.5, -2}, []int{2})
+	i64, _ := engine.NewInt64Tensor([]int64{7, -1, 1 << 40}, []int{3, 1})
+	b, _ := engine.NewBoolTensor([]bool{true, false}, []int{1, 2})
+
+	for _, tensor := range []*engine.Tensor{f64, view, f32, i64, b} {
+		var buf bytes.Buffer
+		if err := engine.Save(&buf, tensor); err != nil {
+			t.Fatalf("Save returned error: %v", err)
+		}
+		got, err := engine.Load(&buf)
+		if err != nil {
+			t.Fatalf("Load returned error: %v", err)
+		}
+		if got.GetDType() != tensor.GetDType() || !reflect.DeepEqual(got.GetShape(), tensor.GetShape()) || !got.Equals(tensor) {
+			t.Errorf("Load(Save(%v %v)) = %v %v %v", tensor.GetDType(), tensor.GetData(), got.GetDType(), got.GetShape(), got.GetData())
+		}
+	}
+
+	if _, err := engine.Load(strings.NewReader("NOTATENSOR")); err == nil {
+		t.Error("Expected error for bad magic")
+	}
+	var buf bytes.Buffer
+	engine.Save(&buf, f64)
+	if _, err := engine.Load(bytes.NewReader(buf.Bytes()[:buf.Len()-4])); err == nil {
+		t.Error("Expected error for truncated data")
+	}
+
+	// Corrupt headers fail without allocating the sizes they claim
+	header := func(rank uint32, dims ...uint64) []byte {
+		out := append([]byte("GOTEN"), 1, byte(engine.Float64))
+		out = binary.LittleEndian.AppendUint32(out, rank)
+		for _, dim := range dims {
+			out = binary.LittleEndian.AppendUint64(out, dim)
+		}
+		return append(out, make([]byte, 16)...)
+	}
+	corrupt := map[string][]byte{
+		"huge rank":          header(0xFFFFFFFF),
+		"zero dimension":     header(2, 2, 0),
+		"negative dimension": header(1, 1<<63),
+		"overflowing size":   header(2, 1<<40, 1<<40),
+		"huge size":          header(1, 1<<50),
+	}
+	for name, file := range corrupt {
+		if _, err := engine.Load(bytes.NewReader(file)); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+func TestWriteNpy(t *testing.T) {
+	tensor, _ := engine.NewFloat32Tensor([]float32{1.5, -2, 3, 4}, []int{2, 2})
+	var buf bytes.Buffer
+	if err := engine.WriteNpy(&buf, tensor); err != nil {
+		t.Fatalf("WriteNpy returned error: %v", err)
+	}
+	out := buf.Bytes()
+
+	// Header as written by numpy.save
+	dict := "{'descr': '<f4', 'fortran_order': False, 'shape': (2, 2), }"
+	dict += strings.Repeat(" ", 128-10-len(dict)-1) + "\n"
+	want := append([]byte("\x93NUMPY\x01\x00"), byte(len(dict)), 0)
+	want = append(want, dict...)
+	if !bytes.Equal(out[:128], want) {
+		t.Errorf("npy header = %q, want %q", out[:128], want)
+	}
+	data := make([]float32, 4)
+	binary.Read(bytes.NewReader(out[128:]), binary.LittleEndian, data)
+	if !reflect.DeepEqual(data, []float32{1.5, -2, 3, 4}) {
+		t.Errorf("npy data = %v, want [1.5 -2 3 4]", data)
+	}
+
+	got, err := engine.ReadNpy(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("ReadNpy returned error: %v", err)
+	}
+	if got.GetDType() != engine.Float32 || !got.Equals(tensor) {
+		t.Errorf("ReadNpy = %v %v, want float32 %v", got.GetDType(), got.GetData(), tensor.GetData())
+	}
+
+	vector, _ := engine.NewInt64Tensor([]int64{1, 2, 3}, []int{3})
+	buf.Reset()
+	engine.WriteNpy(&buf, vector)
+	if !strings.Contains(buf.String(), "'shape': (3,)") {
+		t.Errorf("1-D shape not written as a tuple: %q", buf.String())
+	}
+}
+
+func npyFile(header string, data interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x93NUMPY\x01\x00")
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	binary.Write(&buf, binary.LittleEndian, data)
+	return buf.Bytes()
+}
+
+func TestReadNpy(t *testing.T) {
+	file := npyFile("{'descr':'<i8','shape':(2,1),'fortran_order':False}\n", []int64{5, -6})
+	got, err := engine.ReadNpy(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadNpy returned error: %v", err)
+	}
+	if got.GetDType() != engine.Int64 || !reflect.DeepEqual(got.GetShape(), []int{2, 1}) || !reflect.DeepEqual(got.GetInt64Data(), []int64{5, -6}) {
+		t.Errorf("ReadNpy = %v %v %v, want int64 [2 1] [5 -6]", got.GetDType(), got.GetShape(), got.GetInt64Data())
+	}
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"big-endian", "{'descr': '>f8', 'fortran_order': False, 'shape': (1,), }\n"},
+		{"fortran order", "{'descr': '<f8', 'fortran_order': True, 'shape': (1,), }\n"},
+		{"unsupported dtype", "{'descr': '<c16', 'fortran_order': False, 'shape': (1,), }\n"},
+		{"missing shape", "{'descr': '<f8', 'fortran_order': False, }\n"},
+		{"negative dimension", "{'descr': '<f8', 'fortran_order': False, 'shape': (-1,), }\n"},
+		{"overflowing size", "{'descr': '<f8', 'fortran_order': False, 'shape': (4294967296, 4294967296), }\n"},
+		{"huge size", "{'descr': '<f8', 'fortran_order': False, 'shape': (1125899906842624,), }\n"},
+	}
+	for _, tt := range tests {
+		if _, err := engine.ReadNpy(bytes.NewReader(npyFile(tt.header, []float64{1}))); err == nil {
+			t.Errorf("Expected error for %s", tt.name)
+		}
+	}
+}
+
+func TestNpz(t *testing.T) {
+	w, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	b, _ := engine.NewFloat32Tensor([]float32{0.5}, []int{1})
+	var buf bytes.Buffer
+	if err := engine.WriteNpz(&buf, map[string]*engine.Tensor{"w": w, "b": b}); err != nil {
+		t.Fatalf("WriteNpz returned error: %v", err)
+	}
+	got, err := engine.ReadNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadNpz returned error: %v", err)
+	}
+	if len(got) != 2 || !got["w"].Equals(w) || !got["b"].Equals(b) || got["b"].GetDType() != engine.Float32 {
+		t.Errorf("ReadNpz = %v, want w and b", got)
+	}
+
+	// Compressed archives as written by numpy.savez_compressed
+	buf.Reset()
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.CreateHeader(&zip.FileHeader{Name: "x.npy", Method: zip.Deflate})
+	engine.WriteNpy(f, w)
+	zw.Close()
+	got, err = engine.ReadNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadNpz returned error: %v", err)
+	}
+	if !got["x"].Equals(w) {
+		t.Errorf("ReadNpz of compressed archive = %v, want %v", got["x"].GetData(), w.GetData())
+	}
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestConcatStack(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	b, _ := engine.NewTensor([]float64{5, 6}, []int{1, 2})
+	c, _ := engine.NewTensor([]float64{7, 8}, []int{2, 1})
+
+	rows, err := engine.Concat([]*engine.Tensor{a, b}, 0)
+	if err != nil {
+		t.Fatalf("Concat() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(rows.GetShape(), []int{3, 2}) || !reflect.DeepEqual(rows.GetData(), []float64{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("Concat() axis 0 = %v %v", rows.GetShape(), rows.GetData())
+	}
+	cols, _ := engine.Concat([]*engine.Tensor{a, c}, -1)
+	if !reflect.DeepEqual(cols.GetShape(), []int{2, 3}) || !reflect.DeepEqual(cols.GetData(), []float64{1, 2, 7, 3, 4, 8}) {
+		t.Errorf("Concat() axis 1 = %v %v", cols.GetShape(), cols.GetData())
+	}
+	if _, err := engine.Concat([]*engine.Tensor{a, c}, 0); err == nil {
+		t.Error("Expected error for mismatched shapes")
+	}
+
+	x, _ := engine.NewTensor([]float64{1, 2}, []int{2})
+	y, _ := engine.NewTensor([]float64{3, 4}, []int{2})
+	s0, _ := engine.Stack([]*engine.Tensor{x, y}, 0)
+	if !reflect.DeepEqual(s0.GetShape(), []int{2, 2}) || !reflect.DeepEqual(s0.GetData(), []float64{1, 2, 3, 4}) {
+		t.Errorf("Stack() axis 0 = %v %v", s0.GetShape(), s0.GetData())
+	}
+	s1, _ := engine.Stack([]*engine.Tensor{x, y}, 1)
+	if !reflect.DeepEqual(s1.GetShape(), []int{2, 2}) || !reflect.DeepEqual(s1.GetData(), []float64{1, 3, 2, 4}) {
+		t.Errorf("Stack() axis 1 = %v %v", s1.GetShape(), s1.GetData())
+	}
+	if _, err := engine.Stack([]*engine.Tensor{x, a}, 0); err == nil {
+		t.Error("Expected error stacking tensors of different shapes")
+	}
+}
+
+func TestSplitChunk(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, []int{5, 2})
+	parts, err := engine.Split(x, []int{2, 3}, 0)
+	if err != nil {
+		t.Fatalf("Split() returned error: %v", err)
+	}
+	if len(parts) != 2 || !reflect.DeepEqual(parts[1].GetData(), []float64{4, 5, 6, 7, 8, 9}) {
+		t.Errorf("Split() = %v", parts)
+	}
+	if _, err := engine.Split(x, []int{2, 2}, 0); err == nil {
+		t.Error("Expected error for sizes not adding up")
+	}
+
+	chunks, _ := engine.Chunk(x, 2, 0)
+	if len(chunks) != 2 || !reflect.DeepEqual(chunks[0].GetShape(), []int{3, 2}) || !reflect.DeepEqual(chunks[1].GetShape(), []int{2, 2}) {
+		t.Errorf("Chunk() returned %d chunks", len(chunks))
+	}
+	cols, _ := engine.Chunk(x, 2, 1)
+	if !reflect.DeepEqual(cols[1].GetData(), []float64{1, 3, 5, 7, 9}) {
+		t.Errorf("Chunk() along axis 1 = %v", cols[1].GetData())
+	}
+}
+
+func TestRepeatTile(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	r, _ := engine.Repeat(x, 2, 1)
+	if !reflect.DeepEqual(r.GetShape(), []int{2, 4}) || !reflect.DeepEqual(r.GetData(), []float64{1, 1, 2, 2, 3, 3, 4, 4}) {
+		t.Errorf("Repeat() = %v %v", r.GetShape(), r.GetData())
+	}
+	tiled, _ := engine.Tile(x, []int{1, 2})
+	if !reflect.DeepEqual(tiled.GetShape(), []int{2, 4}) || !reflect.DeepEqual(tiled.GetData(), []float64{1, 2, 1, 2, 3, 4, 3, 4}) {
+		t.Errorf("Tile() = %v %v", tiled.GetShape(), tiled.GetData())
+	}
+	lead, _ := engine.Tile(x, []int{2, 1, 1})
+	if !reflect.DeepEqual(lead.GetShape(), []int{2, 2, 2}) || !reflect.DeepEqual(lead.GetData(), []float64{1, 2, 3, 4, 1, 2, 3, 4}) {
+		t.Errorf("Tile() with extra dimension = %v %v", lead.GetShape(), lead.GetData())
+	}
+	short, _ := engine.Tile(x, []int{2})
+	if !reflect.DeepEqual(short.GetShape(), []int{2, 4}) {
+		t.Errorf("Tile() with fewer reps has shape %v", short.GetShape())
+	}
+}
+
+func TestPad(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3}, []int{1, 3})
+	tests := []struct {
+		mode engine.PadMode
+		data []float64
+	}{
+		{engine.PadConstant, []float64{9, 9, 1, 2, 3, 9}},
+		{engine.PadReflect, []float64{3, 2, 1, 2, 3, 2}},
+		{engine.PadReplicate, []float64{1, 1, 1, 2, 3, 3}},
+	}
+	for _, tt := range tests {
+		p, err := engine.Pad(x, [][2]int{{0, 0}, {2, 1}}, tt.mode, 9)
+		if err != nil {
+			t.Fatalf("Pad() mode %d returned error: %v", tt.mode, err)
+		}
+		if !reflect.DeepEqual(p.GetShape(), []int{1, 6}) || !reflect.DeepEqual(p.GetData(), tt.data) {
+			t.Errorf("Pad() mode %d = %v %v, want %v", tt.mode, p.GetShape(), p.GetData(), tt.data)
+		}
+	}
+	if _, err := engine.Pad(x, [][2]int{{0, 0}, {3, 0}}, engine.PadReflect, 0); err == nil {
+		t.Error("Expected error for reflect padding wider than the tensor")
+	}
+	if _, err := engine.Pad(x, [][2]int{{1, 1}}, engine.PadConstant, 0); err == nil {
+		t.Error("Expected error for missing pad widths")
+	}
+}
+
+func TestShapeOpGradients(t *testing.T) {
+	a, _ := engine.NewTensor([]float64{1, 2, 3, 4}, []int{2, 2})
+	b, _ := engine.NewTensor([]float64{5, 6}, []int{2, 1})
+	a.SetRequiresGrad(true)
+	b.SetRequiresGrad(true)
+	w, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+
+	cat, _ := engine.Concat([]*engine.Tensor{a, b}, 1)
+	weighted, _ := engine.Mul(cat, w)
+	loss, _ := engine.Sum(weighted, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(a.GetGrad().GetData(), []float64{1, 2, 4, 5}) || !reflect.DeepEqual(b.GetGrad().GetData(), []float64{3, 6}) {
+		t.Errorf("Concat() grads = %v %v", a.GetGrad().GetData(), b.GetGrad().GetData())
+	}
+
+	// Replicated elements receive the sum of the gradients of their copies
+	x, _ := engine.NewTensor([]float64{1, 2, 3}, []int{3})
+	x.SetRequiresGrad(true)
+	p, _ := engine.Pad(x, [][2]int{{2, 2}}, engine.PadReplicate, 0)
+	tiled, _ := engine.Tile(p, []int{2})
+	loss, _ = engine.Sum(tiled, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{6, 2, 6}) {
+		t.Errorf("Pad()/Tile() grads = %v", x.GetGrad().GetData())
+	}
+
+	x.ZeroGrad()
+	parts, _ := engine.Chunk(x, 3, 0)
+	scaled, _ := engine.Scale(parts[2], 3)
+	loss, _ = engine.Sum(scaled, nil, false)
+	loss.Backward()
+	if !reflect.DeepEqual(x.GetGrad().GetData(), []float64{0, 0, 3}) {
+		t.Errorf("Chunk() grads = %v", x.GetGrad().GetData())
+	}
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestSoftmax(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 1, 1, 1}, []int{2, 3})
+	y, err := engine.Softmax(x, -1)
+	if err != nil {
+		t.Fatalf("Softmax() returned error: %v", err)
+	}
+	z := math.Exp(1) + math.Exp(2) + math.Exp(3)
+	expected := []float64{math.Exp(1) / z, math.Exp(2) / z, math.Exp(3) / z, 1.0 / 3, 1.0 / 3, 1.0 / 3}
+	if !almostEqual(y.GetData(), expected, 1e-12) {
+		t.Errorf("Softmax() = %v, want %v", y.GetData(), expected)
+	}
+
+	cols, _ := engine.Softmax(x, 0)
+	sums, _ := engine.Sum(cols, []int{0}, false)
+	if !almostEqual(sums.GetData(), []float64{1, 1, 1}, 1e-12) {
+		t.Errorf("Softmax() over axis 0 does not sum to 1: %v", sums.GetData())
+	}
+
+	// Large inputs would overflow a naive exp
+	big, _ := engine.NewTensor([]float64{1000, 1001, -1000}, []int{3})
+	s, _ := engine.Softmax(big, 0)
+	for _, v := range s.GetData() {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Fatalf("Softmax() overflowed: %v", s.GetData())
+		}
+	}
+	if _, err := engine.Softmax(x, 2); err == nil {
+		t.Error("Expected error for out of range axis")
+	}
+}
+
+func TestLogSoftmaxLogSumExp(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1000, 1001, 999, -3, 0, 2}, []int{2, 3})
+	lse, err := engine.LogSumExp(x, []int{1}, false)
+	if err != nil {
+		t.Fatalf("LogSumExp() returned error: %v", err)
+	}
+	want := []float64{
+		1001 + math.Log(math.Exp(-1)+1+math.Exp(-2)),
+		math.Log(math.Exp(-3) + 1 + math.Exp(2)),
+	}
+	if !reflect.DeepEqual(lse.GetShape(), []int{2}) || !almostEqual(lse.GetData(), want, 1e-9) {
+		t.Errorf("LogSumExp() = %v %v, want %v", lse.GetShape(), lse.GetData(), want)
+	}
+
+	ls, _ := engine.LogSoftmax(x, 1)
+	for i, v := range ls.GetData() {
+		if got, exp := v, x.GetData()[i]-want[i/3]; math.Abs(got-exp) > 1e-9 {
+			t.Errorf("LogSoftmax()[%d] = %v, want %v", i, got, exp)
+		}
+	}
+
+	inf, _ := engine.NewTensor([]float64{math.Inf(-1), math.Inf(-1)}, []int{2})
+	if v, _ := engine.LogSumExp(inf, nil, false); !math.IsInf(v.GetData()[0], -1) {
+		t.Errorf("LogSumExp() of -Inf = %v, want -Inf", v.GetData())
+	}
+}
+
+func TestSoftmaxGradients(t *testing.T) {
+	data := []float64{0.5, -1, 2, 0.1, 0.3, -0.7}
+	weights, _ := engine.NewTensor([]float64{1, 2, 3, -1, 0.5, 2}, []int{2, 3})
+	tests := []struct {
+		name string
+		op   func(*engine.Tensor) (*engine.Tensor, error)
+	}{
+		{"Softmax", func(x *engine.Tensor) (*engine.Tensor, error) { return engine.Softmax(x, 1) }},
+		{"LogSoftmax", func(x *engine.Tensor) (*engine.Tensor, error) { return engine.LogSoftmax(x, 0) }},
+		{"LogSumExp", func(x *engine.Tensor) (*engine.Tensor, error) { return engine.LogSumExp(x, []int{1}, true) }},
+	}
+	for _, tt := range tests {
+		// Weight the outputs so the gradient of the sum is not trivially zero
+		weighted := func(x *engine.Tensor) (*engine.Tensor, error) {
+			y, err := tt.op(x)
+			if err != nil {
+				return nil, err
+			}
+			return engine.Mul(y, weights)
+		}
+		x, _ := engine.NewTensor(append([]float64(nil), data...), []int{2, 3})
+		x.SetRequiresGrad(true)
+		y, err := weighted(x)
+		if err != nil {
+			t.Fatalf("%s returned error: %v", tt.name, err)
+		}
+		loss, _ := engine.Sum(y, nil, false)
+		if err := loss.Backward(); err != nil {
+			t.Fatalf("%s backward failed: %v", tt.name, err)
+		}
+		expected := numericGrad(weighted, data, []int{2, 3})
+		if !almostEqual(x.GetGrad().GetData(), expected, 1e-6) {
+			t.Errorf("%s gradient = %v, want %v", tt.name, x.GetGrad().GetData(), expected)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/conacts/goten/engine"
+)
+
+func TestTransposeND(t *testing.T) {
+	// shape [2, 3, 2]
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, []int{2, 3, 2})
+
+	// Swap the first and last dimensions
+	res, err := engine.Transpose(x, 0, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected, _ := engine.NewTensor([]float64{1, 7, 3, 9, 5, 11, 2, 8, 4, 10, 6, 12}, []int{2, 3, 2})
+	if !res.Equals(expected) {
+		t.Errorf("Expected %v, but got %v", expected.GetData(), res.GetData())
+	}
+	if !res.IsView() || res.IsContiguous() {
+		t.Error("Expected transpose to return a non-contiguous view")
+	}
+
+	// Without dims the last two dimensions are swapped
+	res, _ = engine.Transpose(x)
+	if !reflect.DeepEqual(res.GetShape(), []int{2, 2, 3}) {
+		t.Errorf("Expected shape [2 2 3], but got %v", res.GetShape())
+	}
+	if v, _ := res.GetValue([]int{1, 0, 2}); v != 11 {
+		t.Errorf("Expected value 11, but got %v", v)
+	}
+
+	if _, err := engine.Transpose(x, 0); err == nil {
+		t.Error("Expected error transposing with a single dimension")
+	}
+}
+
+func TestPermute(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{1, 2, 3})
+	res, err := engine.Permute(x, 2, 0, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(res.GetShape(), []int{3, 1, 2}) {
+		t.Errorf("Expected shape [3 1 2], but got %v", res.GetShape())
+	}
+	if !reflect.DeepEqual(res.GetData(), []float64{1, 4, 2, 5, 3, 6}) {
+		t.Errorf("Unexpected data %v", res.GetData())
+	}
+	if _, err := engine.Permute(x, 0, 0, 1); err == nil {
+		t.Error("Expected error for repeated dimension")
+	}
+	if _, err := engine.Permute(x, 0, 1); err == nil {
+		t.Error("Expected error for wrong number of dimensions")
+	}
+}
+
+func TestSliceSharesData(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, []int{3, 4})
+
+	// Every other column of the last two rows
+	rows, _ := engine.Slice(x, 0, 1, 3, 1)
+	cols, err := engine.Slice(rows, 1, 0, 4, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(cols.GetData(), []float64{4, 6, 8, 10}) {
+		t.Errorf("Unexpected sliced data %v", cols.GetData())
+	}
+
+	// Writes through the view are visible in the original tensor
+	cols.SetValue(-1, []int{1, 1})
+	if v, _ := x.GetValue([]int{2, 2}); v != -1 {
+		t.Errorf("Expected write through view, got %v", v)
+	}
+	cols.SetData([]float64{100, 101, 102, 103})
+	if !reflect.DeepEqual(x.GetData()[4:], []float64{100, 5, 101, 7, 102, 9, 103, 11}) {
+		t.Errorf("Expected SetData to write through view, got %v", x.GetData())
+	}
+
+	// SetData on the base writes into the buffer its views share
+	data := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	x.SetData(data)
+	data[0] = -5
+	if !reflect.DeepEqual(cols.GetData(), []float64{4, 6, 8, 10}) || x.GetData()[0] != 0 {
+		t.Errorf("Expected SetData to copy into the shared buffer, got view %v and base %v", cols.GetData(), x.GetData())
+	}
+
+	// Negative bounds count from the end
+	last, _ := engine.Slice(x, -1, -1, 4, 1)
+	if !reflect.DeepEqual(last.GetData(), []float64{3, 7, 11}) {
+		t.Errorf("Unexpected data for last column %v", last.GetData())
+	}
+	if _, err := engine.Slice(x, 0, 2, 2, 1); err == nil {
+		t.Error("Expected error for empty slice")
+	}
+
+	narrow, _ := engine.Narrow(x, 1, 1, 2)
+	if !reflect.DeepEqual(narrow.GetData(), []float64{1, 2, 5, 6, 9, 10}) {
+		t.Errorf("Unexpected narrowed data %v", narrow.GetData())
+	}
+	if _, err := engine.Narrow(x, 1, 3, 2); err == nil {
+		t.Error("Expected error narrowing past the end of the axis")
+	}
+}
+
+func TestSqueezeUnsqueezeExpand(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3}, []int{3})
+
+	u, _ := engine.Unsqueeze(x, 0)
+	if !reflect.DeepEqual(u.GetShape(), []int{1, 3}) {
+		t.Errorf("Expected shape [1 3], but got %v", u.GetShape())
+	}
+	u, _ = engine.Unsqueeze(x, -1)
+	if !reflect.DeepEqual(u.GetShape(), []int{3, 1}) {
+		t.Errorf("Expected shape [3 1], but got %v", u.GetShape())
+	}
+
+	e, err := engine.Expand(u, []int{2, 3, 4})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(e.GetShape(), []int{2, 3, 4}) {
+		t.Errorf("Expected shape [2 3 4], but got %v", e.GetShape())
+	}
+	if v, _ := e.GetValue([]int{1, 2, 3}); v != 3 {
+		t.Errorf("Expected value 3, but got %v", v)
+	}
+	if _, err := engine.Expand(u, []int{2, 4}); err == nil {
+		t.Error("Expected error expanding a dimension that is not 1")
+	}
+
+	s, _ := engine.Squeeze(u)
+	if !reflect.DeepEqual(s.GetShape(), []int{3}) {
+		t.Errorf("Expected shape [3], but got %v", s.GetShape())
+	}
+	if _, err := engine.Squeeze(u, 0); err == nil {
+		t.Error("Expected error squeezing a dimension that is not 1")
+	}
+}
+
+func TestContiguousAndReshape(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	tr, _ := engine.Transpose(x)
+
+	c := tr.Contiguous()
+	if !c.IsContiguous() || c.IsView() {
+		t.Error("Expected Contiguous to return a packed tensor")
+	}
+	if !reflect.DeepEqual(c.GetData(), []float64{1, 4, 2, 5, 3, 6}) {
+		t.Errorf("Unexpected contiguous data %v", c.GetData())
+	}
+	if x.Contiguous() != x {
+		t.Error("Expected Contiguous on a packed tensor to return the tensor itself")
+	}
+
+	if err := tr.Reshape([]int{6}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(tr.GetData(), []float64{1, 4, 2, 5, 3, 6}) {
+		t.Errorf("Unexpected reshaped data %v", tr.GetData())
+	}
+}
+
+func TestViewGradients(t *testing.T) {
+	x, _ := engine.NewTensor([]float64{1, 2, 3, 4, 5, 6}, []int{2, 3})
+	x.SetRequiresGrad(true)
+
+	// Use the first column, expanded across 4 columns, and a transposed copy of x
+	col, _ := engine.Slice(x, 1, 0, 1, 1)
+	expanded, _ := engine.Expand(col, []int{2, 4})
+	tr, _ := engine.Transpose(x)
+	w, _ := engine.NewTensor([]float64{1, 2, 3}, []int{3, 1})
+	weighted, _ := engine.Mul(tr, w)
+
+	s1, _ := engine.Sum(expanded, nil, false)
+	s2, _ := engine.Sum(weighted, nil, false)
+	loss, _ := engine.Add(s1, s2)
+	if err := loss.Backward(); err != nil {
+		t.Fatalf("Backward failed: %v", err)
+	}
+
+	expected := []float64{4 + 1, 2, 3, 4 + 1, 2, 3}
+	if !reflect.DeepEqual(x.GetGrad().GetData(), expected) {
+		t.Errorf("unexpected grad through views: got %v, want %v", x.GetGrad().GetData(), expected)
+	}
+}